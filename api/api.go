@@ -21,11 +21,14 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/Peripli/service-manager/api/visibility"
 
 	"github.com/Peripli/service-manager/api/broker"
+	"github.com/Peripli/service-manager/api/operation"
 	"github.com/Peripli/service-manager/api/platform"
+	"github.com/Peripli/service-manager/api/report"
 
 	"github.com/Peripli/service-manager/api/service_offering"
 	"github.com/Peripli/service-manager/api/service_plan"
@@ -33,10 +36,12 @@ import (
 	"github.com/Peripli/service-manager/api/filters"
 	"github.com/Peripli/service-manager/api/filters/authn/basic"
 	"github.com/Peripli/service-manager/api/filters/authn/oauth"
+	"github.com/Peripli/service-manager/api/filters/authz"
 	"github.com/Peripli/service-manager/api/info"
 	"github.com/Peripli/service-manager/api/osb"
 	"github.com/Peripli/service-manager/pkg/health"
 	"github.com/Peripli/service-manager/pkg/security"
+	"github.com/Peripli/service-manager/pkg/security/authorizers"
 	secfilters "github.com/Peripli/service-manager/pkg/security/filters"
 	"github.com/Peripli/service-manager/pkg/web"
 	"github.com/Peripli/service-manager/storage"
@@ -49,6 +54,35 @@ type Settings struct {
 	ClientID          string `mapstructure:"client_id"`
 	SkipSSLValidation bool   `mapstructure:"skip_ssl_validation"`
 	TokenBasicAuth    bool   `mapstructure:"token_basic_auth"`
+
+	CatalogResyncInterval time.Duration `mapstructure:"catalog_resync_interval"`
+	CatalogHistoryLimit   int           `mapstructure:"catalog_history_limit"`
+
+	ProtectPlansWithVisibilities bool          `mapstructure:"protect_plans_with_visibilities"`
+	ProtectedPlansGracePeriod    time.Duration `mapstructure:"protected_plans_grace_period"`
+
+	OperationsPoolSize  int `mapstructure:"operations_pool_size"`
+	OperationsQueueSize int `mapstructure:"operations_queue_size"`
+
+	BrokerHealthIndicators    bool    `mapstructure:"broker_health_indicators"`
+	BrokerHealthDownThreshold float64 `mapstructure:"broker_health_down_threshold"`
+
+	PublicPlansPolicy                 string        `mapstructure:"public_plans_policy"`
+	PublicPlansReconciliationInterval time.Duration `mapstructure:"public_plans_reconciliation_interval"`
+
+	ExpiredVisibilitiesCleanupInterval time.Duration `mapstructure:"expired_visibilities_cleanup_interval"`
+
+	PlatformCredentialsOverlap time.Duration `mapstructure:"platform_credentials_overlap"`
+
+	BasicAuthMaxFailedAttempts          int           `mapstructure:"basic_auth_max_failed_attempts"`
+	BasicAuthMaxFailedAttemptsPerClient int           `mapstructure:"basic_auth_max_failed_attempts_per_client"`
+	BasicAuthLockoutDuration            time.Duration `mapstructure:"basic_auth_lockout_duration"`
+	BasicAuthMaxLockoutDuration         time.Duration `mapstructure:"basic_auth_max_lockout_duration"`
+	BasicAuthTrustedProxies             string        `mapstructure:"basic_auth_trusted_proxies"`
+
+	AuthorizationClaim  string `mapstructure:"authorization_claim"`
+	AuthorizationRoles  string `mapstructure:"authorization_roles"`
+	PlatformPermissions string `mapstructure:"platform_permissions"`
 }
 
 // DefaultSettings returns default values for API settings
@@ -58,6 +92,35 @@ func DefaultSettings() *Settings {
 		ClientID:          "",
 		SkipSSLValidation: false,
 		TokenBasicAuth:    true, // RFC 6749 section 2.3.1
+
+		CatalogResyncInterval: time.Hour,
+		CatalogHistoryLimit:   20,
+
+		ProtectPlansWithVisibilities: false,
+		ProtectedPlansGracePeriod:    7 * 24 * time.Hour,
+
+		OperationsPoolSize:  10,
+		OperationsQueueSize: 100,
+
+		BrokerHealthIndicators:    false,
+		BrokerHealthDownThreshold: 50,
+
+		PublicPlansPolicy:                 "",
+		PublicPlansReconciliationInterval: 10 * time.Minute,
+
+		ExpiredVisibilitiesCleanupInterval: 5 * time.Minute,
+
+		PlatformCredentialsOverlap: 0,
+
+		BasicAuthMaxFailedAttempts:          5,
+		BasicAuthMaxFailedAttemptsPerClient: 20,
+		BasicAuthLockoutDuration:            time.Minute,
+		BasicAuthMaxLockoutDuration:         time.Hour,
+		BasicAuthTrustedProxies:             "",
+
+		AuthorizationClaim:  "scope",
+		AuthorizationRoles:  "service_manager.admin=*;service_manager.read=*:read",
+		PlatformPermissions: "platforms:read,brokers:read,service_offerings:read,service_plans:read,visibilities:read",
 	}
 }
 
@@ -66,6 +129,62 @@ func (s *Settings) Validate() error {
 	if (len(s.TokenIssuerURL)) == 0 {
 		return fmt.Errorf("validate Settings: APITokenIssuerURL missing")
 	}
+	if s.CatalogResyncInterval < 0 {
+		return fmt.Errorf("validate Settings: APICatalogResyncInterval must not be negative")
+	}
+	if s.CatalogHistoryLimit < 0 {
+		return fmt.Errorf("validate Settings: APICatalogHistoryLimit must not be negative")
+	}
+	if s.ProtectedPlansGracePeriod < 0 {
+		return fmt.Errorf("validate Settings: APIProtectedPlansGracePeriod must not be negative")
+	}
+	if s.OperationsPoolSize <= 0 {
+		return fmt.Errorf("validate Settings: APIOperationsPoolSize must be positive")
+	}
+	if s.OperationsQueueSize < 0 {
+		return fmt.Errorf("validate Settings: APIOperationsQueueSize must not be negative")
+	}
+	if s.BrokerHealthDownThreshold <= 0 || s.BrokerHealthDownThreshold > 100 {
+		return fmt.Errorf("validate Settings: APIBrokerHealthDownThreshold must be a percentage greater than 0")
+	}
+	if s.PublicPlansPolicy != "" {
+		if _, err := filters.NewPublicPlansPolicy(s.PublicPlansPolicy); err != nil {
+			return fmt.Errorf("validate Settings: APIPublicPlansPolicy: %s", err)
+		}
+	}
+	if s.PublicPlansReconciliationInterval < 0 {
+		return fmt.Errorf("validate Settings: APIPublicPlansReconciliationInterval must not be negative")
+	}
+	if s.ExpiredVisibilitiesCleanupInterval < 0 {
+		return fmt.Errorf("validate Settings: APIExpiredVisibilitiesCleanupInterval must not be negative")
+	}
+	if s.PlatformCredentialsOverlap < 0 {
+		return fmt.Errorf("validate Settings: APIPlatformCredentialsOverlap must not be negative")
+	}
+	if s.BasicAuthMaxFailedAttempts < 0 {
+		return fmt.Errorf("validate Settings: APIBasicAuthMaxFailedAttempts must not be negative")
+	}
+	if s.BasicAuthMaxFailedAttemptsPerClient < 0 {
+		return fmt.Errorf("validate Settings: APIBasicAuthMaxFailedAttemptsPerClient must not be negative")
+	}
+	if s.BasicAuthLockoutDuration <= 0 {
+		return fmt.Errorf("validate Settings: APIBasicAuthLockoutDuration must be positive")
+	}
+	if s.BasicAuthMaxLockoutDuration < s.BasicAuthLockoutDuration {
+		return fmt.Errorf("validate Settings: APIBasicAuthMaxLockoutDuration must not be less than APIBasicAuthLockoutDuration")
+	}
+	if _, err := basic.ParseTrustedProxies(s.BasicAuthTrustedProxies); err != nil {
+		return fmt.Errorf("validate Settings: APIBasicAuthTrustedProxies: %s", err)
+	}
+	if len(s.AuthorizationClaim) == 0 {
+		return fmt.Errorf("validate Settings: APIAuthorizationClaim missing")
+	}
+	if _, err := authorizers.ParseRoles(s.AuthorizationRoles); err != nil {
+		return fmt.Errorf("validate Settings: APIAuthorizationRoles: %s", err)
+	}
+	if _, err := authorizers.ParsePermissions(s.PlatformPermissions); err != nil {
+		return fmt.Errorf("validate Settings: APIPlatformPermissions: %s", err)
+	}
 	return nil
 }
 
@@ -75,53 +194,129 @@ func New(ctx context.Context, repository storage.Repository, settings *Settings,
 	if err != nil {
 		return nil, err
 	}
-	return &web.API{
+	brokerController, err := NewBrokerController(repository, settings, encrypter)
+	if err != nil {
+		return nil, err
+	}
+	brokerController.Scheduler = operation.NewScheduler(ctx, repository, settings.OperationsPoolSize, settings.OperationsQueueSize)
+	brokerFetcher := &osb.StorageBrokerFetcher{
+		BrokerStorage: repository.Broker(),
+		Encrypter:     encrypter,
+	}
+	catalogFetcher := &osb.StorageCatalogFetcher{
+		CatalogStorage:         repository.ServiceOffering(),
+		CatalogOverrideStorage: repository.CatalogOverride(),
+		BrokerStorage:          repository.Broker(),
+	}
+	roles, err := authorizers.ParseRoles(settings.AuthorizationRoles)
+	if err != nil {
+		return nil, err
+	}
+	platformPermissions, err := authorizers.ParsePermissions(settings.PlatformPermissions)
+	if err != nil {
+		return nil, err
+	}
+	rbacAuthzFilter := authz.NewRBACFilter(settings.AuthorizationClaim, roles, platformPermissions)
+	trustedProxies, err := basic.ParseTrustedProxies(settings.BasicAuthTrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	basicAuthLockouts := basic.NewLockouts(basic.LockoutSettings{
+		MaxFailedAttemptsPerUser:   settings.BasicAuthMaxFailedAttempts,
+		MaxFailedAttemptsPerClient: settings.BasicAuthMaxFailedAttemptsPerClient,
+		Duration:                   settings.BasicAuthLockoutDuration,
+		MaxDuration:                settings.BasicAuthMaxLockoutDuration,
+		TrustedProxies:             trustedProxies,
+	})
+	apiFilters := []web.Filter{
+		&filters.Logging{},
+		basic.NewFilter(repository.Credentials(), encrypter, basicAuthLockouts),
+		bearerAuthnFilter,
+		secfilters.NewRequiredAuthnFilter(),
+		rbacAuthzFilter,
+		secfilters.NewRequiredAuthzFilter(rbacAuthzFilter.FilterMatchers()),
+		&filters.SelectionCriteria{},
+	}
+	smAPI := &web.API{
 		// Default controllers - more filters can be registered using the relevant API methods
 		Controllers: []web.Controller{
-			&broker.Controller{
-				Repository:          repository,
-				OSBClientCreateFunc: newOSBClient(settings.SkipSSLValidation),
-				Encrypter:           encrypter,
-			},
+			brokerController,
 			&platform.Controller{
-				PlatformStorage: repository.Platform(),
-				Encrypter:       encrypter,
+				PlatformStorage:    repository.Platform(),
+				Repository:         repository,
+				CredentialsOverlap: settings.PlatformCredentialsOverlap,
 			},
 			&service_offering.Controller{
 				ServiceOfferingStorage: repository.ServiceOffering(),
+				CatalogOverrideStorage: repository.CatalogOverride(),
 			},
 			&service_plan.Controller{
-				ServicePlanStorage: repository.ServicePlan(),
+				ServicePlanStorage:     repository.ServicePlan(),
+				ServiceOfferingStorage: repository.ServiceOffering(),
+				CatalogOverrideStorage: repository.CatalogOverride(),
 			},
 			&visibility.Controller{
 				Repository: repository,
 			},
+			&operation.Controller{
+				OperationStorage: repository.Operation(),
+			},
+			&report.Controller{
+				Repository: repository,
+			},
 			&info.Controller{
 				TokenIssuer:    settings.TokenIssuerURL,
 				TokenBasicAuth: settings.TokenBasicAuth,
 			},
-			osb.NewController(&osb.StorageBrokerFetcher{
-				BrokerStorage: repository.Broker(),
-				Encrypter:     encrypter,
-			}, &osb.StorageCatalogFetcher{
-				CatalogStorage: repository.ServiceOffering(),
-			},
-				http.DefaultTransport,
-			),
+			// the aggregated broker routes must take precedence over the routes of the individual brokers
+			osb.NewAggregatedController(repository, brokerFetcher, catalogFetcher),
+			osb.NewController(brokerFetcher, catalogFetcher, http.DefaultTransport),
 		},
 		// Default filters - more filters can be registered using the relevant API methods
-		Filters: []web.Filter{
-			&filters.Logging{},
-			basic.NewFilter(repository.Credentials(), encrypter),
-			bearerAuthnFilter,
-			secfilters.NewRequiredAuthnFilter(),
-			&filters.SelectionCriteria{},
-		},
+		Filters:  apiFilters,
 		Registry: health.NewDefaultRegistry(),
+	}
+	smAPI.AddHealthIndicator(basicAuthLockouts)
+	return smAPI, nil
+}
+
+// NewBrokerController returns the controller that manages the service brokers and their catalogs. The public plans of
+// the brokers are reconciled whenever their catalogs are stored if a public plans policy is configured.
+func NewBrokerController(repository storage.Repository, settings *Settings, encrypter security.Encrypter) (*broker.Controller, error) {
+	brokerController := &broker.Controller{
+		Repository:                   repository,
+		OSBClientCreateFunc:          NewOSBClient(settings.SkipSSLValidation),
+		Encrypter:                    encrypter,
+		SkipSSLValidation:            settings.SkipSSLValidation,
+		ProtectPlansWithVisibilities: settings.ProtectPlansWithVisibilities,
+		ProtectedPlansGracePeriod:    settings.ProtectedPlansGracePeriod,
+		CatalogHistoryLimit:          settings.CatalogHistoryLimit,
+	}
+	if settings.PublicPlansPolicy != "" {
+		publicPlansFilter, err := NewPublicServicePlansFilter(repository, settings)
+		if err != nil {
+			return nil, err
+		}
+		brokerController.ReconcilePublicPlansFunc = publicPlansFilter.ReconcilePublicPlans
+	}
+	return brokerController, nil
+}
+
+// NewPublicServicePlansFilter returns the filter that reconciles the public plans of the brokers according to the
+// public plans policy from the settings
+func NewPublicServicePlansFilter(repository storage.Repository, settings *Settings) (*filters.PublicServicePlansFilter, error) {
+	policy, err := filters.NewPublicPlansPolicy(settings.PublicPlansPolicy)
+	if err != nil {
+		return nil, err
+	}
+	return &filters.PublicServicePlansFilter{
+		Repository:              repository,
+		IsCatalogPlanPublicFunc: policy.IsCatalogPlanPublic,
 	}, nil
 }
 
-func newOSBClient(skipSsl bool) osbc.CreateFunc {
+// NewOSBClient returns a function that creates OSB clients for the service brokers
+func NewOSBClient(skipSsl bool) osbc.CreateFunc {
 	return func(configuration *osbc.ClientConfiguration) (osbc.Client, error) {
 		configuration.Insecure = skipSsl
 		return osbc.NewClient(configuration)
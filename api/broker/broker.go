@@ -33,6 +33,13 @@ func (c *Controller) Routes() []web.Route {
 			},
 			Handler: c.createBroker,
 		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodGet,
+				Path:   web.BrokersURL + "/naming_conflicts",
+			},
+			Handler: c.getNamingConflicts,
+		},
 		{
 			Endpoint: web.Endpoint{
 				Method: http.MethodGet,
@@ -68,5 +75,101 @@ func (c *Controller) Routes() []web.Route {
 			},
 			Handler: c.patchBroker,
 		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodDelete,
+				Path:   web.BrokersURL + "/{broker_id}/inactive_plans",
+			},
+			Handler: c.purgeInactivePlans,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodGet,
+				Path:   web.BrokersURL + "/{broker_id}/catalogs",
+			},
+			Handler: c.listCatalogs,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodGet,
+				Path:   web.BrokersURL + "/{broker_id}/catalogs/{catalog_version}",
+			},
+			Handler: c.getCatalog,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodGet,
+				Path:   web.BrokersURL + "/{broker_id}/catalogs/{catalog_version}/diff",
+			},
+			Handler: c.diffCatalogs,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodPut,
+				Path:   web.BrokersURL + "/{broker_id}/pinned_catalog",
+			},
+			Handler: c.pinCatalog,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodDelete,
+				Path:   web.BrokersURL + "/{broker_id}/pinned_catalog",
+			},
+			Handler: c.unpinCatalog,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodPost,
+				Path:   web.BrokersURL + "/refresh",
+			},
+			Handler:      c.refreshBrokers,
+			OptionalBody: true,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodPost,
+				Path:   web.BrokersURL + "/{broker_id}/refresh",
+			},
+			Handler:      c.refreshBroker,
+			OptionalBody: true,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodPost,
+				Path:   web.BrokersURL + "/check",
+			},
+			Handler: c.checkNewBroker,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodPost,
+				Path:   web.BrokersURL + "/{broker_id}/check",
+			},
+			Handler:      c.checkBroker,
+			OptionalBody: true,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodPut,
+				Path:   web.BrokersURL + "/{broker_id}/static_catalog",
+			},
+			Handler: c.setStaticCatalog,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodPost,
+				Path:   web.BrokersURL + "/{broker_id}/suspend",
+			},
+			Handler:      c.suspendBroker,
+			OptionalBody: true,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodPost,
+				Path:   web.BrokersURL + "/{broker_id}/resume",
+			},
+			Handler:      c.resumeBroker,
+			OptionalBody: true,
+		},
 	}
 }
@@ -0,0 +1,340 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package broker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	osbc "github.com/pmorie/go-open-service-broker-client/v2"
+)
+
+const (
+	reachabilityCheck   = "reachability"
+	credentialsCheck    = "credentials"
+	osbVersionCheck     = "osb_version"
+	catalogCheck        = "catalog"
+	tlsCertificateCheck = "tls_certificate"
+
+	brokerCheckTimeout             = 10 * time.Second
+	certificateExpiryWarningPeriod = 30 * 24 * time.Hour
+)
+
+type brokerCheckRequest struct {
+	BrokerURL   string             `json:"broker_url"`
+	Credentials *types.Credentials `json:"credentials"`
+}
+
+// Validate implements InputValidator and verifies all mandatory fields are populated
+func (bcr *brokerCheckRequest) Validate() error {
+	if bcr.BrokerURL == "" {
+		return errors.New("missing broker url")
+	}
+	if bcr.Credentials == nil {
+		return errors.New("missing credentials")
+	}
+	return bcr.Credentials.Validate()
+}
+
+func (c *Controller) checkBroker(r *web.Request) (*web.Response, error) {
+	brokerID := r.PathParams[reqBrokerID]
+	ctx := r.Context()
+	log.C(ctx).Debugf("Checking broker with id %s", brokerID)
+
+	broker, err := c.Repository.Broker().Get(ctx, brokerID)
+	if err != nil {
+		return nil, util.HandleStorageError(err, "broker")
+	}
+	if err := transformBrokerCredentials(ctx, broker, c.Encrypter.Decrypt); err != nil {
+		return nil, err
+	}
+	return util.NewJSONResponse(http.StatusOK, c.brokerCheckReport(ctx, broker))
+}
+
+func (c *Controller) checkNewBroker(r *web.Request) (*web.Response, error) {
+	ctx := r.Context()
+	log.C(ctx).Debug("Checking broker before registration")
+
+	checkRequest := &brokerCheckRequest{}
+	if err := util.BytesToObject(r.Body, checkRequest); err != nil {
+		return nil, err
+	}
+	broker := &types.Broker{
+		BrokerURL:   checkRequest.BrokerURL,
+		Credentials: checkRequest.Credentials,
+	}
+	return util.NewJSONResponse(http.StatusOK, c.brokerCheckReport(ctx, broker))
+}
+
+// brokerCheckReport verifies that the broker is reachable, accepts the credentials, supports the OSB API version used
+// by SM, returns a valid catalog and uses a valid TLS certificate. Checks that depend on a failed check are skipped.
+func (c *Controller) brokerCheckReport(ctx context.Context, broker *types.Broker) *types.BrokerCheckReport {
+	reachability, connectionState := checkReachability(ctx, broker.BrokerURL)
+	checks := []*types.BrokerCheck{reachability}
+	if reachability.Status == types.CheckFailed {
+		checks = append(checks,
+			newBrokerCheck(credentialsCheck, types.CheckSkipped),
+			newBrokerCheck(osbVersionCheck, types.CheckSkipped),
+			newBrokerCheck(catalogCheck, types.CheckSkipped),
+			newBrokerCheck(tlsCertificateCheck, types.CheckSkipped))
+	} else {
+		checks = append(checks, c.checkCatalog(ctx, broker)...)
+		checks = append(checks, c.checkCertificate(broker.BrokerURL, connectionState))
+	}
+
+	report := &types.BrokerCheckReport{
+		Passed: true,
+		Checks: checks,
+	}
+	for _, check := range checks {
+		if check.Status == types.CheckFailed {
+			report.Passed = false
+		}
+	}
+	return report
+}
+
+// checkReachability sends a request to the broker url through the proxy from the environment like the OSB client does.
+// Any response means that the broker is reachable. The TLS connection state is returned for HTTPS brokers.
+func checkReachability(ctx context.Context, brokerURL string) (*types.BrokerCheck, *tls.ConnectionState) {
+	parsedURL, err := url.Parse(brokerURL)
+	if err != nil || parsedURL.Host == "" || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+		return newBrokerCheck(reachabilityCheck, types.CheckFailed, fmt.Sprintf("invalid broker url %s", brokerURL)), nil
+	}
+	request, err := http.NewRequest(http.MethodHead, brokerURL, nil)
+	if err != nil {
+		return newBrokerCheck(reachabilityCheck, types.CheckFailed, fmt.Sprintf("invalid broker url %s: %s", brokerURL, err)), nil
+	}
+
+	// the certificate is verified separately so that its problems are reported by the certificate check
+	transport := newBrokerTransport(true)
+	transport.DisableKeepAlives = true
+	client := &http.Client{
+		Timeout:   brokerCheckTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	response, err := client.Do(request.WithContext(ctx))
+	if err != nil {
+		return newBrokerCheck(reachabilityCheck, types.CheckFailed, fmt.Sprintf("could not connect to %s: %s", parsedURL.Host, err)), nil
+	}
+	response.Body.Close()
+	return newBrokerCheck(reachabilityCheck, types.CheckPassed), response.TLS
+}
+
+// newBrokerTransport returns a transport with the same proxy, dial and TLS settings as the ones of the OSB client
+func newBrokerTransport(insecureSkipVerify bool) *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: insecureSkipVerify,
+		},
+	}
+}
+
+// checkCatalog fetches the broker catalog and returns the outcome of the credentials, OSB version and catalog checks
+func (c *Controller) checkCatalog(ctx context.Context, broker *types.Broker) []*types.BrokerCheck {
+	apiVersion := osbc.DefaultClientConfiguration().APIVersion.HeaderValue()
+	catalog, err := c.fetchCatalog(ctx, broker)
+	if err == nil {
+		catalogStatus := types.CheckPassed
+		catalogProblems := validateCatalog(catalog)
+		if len(catalogProblems) != 0 {
+			catalogStatus = types.CheckFailed
+		}
+		return []*types.BrokerCheck{
+			newBrokerCheck(credentialsCheck, types.CheckPassed),
+			newBrokerCheck(osbVersionCheck, types.CheckPassed, fmt.Sprintf("broker supports OSB API version %s", apiVersion)),
+			newBrokerCheck(catalogCheck, catalogStatus, catalogProblems...),
+		}
+	}
+
+	httpErr, ok := osbc.IsHTTPError(err)
+	if !ok {
+		return []*types.BrokerCheck{
+			newBrokerCheck(credentialsCheck, types.CheckSkipped),
+			newBrokerCheck(osbVersionCheck, types.CheckSkipped),
+			newBrokerCheck(catalogCheck, types.CheckFailed, fmt.Sprintf("could not fetch catalog: %s", err)),
+		}
+	}
+	switch httpErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return []*types.BrokerCheck{
+			newBrokerCheck(credentialsCheck, types.CheckFailed, fmt.Sprintf("broker rejected the credentials with status %d", httpErr.StatusCode)),
+			newBrokerCheck(osbVersionCheck, types.CheckSkipped),
+			newBrokerCheck(catalogCheck, types.CheckSkipped),
+		}
+	case http.StatusPreconditionFailed:
+		return []*types.BrokerCheck{
+			newBrokerCheck(credentialsCheck, types.CheckPassed),
+			newBrokerCheck(osbVersionCheck, types.CheckFailed, fmt.Sprintf("broker does not support OSB API version %s", apiVersion)),
+			newBrokerCheck(catalogCheck, types.CheckSkipped),
+		}
+	default:
+		return []*types.BrokerCheck{
+			newBrokerCheck(credentialsCheck, types.CheckPassed),
+			newBrokerCheck(osbVersionCheck, types.CheckSkipped),
+			newBrokerCheck(catalogCheck, types.CheckFailed, fmt.Sprintf("broker responded to the catalog request with status %d", httpErr.StatusCode)),
+		}
+	}
+}
+
+func (c *Controller) fetchCatalog(ctx context.Context, broker *types.Broker) (*osbc.CatalogResponse, error) {
+	osbClient, err := osbcClient(ctx, c.OSBClientCreateFunc, broker)
+	if err != nil {
+		return nil, err
+	}
+	return osbClient.GetCatalog()
+}
+
+// checkCertificate verifies that the TLS certificate of the broker is trusted and is not about to expire
+func (c *Controller) checkCertificate(brokerURL string, connectionState *tls.ConnectionState) *types.BrokerCheck {
+	if connectionState == nil {
+		return newBrokerCheck(tlsCertificateCheck, types.CheckSkipped, "broker url does not use TLS")
+	}
+	if len(connectionState.PeerCertificates) == 0 {
+		return newBrokerCheck(tlsCertificateCheck, types.CheckFailed, "broker did not present a certificate")
+	}
+
+	certificate := connectionState.PeerCertificates[0]
+	expiry := fmt.Sprintf("certificate expires on %s", util.ToRFCFormat(certificate.NotAfter))
+	if time.Now().After(certificate.NotAfter) {
+		return newBrokerCheck(tlsCertificateCheck, types.CheckFailed, fmt.Sprintf("certificate expired on %s", util.ToRFCFormat(certificate.NotAfter)))
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, intermediate := range connectionState.PeerCertificates[1:] {
+		intermediates.AddCert(intermediate)
+	}
+	parsedURL, _ := url.Parse(brokerURL)
+	if _, err := certificate.Verify(x509.VerifyOptions{
+		DNSName:       parsedURL.Hostname(),
+		Intermediates: intermediates,
+	}); err != nil {
+		// brokers with untrusted certificates can still be used when SSL validation is skipped
+		status := types.CheckFailed
+		if c.SkipSSLValidation {
+			status = types.CheckWarning
+		}
+		return newBrokerCheck(tlsCertificateCheck, status, fmt.Sprintf("certificate is not trusted: %s", err), expiry)
+	}
+
+	if time.Until(certificate.NotAfter) < certificateExpiryWarningPeriod {
+		return newBrokerCheck(tlsCertificateCheck, types.CheckWarning, expiry)
+	}
+	return newBrokerCheck(tlsCertificateCheck, types.CheckPassed, expiry)
+}
+
+// validateCatalog returns the problems of the catalog that violate the OSB specification
+func validateCatalog(catalog *osbc.CatalogResponse) []string {
+	problems := make([]string, 0)
+	if len(catalog.Services) == 0 {
+		problems = append(problems, "catalog contains no services")
+	}
+
+	serviceIDs := make(map[string]bool)
+	serviceNames := make(map[string]bool)
+	planIDs := make(map[string]bool)
+	for serviceIndex, service := range catalog.Services {
+		if service.ID == "" {
+			problems = append(problems, fmt.Sprintf("service at index %d has no id", serviceIndex))
+		} else if serviceIDs[service.ID] {
+			problems = append(problems, fmt.Sprintf("service id %s is not unique", service.ID))
+		}
+		serviceIDs[service.ID] = true
+		if service.Name == "" {
+			problems = append(problems, fmt.Sprintf("service at index %d has no name", serviceIndex))
+		} else if serviceNames[service.Name] {
+			problems = append(problems, fmt.Sprintf("service name %s is not unique", service.Name))
+		}
+		serviceNames[service.Name] = true
+		if len(service.Plans) == 0 {
+			problems = append(problems, fmt.Sprintf("service %s has no plans", service.Name))
+		}
+
+		planNames := make(map[string]bool)
+		for planIndex, plan := range service.Plans {
+			if plan.ID == "" {
+				problems = append(problems, fmt.Sprintf("plan at index %d of service %s has no id", planIndex, service.Name))
+			} else if planIDs[plan.ID] {
+				problems = append(problems, fmt.Sprintf("plan id %s is not unique", plan.ID))
+			}
+			planIDs[plan.ID] = true
+			if plan.Name == "" {
+				problems = append(problems, fmt.Sprintf("plan at index %d of service %s has no name", planIndex, service.Name))
+			} else if planNames[plan.Name] {
+				problems = append(problems, fmt.Sprintf("plan name %s is not unique in service %s", plan.Name, service.Name))
+			}
+			planNames[plan.Name] = true
+			problems = append(problems, validatePlanSchemas(service.Name, &plan)...)
+		}
+	}
+	return problems
+}
+
+func validatePlanSchemas(serviceName string, plan *osbc.Plan) []string {
+	problems := make([]string, 0)
+	validateParameters := func(schemaName string, schema *osbc.InputParametersSchema) {
+		if schema == nil || schema.Parameters == nil {
+			return
+		}
+		if _, isObject := schema.Parameters.(map[string]interface{}); !isObject {
+			problems = append(problems, fmt.Sprintf("%s parameters schema of plan %s of service %s is not a JSON object", schemaName, plan.Name, serviceName))
+		}
+	}
+
+	if plan.Schemas == nil {
+		return problems
+	}
+	if instanceSchemas := plan.Schemas.ServiceInstance; instanceSchemas != nil {
+		validateParameters("service_instance.create", instanceSchemas.Create)
+		validateParameters("service_instance.update", instanceSchemas.Update)
+	}
+	if bindingSchemas := plan.Schemas.ServiceBinding; bindingSchemas != nil && bindingSchemas.Create != nil {
+		validateParameters("service_binding.create", &bindingSchemas.Create.InputParametersSchema)
+	}
+	return problems
+}
+
+func newBrokerCheck(name string, status types.BrokerCheckStatus, details ...string) *types.BrokerCheck {
+	return &types.BrokerCheck{
+		Name:    name,
+		Status:  status,
+		Details: details,
+	}
+}
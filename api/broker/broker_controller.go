@@ -27,6 +27,7 @@ import (
 
 	"github.com/Peripli/service-manager/pkg/query"
 
+	"github.com/Peripli/service-manager/api/operation"
 	"github.com/Peripli/service-manager/pkg/log"
 	"github.com/Peripli/service-manager/pkg/security"
 	osbc "github.com/pmorie/go-open-service-broker-client/v2"
@@ -48,6 +49,24 @@ type Controller struct {
 
 	OSBClientCreateFunc osbc.CreateFunc
 	Encrypter           security.Encrypter
+	// SkipSSLValidation specifies whether the TLS certificates of the brokers are verified
+	SkipSSLValidation bool
+
+	// ProtectPlansWithVisibilities specifies whether plans removed from the broker catalog are deactivated instead of
+	// deleted while they still have visibilities
+	ProtectPlansWithVisibilities bool
+	// ProtectedPlansGracePeriod is the time after which deactivated plans are deleted on the next catalog resync
+	ProtectedPlansGracePeriod time.Duration
+
+	// Scheduler executes the broker registrations that are requested to be asynchronous
+	Scheduler *operation.Scheduler
+
+	// CatalogHistoryLimit is the count of catalog versions kept for each broker. Zero keeps all versions.
+	CatalogHistoryLimit int
+
+	// ReconcilePublicPlansFunc reconciles the public plans of the broker after its catalog is stored. It is not set
+	// when neither a public plans policy nor a public plans filter is configured.
+	ReconcilePublicPlansFunc func(ctx context.Context, txStorage storage.Warehouse, broker *types.Broker) error
 }
 
 var _ web.Controller = &Controller{}
@@ -72,23 +91,75 @@ func (c *Controller) createBroker(r *web.Request) (*web.Response, error) {
 	}
 
 	broker.ID = UUID.String()
+	broker.PinnedCatalogVersion = 0
+	broker.State = types.BrokerActive
 
 	currentTime := time.Now().UTC()
 	broker.CreatedAt = currentTime
 	broker.UpdatedAt = currentTime
 
-	catalog, err := c.getBrokerCatalog(ctx, broker)
+	if isAsync(r) && !isDryRun(r) {
+		scheduledOperation, err := c.Scheduler.Schedule(ctx, types.CreateOperation, web.BrokersURL, func(ctx context.Context) (string, error) {
+			catalog, catalogETag, err := c.getBrokerCatalog(ctx, broker)
+			if err != nil {
+				return "", err
+			}
+			broker.CatalogETag = catalogETag
+			if err := c.registerBroker(ctx, broker, catalog); err != nil {
+				return "", err
+			}
+			return broker.ID, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return operation.NewAcceptedResponse(scheduledOperation)
+	}
+
+	catalog, catalogETag, err := c.getBrokerCatalog(ctx, broker)
 	if err != nil {
 		return nil, err
 	}
+	if isDryRun(r) {
+		return c.catalogDiffResponse(ctx, "", catalog)
+	}
+	broker.CatalogETag = catalogETag
+	if err := c.registerBroker(ctx, broker, catalog); err != nil {
+		return nil, err
+	}
+
+	broker.Credentials = nil
+	return util.NewJSONResponse(http.StatusCreated, broker)
+}
+
+func isAsync(r *web.Request) bool {
+	return r.URL.Query().Get(web.AsyncParam) == "true"
+}
+
+// reconcilePublicPlans reconciles the public plans of the broker if public plans are configured
+func (c *Controller) reconcilePublicPlans(ctx context.Context, txStorage storage.Warehouse, brokerID string) error {
+	if c.ReconcilePublicPlansFunc == nil {
+		return nil
+	}
+	broker, err := txStorage.Broker().Get(ctx, brokerID)
+	if err != nil {
+		return util.HandleStorageError(err, "broker")
+	}
+	return c.ReconcilePublicPlansFunc(ctx, txStorage, broker)
+}
+
+// registerBroker stores the broker together with the service offerings and plans from its catalog
+func (c *Controller) registerBroker(ctx context.Context, broker *types.Broker, catalog *osbc.CatalogResponse) error {
+	broker.CatalogSyncedAt = broker.CreatedAt
+	broker.CatalogSyncError = ""
 
 	if err := transformBrokerCredentials(ctx, broker, c.Encrypter.Encrypt); err != nil {
-		return nil, err
+		return err
 	}
 
-	if err := c.Repository.InTransaction(ctx, func(ctx context.Context, storage storage.Warehouse) error {
-		var brokerID string
-		if brokerID, err = storage.Broker().Create(ctx, broker); err != nil {
+	return c.Repository.InTransaction(ctx, func(ctx context.Context, storage storage.Warehouse) error {
+		brokerID, err := storage.Broker().Create(ctx, broker)
+		if err != nil {
 			return util.HandleStorageError(err, "broker")
 		}
 		for _, service := range catalog.Services {
@@ -149,13 +220,11 @@ func (c *Controller) createBroker(r *web.Request) (*web.Response, error) {
 				}
 			}
 		}
-		return nil
-	}); err != nil {
-		return nil, err
-	}
-
-	broker.Credentials = nil
-	return util.NewJSONResponse(http.StatusCreated, broker)
+		if err := c.recordCatalog(ctx, storage, brokerID, 0, catalog); err != nil {
+			return err
+		}
+		return c.reconcilePublicPlans(ctx, storage, brokerID)
+	})
 }
 
 func (c *Controller) getBroker(r *web.Request) (*web.Response, error) {
@@ -229,6 +298,8 @@ func (c *Controller) patchBroker(r *web.Request) (*web.Response, error) {
 	}
 
 	createdAt := broker.CreatedAt
+	pinnedCatalogVersion := broker.PinnedCatalogVersion
+	state := broker.State
 
 	changes, err := query.LabelChangesFromJSON(r.Body)
 	if err != nil {
@@ -245,11 +316,20 @@ func (c *Controller) patchBroker(r *web.Request) (*web.Response, error) {
 	broker.ID = brokerID
 	broker.CreatedAt = createdAt
 	broker.UpdatedAt = time.Now().UTC()
+	broker.PinnedCatalogVersion = pinnedCatalogVersion
+	broker.State = state
 
-	catalog, err := c.getBrokerCatalog(ctx, broker)
+	catalog, catalogETag, err := c.getBrokerCatalog(ctx, broker)
 	if err != nil {
 		return nil, err
 	}
+	if isDryRun(r) {
+		return c.catalogDiffResponse(ctx, broker.ID, catalog)
+	}
+	broker.CatalogSyncedAt = broker.UpdatedAt
+	broker.CatalogSyncFailedAt = time.Time{}
+	broker.CatalogSyncError = ""
+	broker.CatalogETag = catalogETag
 
 	if err := transformBrokerCredentials(ctx, broker, c.Encrypter.Encrypt); err != nil {
 		return nil, err
@@ -263,6 +343,45 @@ func (c *Controller) patchBroker(r *web.Request) (*web.Response, error) {
 	return util.NewJSONResponse(http.StatusOK, broker)
 }
 
+func (c *Controller) purgeInactivePlans(r *web.Request) (*web.Response, error) {
+	brokerID := r.PathParams[reqBrokerID]
+	ctx := r.Context()
+	log.C(ctx).Debugf("Purging inactive plans of broker with id %s", brokerID)
+
+	if err := c.Repository.InTransaction(ctx, func(ctx context.Context, txStorage storage.Warehouse) error {
+		if _, err := txStorage.Broker().Get(ctx, brokerID); err != nil {
+			return util.HandleStorageError(err, "broker")
+		}
+		serviceOfferings, err := txStorage.ServiceOffering().ListWithServicePlansByBrokerID(ctx, brokerID)
+		if err != nil {
+			return util.HandleStorageError(err, "service_offering")
+		}
+		for _, serviceOffering := range serviceOfferings {
+			hasActivePlans := false
+			for _, servicePlan := range serviceOffering.Plans {
+				if servicePlan.Active {
+					hasActivePlans = true
+					continue
+				}
+				byID := query.ByField(query.EqualsOperator, "id", servicePlan.ID)
+				if err := txStorage.ServicePlan().Delete(ctx, byID); err != nil {
+					return util.HandleStorageError(err, "service_plan")
+				}
+			}
+			if !hasActivePlans {
+				byID := query.ByField(query.EqualsOperator, "id", serviceOffering.ID)
+				if err := txStorage.ServiceOffering().Delete(ctx, byID); err != nil {
+					return util.HandleStorageError(err, "service_offering")
+				}
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return util.NewJSONResponse(http.StatusOK, map[string]string{})
+}
+
 func convertExistingCatalogToMaps(serviceOfferings []*types.ServiceOffering) (map[string]*types.ServiceOffering, map[string]*types.ServicePlan) {
 	serviceOfferingsMap := make(map[string]*types.ServiceOffering)
 	servicePlansMap := make(map[string]*types.ServicePlan)
@@ -277,21 +396,25 @@ func convertExistingCatalogToMaps(serviceOfferings []*types.ServiceOffering) (ma
 	return serviceOfferingsMap, servicePlansMap
 }
 
-func (c *Controller) getBrokerCatalog(ctx context.Context, broker *types.Broker) (*osbc.CatalogResponse, error) {
-	osbClient, err := osbcClient(ctx, c.OSBClientCreateFunc, broker)
-	if err != nil {
-		return nil, err
-	}
-	catalog, err := osbClient.GetCatalog()
-	if err != nil {
-		return nil, &util.HTTPError{
-			ErrorType:   "BrokerError",
-			Description: fmt.Sprintf("error fetching catalog from broker %s: %v", broker.Name, err),
-			StatusCode:  http.StatusBadRequest,
+// getBrokerCatalog returns the catalog of the broker together with its ETag
+func (c *Controller) getBrokerCatalog(ctx context.Context, broker *types.Broker) (*osbc.CatalogResponse, string, error) {
+	if broker.HasStaticCatalog() {
+		log.C(ctx).Debugf("Using the static catalog of broker %s", broker.Name)
+		catalog, err := parseStaticCatalog(broker)
+		if err != nil {
+			return nil, "", err
 		}
+		return catalog, contentETag(broker.StaticCatalog), nil
 	}
+	return c.requestCatalog(ctx, broker, "")
+}
 
-	return catalog, nil
+func catalogFetchError(broker *types.Broker, err error) error {
+	return &util.HTTPError{
+		ErrorType:   "BrokerError",
+		Description: fmt.Sprintf("error fetching catalog from broker %s: %v", broker.Name, err),
+		StatusCode:  http.StatusBadRequest,
+	}
 }
 
 func getBrokerCatalogServicesAndPlans(catalog *osbc.CatalogResponse) ([]*osbc.Service, map[string][]*osbc.Plan, error) {
@@ -361,6 +484,8 @@ func osbcCatalogPlanToServicePlan(servicePlan *types.ServicePlan, plan *catalogP
 	servicePlan.Metadata = json.RawMessage(planMetadataBytes)
 	servicePlan.Schemas = schemasBytes
 	servicePlan.ServiceOfferingID = plan.ServiceOffering.ID
+	servicePlan.Active = true
+	servicePlan.DeactivatedAt = time.Time{}
 
 	return nil
 }
@@ -404,154 +529,231 @@ func (c *Controller) resyncBrokerAndCatalog(ctx context.Context, broker *types.B
 			return util.HandleStorageError(err, "broker")
 		}
 
-		existingServiceOfferingsWithServicePlans, err := txStorage.ServiceOffering().ListWithServicePlansByBrokerID(ctx, broker.ID)
-		if err != nil {
-			return fmt.Errorf("error getting catalog for broker with id %s from SM DB: %s", broker.ID, err)
+		return c.storeCatalog(ctx, txStorage, broker, catalog)
+	}); err != nil {
+		return err
+	}
+	log.C(ctx).Debugf("Successfully updated catalog storage for broker with id %s", broker.ID)
 
-		}
+	return nil
+}
 
-		existingServicesOfferingsMap, existingServicePlansMap := convertExistingCatalogToMaps(existingServiceOfferingsWithServicePlans)
-		log.C(ctx).Debugf("Found %d services and %d plans currently known for broker", len(existingServicesOfferingsMap), len(existingServicePlansMap))
+func (c *Controller) resyncCatalog(ctx context.Context, txStorage storage.Warehouse, brokerID string, catalog *osbc.CatalogResponse) error {
+	existingServiceOfferingsWithServicePlans, err := txStorage.ServiceOffering().ListWithServicePlansByBrokerID(ctx, brokerID)
+	if err != nil {
+		return fmt.Errorf("error getting catalog for broker with id %s from SM DB: %s", brokerID, err)
 
-		catalogServices, catalogPlansMap, err := getBrokerCatalogServicesAndPlans(catalog)
-		if err != nil {
-			return err
-		}
-		log.C(ctx).Debugf("Found %d services and %d plans in catalog for broker with id %s", len(catalogServices), len(catalogPlansMap), broker.ID)
+	}
 
-		catalogPlans := make([]*catalogPlanWithServiceOfferingID, 0)
+	existingServicesOfferingsMap, existingServicePlansMap := convertExistingCatalogToMaps(existingServiceOfferingsWithServicePlans)
+	log.C(ctx).Debugf("Found %d services and %d plans currently known for broker", len(existingServicesOfferingsMap), len(existingServicePlansMap))
 
-		log.C(ctx).Debugf("Resyncing service offerings for broker with id %s...", broker.ID)
-		for _, catalogService := range catalogServices {
-			existingServiceOffering, ok := existingServicesOfferingsMap[catalogService.ID]
-			delete(existingServicesOfferingsMap, catalogService.ID)
-			if ok {
-				if err := osbcCatalogServiceToServiceOffering(existingServiceOffering, catalogService); err != nil {
-					return err
-				}
-				existingServiceOffering.UpdatedAt = time.Now().UTC()
+	catalogServices, catalogPlansMap, err := getBrokerCatalogServicesAndPlans(catalog)
+	if err != nil {
+		return err
+	}
+	log.C(ctx).Debugf("Found %d services and %d plans in catalog for broker with id %s", len(catalogServices), len(catalogPlansMap), brokerID)
 
-				if err := existingServiceOffering.Validate(); err != nil {
-					return &util.HTTPError{
-						ErrorType:   "BadRequest",
-						Description: fmt.Sprintf("service offering constructed during catalog update for broker %s is invalid: %s", broker.ID, err),
-						StatusCode:  http.StatusBadRequest,
-					}
-				}
-				if err := txStorage.ServiceOffering().Update(ctx, existingServiceOffering); err != nil {
-					return util.HandleStorageError(err, "service_offering")
-				}
-			} else {
-				serviceUUID, err := uuid.NewV4()
-				if err != nil {
-					return fmt.Errorf("could not generate GUID for service_plan: %s", err)
-				}
-				existingServiceOffering = &types.ServiceOffering{}
-				if err := osbcCatalogServiceToServiceOffering(existingServiceOffering, catalogService); err != nil {
-					return err
-				}
-				existingServiceOffering.ID = serviceUUID.String()
-				existingServiceOffering.CreatedAt = time.Now().UTC()
-				existingServiceOffering.UpdatedAt = time.Now().UTC()
-				existingServiceOffering.BrokerID = broker.ID
+	catalogPlans := make([]*catalogPlanWithServiceOfferingID, 0)
 
-				if err := existingServiceOffering.Validate(); err != nil {
-					return &util.HTTPError{
-						ErrorType:   "BadRequest",
-						Description: fmt.Sprintf("service offering constructed during catalog update for broker %s is invalid: %s", broker.ID, err),
-						StatusCode:  http.StatusBadRequest,
-					}
-				}
+	log.C(ctx).Debugf("Resyncing service offerings for broker with id %s...", brokerID)
+	for _, catalogService := range catalogServices {
+		existingServiceOffering, ok := existingServicesOfferingsMap[catalogService.ID]
+		delete(existingServicesOfferingsMap, catalogService.ID)
+		if ok {
+			if err := osbcCatalogServiceToServiceOffering(existingServiceOffering, catalogService); err != nil {
+				return err
+			}
+			existingServiceOffering.UpdatedAt = time.Now().UTC()
 
-				var dbServiceID string
-				if dbServiceID, err = txStorage.ServiceOffering().Create(ctx, existingServiceOffering); err != nil {
-					return util.HandleStorageError(err, "service_offering")
+			if err := existingServiceOffering.Validate(); err != nil {
+				return &util.HTTPError{
+					ErrorType:   "BadRequest",
+					Description: fmt.Sprintf("service offering constructed during catalog update for broker %s is invalid: %s", brokerID, err),
+					StatusCode:  http.StatusBadRequest,
 				}
-				existingServiceOffering.ID = dbServiceID
 			}
+			if err := txStorage.ServiceOffering().Update(ctx, existingServiceOffering); err != nil {
+				return util.HandleStorageError(err, "service_offering")
+			}
+		} else {
+			serviceUUID, err := uuid.NewV4()
+			if err != nil {
+				return fmt.Errorf("could not generate GUID for service_plan: %s", err)
+			}
+			existingServiceOffering = &types.ServiceOffering{}
+			if err := osbcCatalogServiceToServiceOffering(existingServiceOffering, catalogService); err != nil {
+				return err
+			}
+			existingServiceOffering.ID = serviceUUID.String()
+			existingServiceOffering.CreatedAt = time.Now().UTC()
+			existingServiceOffering.UpdatedAt = time.Now().UTC()
+			existingServiceOffering.BrokerID = brokerID
 
-			catalogPlansForService := catalogPlansMap[catalogService.ID]
-			for catalogPlanOfCatalogServiceIndex := range catalogPlansForService {
-				catalogPlan := &catalogPlanWithServiceOfferingID{
-					Plan:            catalogPlansForService[catalogPlanOfCatalogServiceIndex],
-					ServiceOffering: existingServiceOffering,
+			if err := existingServiceOffering.Validate(); err != nil {
+				return &util.HTTPError{
+					ErrorType:   "BadRequest",
+					Description: fmt.Sprintf("service offering constructed during catalog update for broker %s is invalid: %s", brokerID, err),
+					StatusCode:  http.StatusBadRequest,
 				}
-				catalogPlans = append(catalogPlans, catalogPlan)
 			}
-		}
 
-		for _, existingServiceOffering := range existingServicesOfferingsMap {
-			byID := query.ByField(query.EqualsOperator, "id", existingServiceOffering.ID)
-			if err := txStorage.ServiceOffering().Delete(ctx, byID); err != nil {
+			var dbServiceID string
+			if dbServiceID, err = txStorage.ServiceOffering().Create(ctx, existingServiceOffering); err != nil {
 				return util.HandleStorageError(err, "service_offering")
 			}
+			existingServiceOffering.ID = dbServiceID
 		}
-		log.C(ctx).Debugf("Successfully resynced service offerings for broker with id %s", broker.ID)
-
-		log.C(ctx).Debugf("Resyncing service plans for broker with id %s", broker.ID)
-		for _, catalogPlan := range catalogPlans {
-			existingServicePlan, ok := existingServicePlansMap[catalogPlan.ID]
-			delete(existingServicePlansMap, catalogPlan.ID)
-			if ok {
-				if err := osbcCatalogPlanToServicePlan(existingServicePlan, catalogPlan); err != nil {
-					return err
-				}
-				existingServicePlan.UpdatedAt = time.Now().UTC()
 
-				if err := existingServicePlan.Validate(); err != nil {
-					return &util.HTTPError{
-						ErrorType:   "BadRequest",
-						Description: fmt.Sprintf("service plan constructed during catalog update for broker %s is invalid: %s", broker.ID, err),
-						StatusCode:  http.StatusBadRequest,
-					}
-				}
+		catalogPlansForService := catalogPlansMap[catalogService.ID]
+		for catalogPlanOfCatalogServiceIndex := range catalogPlansForService {
+			catalogPlan := &catalogPlanWithServiceOfferingID{
+				Plan:            catalogPlansForService[catalogPlanOfCatalogServiceIndex],
+				ServiceOffering: existingServiceOffering,
+			}
+			catalogPlans = append(catalogPlans, catalogPlan)
+		}
+	}
 
-				if err := txStorage.ServicePlan().Update(ctx, existingServicePlan); err != nil {
-					return util.HandleStorageError(err, "service_plan")
-				}
-			} else {
-				planUUID, err := uuid.NewV4()
-				if err != nil {
-					return fmt.Errorf("could not generate GUID for service_plan: %s", err)
-				}
-				servicePlan := &types.ServicePlan{}
-				if err := osbcCatalogPlanToServicePlan(servicePlan, catalogPlan); err != nil {
-					return err
-				}
-				servicePlan.ID = planUUID.String()
-				servicePlan.CreatedAt = time.Now().UTC()
-				servicePlan.UpdatedAt = time.Now().UTC()
-				if err := servicePlan.Validate(); err != nil {
-					return &util.HTTPError{
-						ErrorType:   "BadRequest",
-						Description: fmt.Sprintf("service plan constructed during catalog update for broker %s is invalid: %s", broker.ID, err),
-						StatusCode:  http.StatusBadRequest,
-					}
-				}
+	retainedServiceOfferingIDs, err := c.deactivateProtectedPlans(ctx, txStorage, catalogPlans, existingServicePlansMap)
+	if err != nil {
+		return err
+	}
 
-				if _, err := txStorage.ServicePlan().Create(ctx, servicePlan); err != nil {
-					return util.HandleStorageError(err, "service_plan")
+	for _, existingServiceOffering := range existingServicesOfferingsMap {
+		if retainedServiceOfferingIDs[existingServiceOffering.ID] {
+			continue
+		}
+		byID := query.ByField(query.EqualsOperator, "id", existingServiceOffering.ID)
+		if err := txStorage.ServiceOffering().Delete(ctx, byID); err != nil {
+			return util.HandleStorageError(err, "service_offering")
+		}
+	}
+	log.C(ctx).Debugf("Successfully resynced service offerings for broker with id %s", brokerID)
+
+	log.C(ctx).Debugf("Resyncing service plans for broker with id %s", brokerID)
+	for _, catalogPlan := range catalogPlans {
+		existingServicePlan, ok := existingServicePlansMap[catalogPlan.ID]
+		delete(existingServicePlansMap, catalogPlan.ID)
+		if ok {
+			if err := osbcCatalogPlanToServicePlan(existingServicePlan, catalogPlan); err != nil {
+				return err
+			}
+			existingServicePlan.UpdatedAt = time.Now().UTC()
+
+			if err := existingServicePlan.Validate(); err != nil {
+				return &util.HTTPError{
+					ErrorType:   "BadRequest",
+					Description: fmt.Sprintf("service plan constructed during catalog update for broker %s is invalid: %s", brokerID, err),
+					StatusCode:  http.StatusBadRequest,
 				}
 			}
-		}
 
-		for _, existingServicePlan := range existingServicePlansMap {
-			byID := query.ByField(query.EqualsOperator, "id", existingServicePlan.ID)
-			if err := txStorage.ServicePlan().Delete(ctx, byID); err != nil {
-				if err == util.ErrNotFoundInStorage {
-					// If the service for the plan was deleted, plan would already be gone
-					continue
+			if err := txStorage.ServicePlan().Update(ctx, existingServicePlan); err != nil {
+				return util.HandleStorageError(err, "service_plan")
+			}
+		} else {
+			planUUID, err := uuid.NewV4()
+			if err != nil {
+				return fmt.Errorf("could not generate GUID for service_plan: %s", err)
+			}
+			servicePlan := &types.ServicePlan{}
+			if err := osbcCatalogPlanToServicePlan(servicePlan, catalogPlan); err != nil {
+				return err
+			}
+			servicePlan.ID = planUUID.String()
+			servicePlan.CreatedAt = time.Now().UTC()
+			servicePlan.UpdatedAt = time.Now().UTC()
+			if err := servicePlan.Validate(); err != nil {
+				return &util.HTTPError{
+					ErrorType:   "BadRequest",
+					Description: fmt.Sprintf("service plan constructed during catalog update for broker %s is invalid: %s", brokerID, err),
+					StatusCode:  http.StatusBadRequest,
 				}
+			}
+
+			if _, err := txStorage.ServicePlan().Create(ctx, servicePlan); err != nil {
 				return util.HandleStorageError(err, "service_plan")
 			}
 		}
-		log.C(ctx).Debugf("Successfully resynced service plans for broker with id %s", broker.ID)
+	}
 
-		return nil
-	}); err != nil {
-		return err
+	for _, existingServicePlan := range existingServicePlansMap {
+		byID := query.ByField(query.EqualsOperator, "id", existingServicePlan.ID)
+		if err := txStorage.ServicePlan().Delete(ctx, byID); err != nil {
+			if err == util.ErrNotFoundInStorage {
+				// If the service for the plan was deleted, plan would already be gone
+				continue
+			}
+			return util.HandleStorageError(err, "service_plan")
+		}
 	}
-	log.C(ctx).Debugf("Successfully updated catalog storage for broker with id %s", broker.ID)
+	log.C(ctx).Debugf("Successfully resynced service plans for broker with id %s", brokerID)
 
-	return nil
+	return c.reconcilePublicPlans(ctx, txStorage, brokerID)
+}
+
+// deactivateProtectedPlans deactivates the existing plans that are no longer in the broker catalog but are protected from
+// deletion. The protected plans are removed from the existing plans map and the ids of their service offerings are returned.
+func (c *Controller) deactivateProtectedPlans(ctx context.Context, txStorage storage.Warehouse, catalogPlans []*catalogPlanWithServiceOfferingID, existingServicePlansMap map[string]*types.ServicePlan) (map[string]bool, error) {
+	retainedServiceOfferingIDs := make(map[string]bool)
+	if !c.ProtectPlansWithVisibilities {
+		return retainedServiceOfferingIDs, nil
+	}
+
+	catalogPlanIDs := make(map[string]bool)
+	for _, catalogPlan := range catalogPlans {
+		catalogPlanIDs[catalogPlan.ID] = true
+	}
+	for catalogPlanID, existingServicePlan := range existingServicePlansMap {
+		if catalogPlanIDs[catalogPlanID] {
+			continue
+		}
+		protected, err := c.isPlanProtected(ctx, txStorage, existingServicePlan)
+		if err != nil {
+			return nil, err
+		}
+		if !protected {
+			continue
+		}
+		delete(existingServicePlansMap, catalogPlanID)
+		retainedServiceOfferingIDs[existingServicePlan.ServiceOfferingID] = true
+		if !existingServicePlan.Active {
+			continue
+		}
+
+		log.C(ctx).Infof("Deactivating service plan with id %s as it is no longer in the broker catalog but still has visibilities", existingServicePlan.ID)
+		existingServicePlan.Active = false
+		existingServicePlan.DeactivatedAt = time.Now().UTC()
+		existingServicePlan.UpdatedAt = existingServicePlan.DeactivatedAt
+		if err := txStorage.ServicePlan().Update(ctx, existingServicePlan); err != nil {
+			return nil, util.HandleStorageError(err, "service_plan")
+		}
+	}
+	return retainedServiceOfferingIDs, nil
+}
+
+// isPlanProtected returns whether a plan that is no longer in the broker catalog should be kept. Plans are protected
+// while they have visibilities of their own or of their service offering unless they have been inactive for longer
+// than the grace period.
+func (c *Controller) isPlanProtected(ctx context.Context, txStorage storage.Warehouse, servicePlan *types.ServicePlan) (bool, error) {
+	if !c.ProtectPlansWithVisibilities {
+		return false, nil
+	}
+	if !servicePlan.Active && time.Since(servicePlan.DeactivatedAt) >= c.ProtectedPlansGracePeriod {
+		return false, nil
+	}
+	for _, criterion := range []query.Criterion{
+		query.ByField(query.EqualsOperator, "service_plan_id", servicePlan.ID),
+		query.ByField(query.EqualsOperator, "service_offering_id", servicePlan.ServiceOfferingID),
+	} {
+		visibilities, err := txStorage.Visibility().List(ctx, criterion)
+		if err != nil {
+			return false, util.HandleStorageError(err, "visibility")
+		}
+		if len(visibilities) != 0 {
+			return true, nil
+		}
+	}
+	return false, nil
 }
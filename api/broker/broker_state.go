@@ -0,0 +1,57 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package broker
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+)
+
+func (c *Controller) suspendBroker(r *web.Request) (*web.Response, error) {
+	return c.changeBrokerState(r, types.BrokerSuspended)
+}
+
+func (c *Controller) resumeBroker(r *web.Request) (*web.Response, error) {
+	return c.changeBrokerState(r, types.BrokerActive)
+}
+
+// changeBrokerState moves the broker to the specified state. Its service offerings, plans and visibilities are kept.
+func (c *Controller) changeBrokerState(r *web.Request, state types.BrokerState) (*web.Response, error) {
+	brokerID := r.PathParams[reqBrokerID]
+	ctx := r.Context()
+	log.C(ctx).Debugf("Changing state of broker with id %s to %s", brokerID, state)
+
+	broker, err := c.Repository.Broker().Get(ctx, brokerID)
+	if err != nil {
+		return nil, util.HandleStorageError(err, "broker")
+	}
+	if broker.State != state {
+		broker.State = state
+		broker.UpdatedAt = time.Now().UTC()
+		if err := c.Repository.Broker().Update(ctx, broker); err != nil {
+			return nil, util.HandleStorageError(err, "broker")
+		}
+	}
+
+	broker.Credentials = nil
+	return util.NewJSONResponse(http.StatusOK, broker)
+}
@@ -0,0 +1,250 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
+	osbc "github.com/pmorie/go-open-service-broker-client/v2"
+)
+
+func isDryRun(r *web.Request) bool {
+	return r.URL.Query().Get(web.DryRunParam) == "true"
+}
+
+// catalogDiffResponse returns the changes that storing the catalog for the broker with the specified id would make.
+// An empty broker id denotes a broker that is not registered yet.
+func (c *Controller) catalogDiffResponse(ctx context.Context, brokerID string, catalog *osbc.CatalogResponse) (*web.Response, error) {
+	diff, err := c.catalogDiff(ctx, c.Repository, brokerID, catalog)
+	if err != nil {
+		return nil, err
+	}
+	return util.NewJSONResponse(http.StatusOK, diff)
+}
+
+// catalogDiff computes the changes that resyncCatalog would make when storing the catalog for the broker with the specified id
+func (c *Controller) catalogDiff(ctx context.Context, repository storage.Warehouse, brokerID string, catalog *osbc.CatalogResponse) (*types.CatalogDiff, error) {
+	log.C(ctx).Debugf("Computing catalog diff for broker with id %s", brokerID)
+	existingServiceOfferings := make([]*types.ServiceOffering, 0)
+	if brokerID != "" {
+		var err error
+		existingServiceOfferings, err = repository.ServiceOffering().ListWithServicePlansByBrokerID(ctx, brokerID)
+		if err != nil {
+			return nil, util.HandleStorageError(err, "service_offering")
+		}
+	}
+
+	diff, err := diffCatalog(brokerID, existingServiceOfferings, catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	retainedServiceOfferingIDs := make(map[string]bool)
+	removedServicePlans := make([]*types.ServicePlan, 0, len(diff.RemovedServicePlans))
+	removedServicePlanIDs := make([]string, 0, len(diff.RemovedServicePlans))
+	for _, removedServicePlan := range diff.RemovedServicePlans {
+		protected, err := c.isPlanProtected(ctx, repository, removedServicePlan)
+		if err != nil {
+			return nil, err
+		}
+		if !protected {
+			removedServicePlans = append(removedServicePlans, removedServicePlan)
+			removedServicePlanIDs = append(removedServicePlanIDs, removedServicePlan.ID)
+			continue
+		}
+		retainedServiceOfferingIDs[removedServicePlan.ServiceOfferingID] = true
+		if removedServicePlan.Active {
+			diff.DeactivatedServicePlans = append(diff.DeactivatedServicePlans, removedServicePlan)
+		}
+	}
+	diff.RemovedServicePlans = removedServicePlans
+
+	removedServiceOfferings := make([]*types.ServiceOffering, 0, len(diff.RemovedServiceOfferings))
+	for _, removedServiceOffering := range diff.RemovedServiceOfferings {
+		if !retainedServiceOfferingIDs[removedServiceOffering.ID] {
+			removedServiceOfferings = append(removedServiceOfferings, removedServiceOffering)
+		}
+	}
+	diff.RemovedServiceOfferings = removedServiceOfferings
+
+	if len(removedServicePlanIDs) != 0 {
+		byServicePlanIDs := query.ByField(query.InOperator, "service_plan_id", removedServicePlanIDs...)
+		visibilities, err := repository.Visibility().List(ctx, byServicePlanIDs)
+		if err != nil {
+			return nil, util.HandleStorageError(err, "visibility")
+		}
+		diff.RemovedVisibilities = visibilities
+	}
+	if len(diff.RemovedServiceOfferings) != 0 {
+		removedServiceOfferingIDs := make([]string, 0, len(diff.RemovedServiceOfferings))
+		for _, removedServiceOffering := range diff.RemovedServiceOfferings {
+			removedServiceOfferingIDs = append(removedServiceOfferingIDs, removedServiceOffering.ID)
+		}
+		byServiceOfferingIDs := query.ByField(query.InOperator, "service_offering_id", removedServiceOfferingIDs...)
+		visibilities, err := repository.Visibility().List(ctx, byServiceOfferingIDs)
+		if err != nil {
+			return nil, util.HandleStorageError(err, "visibility")
+		}
+		diff.RemovedVisibilities = append(diff.RemovedVisibilities, visibilities...)
+	}
+
+	return diff, nil
+}
+
+// diffCatalog computes the service offerings and plans that are added, updated and removed when the existing service
+// offerings are replaced with the ones in the catalog. Offerings and plans are matched by their catalog ids.
+func diffCatalog(brokerID string, existingServiceOfferings []*types.ServiceOffering, catalog *osbc.CatalogResponse) (*types.CatalogDiff, error) {
+	existingServicesOfferingsMap, existingServicePlansMap := convertExistingCatalogToMaps(existingServiceOfferings)
+
+	catalogServices, catalogPlansMap, err := getBrokerCatalogServicesAndPlans(catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := newCatalogDiff()
+
+	for _, catalogService := range catalogServices {
+		serviceOffering := &types.ServiceOffering{BrokerID: brokerID}
+		existingServiceOffering, ok := existingServicesOfferingsMap[catalogService.ID]
+		delete(existingServicesOfferingsMap, catalogService.ID)
+		if ok {
+			*serviceOffering = *existingServiceOffering
+			serviceOffering.Plans = nil
+		}
+		if err := osbcCatalogServiceToServiceOffering(serviceOffering, catalogService); err != nil {
+			return nil, err
+		}
+		if !ok {
+			diff.AddedServiceOfferings = append(diff.AddedServiceOfferings, serviceOffering)
+		} else if !serviceOfferingsEqual(existingServiceOffering, serviceOffering) {
+			diff.UpdatedServiceOfferings = append(diff.UpdatedServiceOfferings, serviceOffering)
+		}
+
+		for _, catalogPlan := range catalogPlansMap[catalogService.ID] {
+			servicePlan := &types.ServicePlan{}
+			existingServicePlan, ok := existingServicePlansMap[catalogPlan.ID]
+			delete(existingServicePlansMap, catalogPlan.ID)
+			if ok {
+				*servicePlan = *existingServicePlan
+			}
+			if err := osbcCatalogPlanToServicePlan(servicePlan, &catalogPlanWithServiceOfferingID{
+				Plan:            catalogPlan,
+				ServiceOffering: serviceOffering,
+			}); err != nil {
+				return nil, err
+			}
+			if !ok {
+				diff.AddedServicePlans = append(diff.AddedServicePlans, servicePlan)
+			} else if !servicePlansEqual(existingServicePlan, servicePlan) {
+				diff.UpdatedServicePlans = append(diff.UpdatedServicePlans, servicePlan)
+			}
+		}
+	}
+
+	// existing offerings and plans are iterated in order so that the diff is stable
+	for _, existingServiceOffering := range existingServiceOfferings {
+		if _, ok := existingServicesOfferingsMap[existingServiceOffering.CatalogID]; ok {
+			removedServiceOffering := *existingServiceOffering
+			removedServiceOffering.Plans = nil
+			diff.RemovedServiceOfferings = append(diff.RemovedServiceOfferings, &removedServiceOffering)
+		}
+		for _, existingServicePlan := range existingServiceOffering.Plans {
+			if _, ok := existingServicePlansMap[existingServicePlan.CatalogID]; ok {
+				diff.RemovedServicePlans = append(diff.RemovedServicePlans, existingServicePlan)
+			}
+		}
+	}
+
+	return diff, nil
+}
+
+// newCatalogDiff returns a diff without any changes
+func newCatalogDiff() *types.CatalogDiff {
+	return &types.CatalogDiff{
+		AddedServiceOfferings:   make([]*types.ServiceOffering, 0),
+		UpdatedServiceOfferings: make([]*types.ServiceOffering, 0),
+		RemovedServiceOfferings: make([]*types.ServiceOffering, 0),
+		AddedServicePlans:       make([]*types.ServicePlan, 0),
+		UpdatedServicePlans:     make([]*types.ServicePlan, 0),
+		RemovedServicePlans:     make([]*types.ServicePlan, 0),
+		DeactivatedServicePlans: make([]*types.ServicePlan, 0),
+		RemovedVisibilities:     make([]*types.Visibility, 0),
+	}
+}
+
+func serviceOfferingsEqual(so1, so2 *types.ServiceOffering) bool {
+	return so1.Name == so2.Name &&
+		so1.Description == so2.Description &&
+		so1.Bindable == so2.Bindable &&
+		so1.InstancesRetrievable == so2.InstancesRetrievable &&
+		so1.BindingsRetrievable == so2.BindingsRetrievable &&
+		so1.PlanUpdatable == so2.PlanUpdatable &&
+		so1.CatalogName == so2.CatalogName &&
+		jsonEqual(so1.Tags, so2.Tags) &&
+		jsonEqual(so1.Requires, so2.Requires) &&
+		jsonEqual(so1.Metadata, so2.Metadata)
+}
+
+func servicePlansEqual(sp1, sp2 *types.ServicePlan) bool {
+	return sp1.Name == sp2.Name &&
+		sp1.Description == sp2.Description &&
+		sp1.CatalogName == sp2.CatalogName &&
+		sp1.Free == sp2.Free &&
+		sp1.Bindable == sp2.Bindable &&
+		sp1.PlanUpdatable == sp2.PlanUpdatable &&
+		sp1.ServiceOfferingID == sp2.ServiceOfferingID &&
+		sp1.Active == sp2.Active &&
+		jsonEqual(sp1.Metadata, sp2.Metadata) &&
+		jsonEqual(sp1.Schemas, sp2.Schemas)
+}
+
+// jsonEqual compares two JSON values semantically. Missing values, null and empty objects are considered equal
+// as they are all stored the same way in SM DB
+func jsonEqual(json1, json2 json.RawMessage) bool {
+	value1, err := jsonValue(json1)
+	if err != nil {
+		return false
+	}
+	value2, err := jsonValue(json2)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(value1, value2)
+}
+
+func jsonValue(raw json.RawMessage) (interface{}, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+	if object, ok := value.(map[string]interface{}); ok && len(object) == 0 {
+		return nil, nil
+	}
+	return value, nil
+}
@@ -0,0 +1,145 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package broker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/types"
+	osbc "github.com/pmorie/go-open-service-broker-client/v2"
+)
+
+// fetchCatalogIfChanged fetches the catalog of the broker sending the ETag of the latest fetched catalog in the
+// If-None-Match header. It returns the catalog together with its ETag or a nil catalog when the catalog is unchanged.
+func (c *Controller) fetchCatalogIfChanged(ctx context.Context, broker *types.Broker) (*osbc.CatalogResponse, string, error) {
+	var catalog *osbc.CatalogResponse
+	var etag string
+	var err error
+	if broker.HasStaticCatalog() {
+		catalog, etag, err = c.getBrokerCatalog(ctx, broker)
+	} else {
+		catalog, etag, err = c.requestCatalog(ctx, broker, broker.CatalogETag)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if catalog == nil || etag == broker.CatalogETag {
+		log.C(ctx).Debugf("Catalog of broker %s is unchanged", broker.Name)
+		return nil, broker.CatalogETag, nil
+	}
+	return catalog, etag, nil
+}
+
+// requestCatalog fetches the catalog of the broker the way the OSB client does. If ifNoneMatch is set, it is sent
+// in the If-None-Match header and a nil catalog is returned when the broker responds that the catalog is not
+// modified. The returned ETag is the one provided by the broker or a hash of the catalog content.
+func (c *Controller) requestCatalog(ctx context.Context, broker *types.Broker, ifNoneMatch string) (*osbc.CatalogResponse, string, error) {
+	config := osbc.DefaultClientConfiguration()
+	request, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(broker.BrokerURL, "/")+"/v2/catalog", nil)
+	if err != nil {
+		return nil, "", catalogFetchError(broker, err)
+	}
+	request.Header.Set(osbc.APIVersionHeader, config.APIVersion.HeaderValue())
+	if broker.Credentials != nil && broker.Credentials.Basic != nil {
+		request.SetBasicAuth(broker.Credentials.Basic.Username, broker.Credentials.Basic.Password)
+	}
+	if ifNoneMatch != "" {
+		request.Header.Set("If-None-Match", ifNoneMatch)
+	}
+
+	transport := newBrokerTransport(c.SkipSSLValidation)
+	transport.DisableKeepAlives = true
+	client := &http.Client{
+		Timeout:   time.Duration(config.TimeoutSeconds) * time.Second,
+		Transport: transport,
+	}
+	log.C(ctx).Debugf("Fetching catalog of broker %s accessible at: %s", broker.Name, broker.BrokerURL)
+	response, err := client.Do(request.WithContext(ctx))
+	if err != nil {
+		return nil, "", catalogFetchError(broker, err)
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, "", catalogFetchError(broker, err)
+	}
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		if ifNoneMatch != "" {
+			log.C(ctx).Debugf("Catalog of broker %s is not modified", broker.Name)
+			return nil, ifNoneMatch, nil
+		}
+		fallthrough
+	default:
+		return nil, "", catalogFetchError(broker, catalogStatusCodeError(response.StatusCode, body))
+	}
+
+	catalog := &osbc.CatalogResponse{}
+	if err := json.Unmarshal(body, catalog); err != nil {
+		return nil, "", catalogFetchError(broker, osbc.HTTPStatusCodeError{StatusCode: response.StatusCode, ResponseError: err})
+	}
+	// plan schemas are alpha features of the OSB client which are not enabled by the default configuration
+	if !config.EnableAlphaFeatures {
+		for serviceIndex := range catalog.Services {
+			for planIndex := range catalog.Services[serviceIndex].Plans {
+				catalog.Services[serviceIndex].Plans[planIndex].Schemas = nil
+			}
+		}
+	}
+
+	etag := response.Header.Get("ETag")
+	if etag == "" {
+		etag = contentETag(body)
+	}
+	return catalog, etag, nil
+}
+
+// catalogStatusCodeError returns the error of an unsuccessful catalog response the same way as the OSB client
+func catalogStatusCodeError(statusCode int, body []byte) error {
+	statusCodeErr := osbc.HTTPStatusCodeError{
+		StatusCode: statusCode,
+	}
+	brokerResponse := make(map[string]interface{})
+	if err := json.Unmarshal(body, &brokerResponse); err != nil {
+		statusCodeErr.ResponseError = err
+		return statusCodeErr
+	}
+	if errorMessage, ok := brokerResponse["error"].(string); ok {
+		statusCodeErr.ErrorMessage = &errorMessage
+	}
+	if description, ok := brokerResponse["description"].(string); ok {
+		statusCodeErr.Description = &description
+	}
+	return statusCodeErr
+}
+
+// contentETag identifies a catalog for which the broker provides no ETag by a hash of its content
+func contentETag(content []byte) string {
+	hash := sha256.Sum256(content)
+	return fmt.Sprintf("%q", hex.EncodeToString(hash[:]))
+}
@@ -0,0 +1,326 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
+	"github.com/gofrs/uuid"
+	osbc "github.com/pmorie/go-open-service-broker-client/v2"
+)
+
+const (
+	reqCatalogVersion  = "catalog_version"
+	toCatalogVersion   = "to"
+	brokerCatalogTitle = "broker_catalog"
+)
+
+type pinnedCatalog struct {
+	Version int64 `json:"version"`
+}
+
+// Validate implements InputValidator and verifies all mandatory fields are populated
+func (pc *pinnedCatalog) Validate() error {
+	if pc.Version <= 0 {
+		return errors.New("catalog version must be a positive number")
+	}
+	return nil
+}
+
+func (c *Controller) listCatalogs(r *web.Request) (*web.Response, error) {
+	brokerID := r.PathParams[reqBrokerID]
+	ctx := r.Context()
+	log.C(ctx).Debugf("Getting catalog history of broker with id %s", brokerID)
+
+	if _, err := c.Repository.Broker().Get(ctx, brokerID); err != nil {
+		return nil, util.HandleStorageError(err, "broker")
+	}
+	byBrokerID := query.ByField(query.EqualsOperator, "broker_id", brokerID)
+	brokerCatalogs, err := c.Repository.BrokerCatalog().List(ctx, byBrokerID)
+	if err != nil {
+		return nil, util.HandleStorageError(err, brokerCatalogTitle)
+	}
+	for _, brokerCatalog := range brokerCatalogs {
+		brokerCatalog.Catalog = nil
+	}
+	return util.NewJSONResponse(http.StatusOK, &types.BrokerCatalogs{
+		BrokerCatalogs: brokerCatalogs,
+	})
+}
+
+func (c *Controller) getCatalog(r *web.Request) (*web.Response, error) {
+	brokerID := r.PathParams[reqBrokerID]
+	ctx := r.Context()
+	version, err := parseCatalogVersion(r.PathParams[reqCatalogVersion])
+	if err != nil {
+		return nil, err
+	}
+	log.C(ctx).Debugf("Getting catalog version %d of broker with id %s", version, brokerID)
+
+	brokerCatalog, err := getCatalogVersion(ctx, c.Repository, brokerID, version)
+	if err != nil {
+		return nil, err
+	}
+	return util.NewJSONResponse(http.StatusOK, brokerCatalog)
+}
+
+func (c *Controller) diffCatalogs(r *web.Request) (*web.Response, error) {
+	brokerID := r.PathParams[reqBrokerID]
+	ctx := r.Context()
+	fromVersion, err := parseCatalogVersion(r.PathParams[reqCatalogVersion])
+	if err != nil {
+		return nil, err
+	}
+
+	fromCatalog, err := getCatalogVersion(ctx, c.Repository, brokerID, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	var toCatalog *types.BrokerCatalog
+	if toVersionParam := r.URL.Query().Get(toCatalogVersion); toVersionParam != "" {
+		toVersion, err := parseCatalogVersion(toVersionParam)
+		if err != nil {
+			return nil, err
+		}
+		if toCatalog, err = getCatalogVersion(ctx, c.Repository, brokerID, toVersion); err != nil {
+			return nil, err
+		}
+	} else {
+		if toCatalog, err = getLatestCatalog(ctx, c.Repository, brokerID); err != nil {
+			return nil, err
+		}
+	}
+	log.C(ctx).Debugf("Computing diff between catalog versions %d and %d of broker with id %s", fromCatalog.Version, toCatalog.Version, brokerID)
+
+	fromServiceOfferings, err := catalogServiceOfferings(fromCatalog)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := unmarshalCatalog(toCatalog)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := diffCatalog(brokerID, fromServiceOfferings, catalog)
+	if err != nil {
+		return nil, err
+	}
+	return util.NewJSONResponse(http.StatusOK, diff)
+}
+
+func (c *Controller) pinCatalog(r *web.Request) (*web.Response, error) {
+	brokerID := r.PathParams[reqBrokerID]
+	ctx := r.Context()
+
+	pinned := &pinnedCatalog{}
+	if err := util.BytesToObject(r.Body, pinned); err != nil {
+		return nil, err
+	}
+	log.C(ctx).Debugf("Pinning catalog of broker with id %s to version %d", brokerID, pinned.Version)
+
+	var broker *types.Broker
+	if err := c.Repository.InTransaction(ctx, func(ctx context.Context, txStorage storage.Warehouse) error {
+		brokerCatalog, err := getCatalogVersion(ctx, txStorage, brokerID, pinned.Version)
+		if err != nil {
+			return err
+		}
+		broker, err = c.switchCatalog(ctx, txStorage, brokerID, brokerCatalog, pinned.Version)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	broker.Credentials = nil
+	return util.NewJSONResponse(http.StatusOK, broker)
+}
+
+func (c *Controller) unpinCatalog(r *web.Request) (*web.Response, error) {
+	brokerID := r.PathParams[reqBrokerID]
+	ctx := r.Context()
+	log.C(ctx).Debugf("Unpinning catalog of broker with id %s", brokerID)
+
+	var broker *types.Broker
+	if err := c.Repository.InTransaction(ctx, func(ctx context.Context, txStorage storage.Warehouse) error {
+		brokerCatalog, err := getLatestCatalog(ctx, txStorage, brokerID)
+		if err != nil {
+			return err
+		}
+		broker, err = c.switchCatalog(ctx, txStorage, brokerID, brokerCatalog, 0)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	broker.Credentials = nil
+	return util.NewJSONResponse(http.StatusOK, broker)
+}
+
+// switchCatalog replaces the catalog of the broker in SM DB with the catalog snapshot and pins the broker to the
+// specified version. Version 0 means that the broker is not pinned and future catalog changes are applied.
+func (c *Controller) switchCatalog(ctx context.Context, txStorage storage.Warehouse, brokerID string, brokerCatalog *types.BrokerCatalog, pinnedVersion int64) (*types.Broker, error) {
+	broker, err := txStorage.Broker().Get(ctx, brokerID)
+	if err != nil {
+		return nil, util.HandleStorageError(err, "broker")
+	}
+	catalog, err := unmarshalCatalog(brokerCatalog)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.resyncCatalog(ctx, txStorage, brokerID, catalog); err != nil {
+		return nil, err
+	}
+	broker.PinnedCatalogVersion = pinnedVersion
+	broker.UpdatedAt = time.Now().UTC()
+	if err := txStorage.Broker().Update(ctx, broker); err != nil {
+		return nil, util.HandleStorageError(err, "broker")
+	}
+	return broker, nil
+}
+
+// storeCatalog records the catalog in the catalog history of the broker and resyncs it in SM DB
+// unless the broker is pinned to a previous catalog version
+func (c *Controller) storeCatalog(ctx context.Context, txStorage storage.Warehouse, broker *types.Broker, catalog *osbc.CatalogResponse) error {
+	if err := c.recordCatalog(ctx, txStorage, broker.ID, broker.PinnedCatalogVersion, catalog); err != nil {
+		return err
+	}
+	if broker.PinnedCatalogVersion != 0 {
+		log.C(ctx).Infof("Catalog of broker with id %s is pinned to version %d and will not be resynced", broker.ID, broker.PinnedCatalogVersion)
+		return nil
+	}
+	return c.resyncCatalog(ctx, txStorage, broker.ID, catalog)
+}
+
+// recordCatalog stores a new version of the broker catalog if it differs from the latest stored version. The oldest
+// versions beyond the catalog history limit are removed except for the pinned version.
+func (c *Controller) recordCatalog(ctx context.Context, txStorage storage.Warehouse, brokerID string, pinnedVersion int64, catalog *osbc.CatalogResponse) error {
+	catalogBytes, err := json.Marshal(catalog)
+	if err != nil {
+		return fmt.Errorf("could not marshal catalog of broker with id %s: %s", brokerID, err)
+	}
+
+	version := int64(1)
+	latestCatalog, err := txStorage.BrokerCatalog().GetLatest(ctx, brokerID)
+	if err == nil {
+		if jsonEqual(latestCatalog.Catalog, catalogBytes) {
+			return nil
+		}
+		version = latestCatalog.Version + 1
+	} else if err != util.ErrNotFoundInStorage {
+		return util.HandleStorageError(err, brokerCatalogTitle)
+	}
+
+	UUID, err := uuid.NewV4()
+	if err != nil {
+		return fmt.Errorf("could not generate GUID for broker catalog: %s", err)
+	}
+	log.C(ctx).Debugf("Storing catalog version %d of broker with id %s", version, brokerID)
+	if _, err := txStorage.BrokerCatalog().Create(ctx, &types.BrokerCatalog{
+		ID:        UUID.String(),
+		BrokerID:  brokerID,
+		Version:   version,
+		Catalog:   catalogBytes,
+		CreatedAt: time.Now().UTC(),
+	}); err != nil {
+		return util.HandleStorageError(err, brokerCatalogTitle)
+	}
+
+	if c.CatalogHistoryLimit > 0 {
+		if err := txStorage.BrokerCatalog().Prune(ctx, brokerID, c.CatalogHistoryLimit, pinnedVersion); err != nil {
+			return util.HandleStorageError(err, brokerCatalogTitle)
+		}
+	}
+	return nil
+}
+
+func getCatalogVersion(ctx context.Context, repository storage.Warehouse, brokerID string, version int64) (*types.BrokerCatalog, error) {
+	byBrokerID := query.ByField(query.EqualsOperator, "broker_id", brokerID)
+	byVersion := query.ByField(query.EqualsOperator, "version", strconv.FormatInt(version, 10))
+	brokerCatalogs, err := repository.BrokerCatalog().List(ctx, byBrokerID, byVersion)
+	if err != nil {
+		return nil, util.HandleStorageError(err, brokerCatalogTitle)
+	}
+	if len(brokerCatalogs) == 0 {
+		return nil, util.HandleStorageError(util.ErrNotFoundInStorage, brokerCatalogTitle)
+	}
+	return brokerCatalogs[0], nil
+}
+
+func getLatestCatalog(ctx context.Context, repository storage.Warehouse, brokerID string) (*types.BrokerCatalog, error) {
+	brokerCatalog, err := repository.BrokerCatalog().GetLatest(ctx, brokerID)
+	if err != nil {
+		return nil, util.HandleStorageError(err, brokerCatalogTitle)
+	}
+	return brokerCatalog, nil
+}
+
+func parseCatalogVersion(value string) (int64, error) {
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, &util.HTTPError{
+			ErrorType:   "BadRequest",
+			Description: fmt.Sprintf("invalid catalog version %s", value),
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
+	return version, nil
+}
+
+func unmarshalCatalog(brokerCatalog *types.BrokerCatalog) (*osbc.CatalogResponse, error) {
+	catalog := &osbc.CatalogResponse{}
+	if err := json.Unmarshal(brokerCatalog.Catalog, catalog); err != nil {
+		return nil, fmt.Errorf("could not unmarshal catalog version %d of broker with id %s: %s", brokerCatalog.Version, brokerCatalog.BrokerID, err)
+	}
+	return catalog, nil
+}
+
+// catalogServiceOfferings converts the catalog snapshot to service offerings with their service plans
+func catalogServiceOfferings(brokerCatalog *types.BrokerCatalog) ([]*types.ServiceOffering, error) {
+	catalog, err := unmarshalCatalog(brokerCatalog)
+	if err != nil {
+		return nil, err
+	}
+	serviceOfferings := make([]*types.ServiceOffering, 0, len(catalog.Services))
+	for serviceIndex := range catalog.Services {
+		serviceOffering := &types.ServiceOffering{BrokerID: brokerCatalog.BrokerID}
+		if err := osbcCatalogServiceToServiceOffering(serviceOffering, &catalog.Services[serviceIndex]); err != nil {
+			return nil, err
+		}
+		for planIndex := range catalog.Services[serviceIndex].Plans {
+			servicePlan := &types.ServicePlan{}
+			if err := osbcCatalogPlanToServicePlan(servicePlan, &catalogPlanWithServiceOfferingID{
+				Plan:            &catalog.Services[serviceIndex].Plans[planIndex],
+				ServiceOffering: serviceOffering,
+			}); err != nil {
+				return nil, err
+			}
+			serviceOffering.Plans = append(serviceOffering.Plans, servicePlan)
+		}
+		serviceOfferings = append(serviceOfferings, serviceOffering)
+	}
+	return serviceOfferings, nil
+}
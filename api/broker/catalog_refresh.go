@@ -0,0 +1,164 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package broker
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
+)
+
+func (c *Controller) refreshBroker(r *web.Request) (*web.Response, error) {
+	brokerID := r.PathParams[reqBrokerID]
+	ctx := r.Context()
+	log.C(ctx).Debugf("Refreshing catalog of broker with id %s", brokerID)
+
+	broker, err := c.Repository.Broker().Get(ctx, brokerID)
+	if err != nil {
+		return nil, util.HandleStorageError(err, "broker")
+	}
+	diff, err := c.refreshCatalog(ctx, broker)
+	if err != nil {
+		return nil, err
+	}
+	return util.NewJSONResponse(http.StatusOK, diff)
+}
+
+func (c *Controller) refreshBrokers(r *web.Request) (*web.Response, error) {
+	ctx := r.Context()
+	log.C(ctx).Debug("Refreshing catalogs of brokers")
+
+	criteria, err := query.BuildCriteriaFromRequest(r)
+	if err != nil {
+		return nil, util.HandleSelectionError(err)
+	}
+	// refreshing the catalogs of all brokers at once is left to the catalog resync job
+	if len(criteria) == 0 {
+		return nil, &util.HTTPError{
+			ErrorType:   "BadRequest",
+			Description: "a label query or a field query selecting the brokers to refresh is required",
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
+	brokers, err := c.Repository.Broker().List(ctx, criteria...)
+	if err != nil {
+		return nil, util.HandleSelectionError(err)
+	}
+
+	refreshes := make([]*types.CatalogRefresh, 0, len(brokers))
+	for _, broker := range brokers {
+		// suspended brokers are often being fixed so their catalogs are refreshed once they are resumed
+		if broker.State == types.BrokerSuspended {
+			log.C(ctx).Debugf("Skipping catalog refresh of suspended broker with id %s", broker.ID)
+			continue
+		}
+		refresh := &types.CatalogRefresh{
+			BrokerID: broker.ID,
+		}
+		if refresh.Changes, err = c.refreshCatalog(ctx, broker); err != nil {
+			log.C(ctx).WithError(err).Errorf("Could not refresh catalog of broker with id %s", broker.ID)
+			refresh.Error = err.Error()
+		}
+		refreshes = append(refreshes, refresh)
+	}
+	return util.NewJSONResponse(http.StatusOK, &types.CatalogRefreshes{
+		CatalogRefreshes: refreshes,
+	})
+}
+
+// refreshCatalog fetches the catalog of the broker and resyncs it in SM DB without changing the broker itself.
+// It returns the changes made in SM DB. The time and the error of the catalog sync are recorded on the broker.
+func (c *Controller) refreshCatalog(ctx context.Context, broker *types.Broker) (*types.CatalogDiff, error) {
+	diff, refreshErr := c.fetchAndStoreCatalog(ctx, broker)
+	if refreshErr == nil {
+		return diff, nil
+	}
+
+	// the failed transaction has been rolled back so the error is recorded in a new one
+	if err := c.Repository.InTransaction(ctx, func(ctx context.Context, txStorage storage.Warehouse) error {
+		_, err := recordCatalogSync(ctx, txStorage, broker.ID, refreshErr.Error())
+		return err
+	}); err != nil {
+		log.C(ctx).WithError(err).Errorf("Could not record catalog sync error for broker with id %s", broker.ID)
+	}
+	return nil, refreshErr
+}
+
+func (c *Controller) fetchAndStoreCatalog(ctx context.Context, broker *types.Broker) (*types.CatalogDiff, error) {
+	if err := transformBrokerCredentials(ctx, broker, c.Encrypter.Decrypt); err != nil {
+		return nil, err
+	}
+	catalog, catalogETag, err := c.fetchCatalogIfChanged(ctx, broker)
+	if err != nil {
+		return nil, err
+	}
+
+	var diff *types.CatalogDiff
+	if err := c.Repository.InTransaction(ctx, func(ctx context.Context, txStorage storage.Warehouse) error {
+		syncedBroker, err := recordCatalogSync(ctx, txStorage, broker.ID, "")
+		if err != nil {
+			return err
+		}
+		// an unchanged catalog is not resynced so that the service offerings and plans remain untouched
+		if catalog == nil {
+			diff = newCatalogDiff()
+			return nil
+		}
+		syncedBroker.CatalogETag = catalogETag
+		if err := txStorage.Broker().Update(ctx, syncedBroker); err != nil {
+			return util.HandleStorageError(err, "broker")
+		}
+		// the catalog of a pinned broker is only recorded in the catalog history
+		if syncedBroker.PinnedCatalogVersion != 0 {
+			diff = newCatalogDiff()
+		} else if diff, err = c.catalogDiff(ctx, txStorage, broker.ID, catalog); err != nil {
+			return err
+		}
+		return c.storeCatalog(ctx, txStorage, syncedBroker, catalog)
+	}); err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+// recordCatalogSync stores the outcome of the latest catalog sync of the broker. A successful sync advances the time
+// of the latest sync, while a failed one is recorded separately so that the broker is retried sooner.
+func recordCatalogSync(ctx context.Context, txStorage storage.Warehouse, brokerID string, syncError string) (*types.Broker, error) {
+	// the broker is fetched again so that changes made while its catalog was being fetched are not overridden
+	broker, err := txStorage.Broker().Get(ctx, brokerID)
+	if err != nil {
+		return nil, util.HandleStorageError(err, "broker")
+	}
+	if syncError == "" {
+		broker.CatalogSyncedAt = time.Now().UTC()
+		broker.CatalogSyncFailedAt = time.Time{}
+	} else {
+		broker.CatalogSyncFailedAt = time.Now().UTC()
+	}
+	broker.CatalogSyncError = syncError
+	if err := txStorage.Broker().Update(ctx, broker); err != nil {
+		return nil, util.HandleStorageError(err, "broker")
+	}
+	return broker, nil
+}
@@ -0,0 +1,157 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package broker
+
+import (
+	"context"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/types"
+)
+
+// CatalogResyncIntervalLabel is the broker label that overrides the catalog resync interval for a single broker.
+// Its value is a duration such as 30m. A non-positive duration disables the periodic resync for the broker.
+const CatalogResyncIntervalLabel = "catalog_resync_interval"
+
+const (
+	catalogResyncLockKey = 112
+	minCatalogResyncWait = time.Second
+
+	// catalogResyncRetryInterval is the time after which a failed catalog sync is retried
+	catalogResyncRetryInterval = time.Minute
+)
+
+// CatalogResyncJob periodically fetches the catalogs of the registered brokers and resyncs them in SM DB.
+// Only one Service Manager instance sharing the same storage runs a resync pass at a time.
+type CatalogResyncJob struct {
+	controller *Controller
+	interval   time.Duration
+}
+
+// NewCatalogResyncJob returns a job that resyncs the broker catalogs every interval in the same way as the controller
+func NewCatalogResyncJob(controller *Controller, interval time.Duration) *CatalogResyncJob {
+	return &CatalogResyncJob{
+		controller: controller,
+		interval:   interval,
+	}
+}
+
+// Run resyncs the catalogs of the brokers that are due until the context is done
+func (j *CatalogResyncJob) Run(ctx context.Context) {
+	log.C(ctx).Infof("Starting periodic catalog resync with interval %s", j.interval)
+	for {
+		wait := j.resyncDueBrokers(ctx)
+		select {
+		case <-ctx.Done():
+			log.C(ctx).Info("Stopping periodic catalog resync")
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// resyncDueBrokers resyncs all brokers whose catalogs are due and returns the time until the next broker becomes due.
+// The lock is held on its own connection rather than in a transaction, as the catalogs are fetched over the network
+// and each one is stored in a transaction of its own.
+func (j *CatalogResyncJob) resyncDueBrokers(ctx context.Context) time.Duration {
+	wait, err := j.resyncDueBrokersLocked(ctx)
+	if err != nil {
+		log.C(ctx).WithError(err).Error("Could not resync broker catalogs")
+	}
+	if wait < minCatalogResyncWait {
+		wait = minCatalogResyncWait
+	}
+	return wait
+}
+
+func (j *CatalogResyncJob) resyncDueBrokersLocked(ctx context.Context) (time.Duration, error) {
+	wait := j.interval
+	release, err := j.controller.Repository.AdvisoryLock().TryLockSession(ctx, catalogResyncLockKey)
+	if err != nil {
+		return wait, err
+	}
+	if release == nil {
+		log.C(ctx).Debug("Catalog resync is already running in another Service Manager instance")
+		return wait, nil
+	}
+	defer release()
+
+	brokers, err := j.controller.Repository.Broker().List(ctx)
+	if err != nil {
+		return wait, err
+	}
+	for _, broker := range brokers {
+		interval := j.brokerInterval(ctx, broker)
+		// suspended brokers are often being fixed so their catalogs are resynced once they are resumed
+		if interval <= 0 || broker.State == types.BrokerSuspended {
+			continue
+		}
+		nextSync := nextCatalogSync(broker, interval)
+		if !nextSync.After(time.Now()) {
+			if err := j.resyncBroker(ctx, broker); err != nil {
+				nextSync = time.Now().Add(retryInterval(interval))
+			} else {
+				nextSync = time.Now().Add(interval)
+			}
+		}
+		if untilNextSync := time.Until(nextSync); untilNextSync < wait {
+			wait = untilNextSync
+		}
+	}
+	return wait, nil
+}
+
+// nextCatalogSync returns when the catalog of the broker is due. A broker whose latest sync failed is retried
+// sooner than the interval.
+func nextCatalogSync(broker *types.Broker, interval time.Duration) time.Time {
+	if !broker.CatalogSyncFailedAt.IsZero() {
+		return broker.CatalogSyncFailedAt.Add(retryInterval(interval))
+	}
+	return broker.CatalogSyncedAt.Add(interval)
+}
+
+func retryInterval(interval time.Duration) time.Duration {
+	if interval < catalogResyncRetryInterval {
+		return interval
+	}
+	return catalogResyncRetryInterval
+}
+
+func (j *CatalogResyncJob) brokerInterval(ctx context.Context, broker *types.Broker) time.Duration {
+	values := broker.Labels[CatalogResyncIntervalLabel]
+	if len(values) == 0 {
+		return j.interval
+	}
+	interval, err := time.ParseDuration(values[0])
+	if err != nil {
+		log.C(ctx).Warnf("Invalid %s label value %s for broker with id %s. Using default interval %s", CatalogResyncIntervalLabel, values[0], broker.ID, j.interval)
+		return j.interval
+	}
+	return interval
+}
+
+// resyncBroker fetches the catalog of the broker and resyncs it in SM DB. The outcome is recorded on the broker.
+func (j *CatalogResyncJob) resyncBroker(ctx context.Context, broker *types.Broker) error {
+	log.C(ctx).Debugf("Resyncing catalog for broker with id %s", broker.ID)
+	if _, err := j.controller.refreshCatalog(ctx, broker); err != nil {
+		log.C(ctx).WithError(err).Errorf("Could not resync catalog for broker with id %s", broker.ID)
+		return err
+	}
+	log.C(ctx).Debugf("Successfully resynced catalog for broker with id %s", broker.ID)
+	return nil
+}
@@ -0,0 +1,68 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package broker
+
+import (
+	"context"
+
+	"github.com/Peripli/service-manager/pkg/health"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/storage"
+)
+
+// HealthIndicator reports the health of every registered broker based on the outcome of its latest catalog sync,
+// so that health requests do not call the brokers
+type HealthIndicator struct {
+	BrokerStorage     storage.Broker
+	AggregationPolicy health.AggregationPolicy
+}
+
+// Name returns the name of the brokers component
+func (i *HealthIndicator) Name() string {
+	return "brokers"
+}
+
+// Health returns the aggregated health of the registered brokers
+func (i *HealthIndicator) Health() *health.Health {
+	brokers, err := i.BrokerStorage.List(context.Background())
+	if err != nil {
+		return health.New().WithError(err).WithDetail("message", "Listing brokers failed")
+	}
+	if len(brokers) == 0 {
+		return health.New().Up()
+	}
+
+	healths := make(map[string]*health.Health, len(brokers))
+	for _, broker := range brokers {
+		brokerHealth := health.New().Up()
+		if !broker.CatalogSyncedAt.IsZero() {
+			brokerHealth.WithDetail("catalog_synced_at", util.ToRFCFormat(broker.CatalogSyncedAt))
+		}
+		if !broker.CatalogSyncFailedAt.IsZero() {
+			brokerHealth.WithDetail("catalog_sync_failed_at", util.ToRFCFormat(broker.CatalogSyncFailedAt))
+		}
+		if broker.CatalogSyncError != "" {
+			brokerHealth.Down().WithDetail("error", broker.CatalogSyncError)
+		}
+		if broker.State == types.BrokerSuspended {
+			brokerHealth.Unknown().WithDetail("state", broker.State)
+		}
+		healths[broker.Name] = brokerHealth
+	}
+	return i.AggregationPolicy.Apply(healths)
+}
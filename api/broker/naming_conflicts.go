@@ -0,0 +1,110 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package broker
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+)
+
+// namingConflictsCollector groups the catalog entries of all brokers by the values of the fields that must be unique
+type namingConflictsCollector map[types.NamingConflictField]map[string][]*types.NamingConflictEntry
+
+func (ncc namingConflictsCollector) add(field types.NamingConflictField, value string, entry *types.NamingConflictEntry) {
+	if ncc[field] == nil {
+		ncc[field] = make(map[string][]*types.NamingConflictEntry)
+	}
+	ncc[field][value] = append(ncc[field][value], entry)
+}
+
+func (ncc namingConflictsCollector) conflicts() []*types.NamingConflict {
+	conflicts := make([]*types.NamingConflict, 0)
+	for field, values := range ncc {
+		for value, entries := range values {
+			if len(entries) > 1 {
+				conflicts = append(conflicts, &types.NamingConflict{
+					Field:   field,
+					Value:   value,
+					Entries: entries,
+				})
+			}
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Field != conflicts[j].Field {
+			return conflicts[i].Field < conflicts[j].Field
+		}
+		return conflicts[i].Value < conflicts[j].Value
+	})
+	return conflicts
+}
+
+// getNamingConflicts reports the service names, as offered to the platforms after applying the naming policies of
+// the brokers, and the catalog ids of services and plans which are not unique across all registered brokers
+func (c *Controller) getNamingConflicts(r *web.Request) (*web.Response, error) {
+	ctx := r.Context()
+	log.C(ctx).Debug("Getting catalog naming conflicts of all brokers")
+
+	brokers, err := c.Repository.Broker().List(ctx)
+	if err != nil {
+		return nil, util.HandleStorageError(err, "broker")
+	}
+	collector := make(namingConflictsCollector)
+	for _, broker := range brokers {
+		serviceOfferings, err := c.Repository.ServiceOffering().ListWithServicePlansByBrokerID(ctx, broker.ID)
+		if err != nil {
+			return nil, util.HandleStorageError(err, "service_offering")
+		}
+		for _, serviceOffering := range serviceOfferings {
+			serviceOffering.Name = serviceOffering.CatalogName
+		}
+		if broker.NamingPolicy != nil {
+			if err := broker.NamingPolicy.Apply(broker, serviceOfferings); err != nil {
+				return nil, err
+			}
+		}
+		for _, serviceOffering := range serviceOfferings {
+			serviceEntry := &types.NamingConflictEntry{
+				BrokerID:          broker.ID,
+				BrokerName:        broker.Name,
+				ServiceOfferingID: serviceOffering.ID,
+			}
+			collector.add(types.ServiceNameConflict, serviceOffering.Name, serviceEntry)
+			collector.add(types.ServiceCatalogIDConflict, serviceOffering.CatalogID, serviceEntry)
+			for _, servicePlan := range serviceOffering.Plans {
+				if !servicePlan.Active {
+					continue
+				}
+				collector.add(types.PlanCatalogIDConflict, servicePlan.CatalogID, &types.NamingConflictEntry{
+					BrokerID:          broker.ID,
+					BrokerName:        broker.Name,
+					ServiceOfferingID: serviceOffering.ID,
+					ServicePlanID:     servicePlan.ID,
+				})
+			}
+		}
+	}
+
+	return util.NewJSONResponse(http.StatusOK, &types.NamingConflicts{
+		NamingConflicts: collector.conflicts(),
+	})
+}
@@ -0,0 +1,91 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package broker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	osbc "github.com/pmorie/go-open-service-broker-client/v2"
+)
+
+// setStaticCatalog replaces the static catalog of a broker with the catalog document in the request body
+func (c *Controller) setStaticCatalog(r *web.Request) (*web.Response, error) {
+	brokerID := r.PathParams[reqBrokerID]
+	ctx := r.Context()
+	log.C(ctx).Debugf("Updating static catalog of broker with id %s", brokerID)
+
+	broker, err := c.Repository.Broker().Get(ctx, brokerID)
+	if err != nil {
+		return nil, util.HandleStorageError(err, "broker")
+	}
+	if !broker.HasStaticCatalog() {
+		return nil, &util.HTTPError{
+			ErrorType:   "Conflict",
+			Description: fmt.Sprintf("broker %s fetches its catalog from the broker and has no static catalog", broker.Name),
+			StatusCode:  http.StatusConflict,
+		}
+	}
+
+	broker.StaticCatalog = r.Body
+	catalog, catalogETag, err := c.getBrokerCatalog(ctx, broker)
+	if err != nil {
+		return nil, err
+	}
+	if isDryRun(r) {
+		return c.catalogDiffResponse(ctx, broker.ID, catalog)
+	}
+	broker.CatalogETag = catalogETag
+	broker.UpdatedAt = time.Now().UTC()
+	broker.CatalogSyncedAt = broker.UpdatedAt
+	broker.CatalogSyncFailedAt = time.Time{}
+	broker.CatalogSyncError = ""
+
+	if err := c.resyncBrokerAndCatalog(ctx, broker, catalog, nil); err != nil {
+		return nil, err
+	}
+
+	broker.Credentials = nil
+	return util.NewJSONResponse(http.StatusOK, broker)
+}
+
+// parseStaticCatalog returns the static catalog of the broker if it complies with the OSB specification
+func parseStaticCatalog(broker *types.Broker) (*osbc.CatalogResponse, error) {
+	catalog := &osbc.CatalogResponse{}
+	if err := json.Unmarshal(broker.StaticCatalog, catalog); err != nil {
+		return nil, &util.HTTPError{
+			ErrorType:   "BadRequest",
+			Description: fmt.Sprintf("static catalog of broker %s is not a valid catalog document: %s", broker.Name, err),
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
+	if problems := validateCatalog(catalog); len(problems) != 0 {
+		return nil, &util.HTTPError{
+			ErrorType:   "BadRequest",
+			Description: fmt.Sprintf("static catalog of broker %s is invalid: %s", broker.Name, strings.Join(problems, "; ")),
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
+	return catalog, nil
+}
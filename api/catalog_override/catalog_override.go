@@ -0,0 +1,151 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+// Package catalog_override contains logic for building the Service Manager catalog overrides API
+package catalog_override
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
+	"github.com/gofrs/uuid"
+)
+
+const catalogOverrideTitle = "catalog_override"
+
+// Target identifies the service offering or plan of a broker catalog that an override applies to.
+// The PlanCatalogID of a service offering target is empty.
+type Target struct {
+	BrokerID         string
+	ServiceCatalogID string
+	PlanCatalogID    string
+}
+
+// Handler provides the get, set and delete catalog override API logic for a service offering or plan
+type Handler struct {
+	CatalogOverrideStorage storage.CatalogOverride
+
+	// Kind is the name of the overridden resource used in the log messages
+	Kind string
+	// PathParam is the request path parameter containing the id of the overridden resource
+	PathParam string
+	// TargetFunc returns the target of the overrides of the resource with the specified id
+	TargetFunc func(ctx context.Context, id string) (*Target, error)
+}
+
+// Get returns the catalog override of the resource
+func (h *Handler) Get(r *web.Request) (*web.Response, error) {
+	id := r.PathParams[h.PathParam]
+	ctx := r.Context()
+	log.C(ctx).Debugf("Getting catalog override of %s with id %s", h.Kind, id)
+
+	target, err := h.TargetFunc(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	override, err := h.find(ctx, target)
+	if err != nil {
+		return nil, util.HandleStorageError(err, catalogOverrideTitle)
+	}
+	if override == nil {
+		return nil, util.HandleStorageError(util.ErrNotFoundInStorage, catalogOverrideTitle)
+	}
+	return util.NewJSONResponse(http.StatusOK, override)
+}
+
+// Set creates or replaces the catalog override of the resource
+func (h *Handler) Set(r *web.Request) (*web.Response, error) {
+	id := r.PathParams[h.PathParam]
+	ctx := r.Context()
+	log.C(ctx).Debugf("Setting catalog override of %s with id %s", h.Kind, id)
+
+	override := &types.CatalogOverride{}
+	if err := util.BytesToObject(r.Body, override); err != nil {
+		return nil, err
+	}
+	target, err := h.TargetFunc(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := h.find(ctx, target)
+	if err != nil {
+		return nil, util.HandleStorageError(err, catalogOverrideTitle)
+	}
+
+	override.BrokerID = target.BrokerID
+	override.ServiceCatalogID = target.ServiceCatalogID
+	override.PlanCatalogID = target.PlanCatalogID
+	override.UpdatedAt = time.Now().UTC()
+	if existing != nil {
+		override.ID = existing.ID
+		override.CreatedAt = existing.CreatedAt
+		if err := h.CatalogOverrideStorage.Update(ctx, override); err != nil {
+			return nil, util.HandleStorageError(err, catalogOverrideTitle)
+		}
+		return util.NewJSONResponse(http.StatusOK, override)
+	}
+
+	UUID, err := uuid.NewV4()
+	if err != nil {
+		return nil, fmt.Errorf("could not generate GUID for catalog override: %s", err)
+	}
+	override.ID = UUID.String()
+	override.CreatedAt = override.UpdatedAt
+	if _, err := h.CatalogOverrideStorage.Create(ctx, override); err != nil {
+		return nil, util.HandleStorageError(err, catalogOverrideTitle)
+	}
+	return util.NewJSONResponse(http.StatusCreated, override)
+}
+
+// Delete removes the catalog override of the resource
+func (h *Handler) Delete(r *web.Request) (*web.Response, error) {
+	id := r.PathParams[h.PathParam]
+	ctx := r.Context()
+	log.C(ctx).Debugf("Deleting catalog override of %s with id %s", h.Kind, id)
+
+	target, err := h.TargetFunc(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.CatalogOverrideStorage.Delete(ctx, criteria(target)...); err != nil {
+		return nil, util.HandleStorageError(err, catalogOverrideTitle)
+	}
+	return util.NewJSONResponse(http.StatusOK, map[string]string{})
+}
+
+func (h *Handler) find(ctx context.Context, target *Target) (*types.CatalogOverride, error) {
+	overrides, err := h.CatalogOverrideStorage.List(ctx, criteria(target)...)
+	if err != nil || len(overrides) == 0 {
+		return nil, err
+	}
+	return overrides[0], nil
+}
+
+func criteria(target *Target) []query.Criterion {
+	return []query.Criterion{
+		query.ByField(query.EqualsOperator, "broker_id", target.BrokerID),
+		query.ByField(query.EqualsOperator, "service_catalog_id", target.ServiceCatalogID),
+		query.ByField(query.EqualsOperator, "plan_catalog_id", target.PlanCatalogID),
+	}
+}
@@ -17,16 +17,24 @@
 package basic
 
 import (
+	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/Peripli/service-manager/pkg/log"
 	"github.com/Peripli/service-manager/pkg/security"
 	"github.com/Peripli/service-manager/pkg/util"
 	"github.com/Peripli/service-manager/pkg/web"
 	"github.com/Peripli/service-manager/storage"
 )
 
+// unknownUserPasswordHash is compared with the passwords of unknown usernames so that they are rejected in the same
+// time as wrong passwords and the existing usernames cannot be told apart
+var unknownUserPasswordHash = []byte("$2a$10$ZojbMd82UOC.wMTwX1CJt.F/yg3rBpIDCxzY8vqnpKBgCm2WJs9lm")
+
 type basicAuthnData struct {
 	data json.RawMessage
 }
@@ -39,6 +47,18 @@ func (bad *basicAuthnData) Data(v interface{}) error {
 type basicAuthenticator struct {
 	CredentialStorage storage.Credentials
 	Encrypter         security.Encrypter
+	Lockouts          *Lockouts
+
+	verifiedPasswords verifiedPasswords
+}
+
+// lockedOutError is returned when the username or the client of the request is locked out after repeated failed attempts
+type lockedOutError struct {
+	retryAfter time.Duration
+}
+
+func (e *lockedOutError) Error() string {
+	return "too many failed authentication attempts"
 }
 
 // Authenticate authenticates by using the provided Basic credentials
@@ -48,28 +68,59 @@ func (a *basicAuthenticator) Authenticate(request *http.Request) (*web.UserConte
 		return nil, security.Abstain, nil
 	}
 
+	client := a.Lockouts.clientIP(request)
+	if retryAfter := a.Lockouts.retryAfter(username, client); retryAfter > 0 {
+		return nil, security.Abstain, &lockedOutError{retryAfter: retryAfter}
+	}
+
 	ctx := request.Context()
 	credentials, err := a.CredentialStorage.Get(ctx, username)
 
 	if err != nil {
 		if err == util.ErrNotFoundInStorage {
+			security.PasswordMatchesHash(unknownUserPasswordHash, []byte(password))
+			a.Lockouts.recordFailure(ctx, username, client)
 			return nil, security.Deny, err
 		}
 		return nil, security.Abstain, fmt.Errorf("could not get credentials entity from storage: %s", err)
 	}
-	passwordBytes, err := a.Encrypter.Decrypt(ctx, []byte(credentials.Basic.Password))
-	if err != nil {
-		return nil, security.Abstain, fmt.Errorf("could not reverse credentials from storage: %v", err)
-	}
-
-	if string(passwordBytes) != password {
-		return nil, security.Deny, nil
+	storedPassword := []byte(credentials.Basic.Password)
+	if security.IsPasswordHash(storedPassword) {
+		if !a.verifiedPasswords.matches(storedPassword, []byte(password)) {
+			a.Lockouts.recordFailure(ctx, username, client)
+			return nil, security.Deny, nil
+		}
+	} else {
+		// the password was stored before passwords were hashed, so it is reversed and compared and on success it is
+		// replaced with its hash
+		passwordBytes, err := a.Encrypter.Decrypt(ctx, storedPassword)
+		if err != nil {
+			return nil, security.Abstain, fmt.Errorf("could not reverse credentials from storage: %v", err)
+		}
+		if subtle.ConstantTimeCompare(passwordBytes, []byte(password)) != 1 {
+			a.Lockouts.recordFailure(ctx, username, client)
+			return nil, security.Deny, nil
+		}
+		a.rehashPassword(ctx, username, password)
 	}
+	a.Lockouts.recordSuccess(username, client)
 
 	return &web.UserContext{
 		Data: &basicAuthnData{
 			data: credentials.Details,
 		},
-		Name: username,
+		Name:               username,
+		AuthenticationType: web.BasicAuthentication,
 	}, security.Allow, nil
 }
+
+func (a *basicAuthenticator) rehashPassword(ctx context.Context, username, password string) {
+	hash, err := security.HashPassword([]byte(password))
+	if err != nil {
+		log.C(ctx).WithError(err).Warnf("Could not hash the password of %s", username)
+		return
+	}
+	if err := a.CredentialStorage.UpdatePassword(ctx, username, string(hash)); err != nil {
+		log.C(ctx).WithError(err).Warnf("Could not store the hashed password of %s", username)
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/Peripli/service-manager/pkg/security/securityfakes"
 	"github.com/Peripli/service-manager/pkg/types"
 	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
 	"github.com/Peripli/service-manager/storage/storagefakes"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -81,6 +82,10 @@ var _ = Describe("Basic Authenticator", func() {
 				Expect(user).To(BeNil())
 				Expect(decision).To(Equal(security.Deny))
 			})
+
+			It("Should compare the password with a valid hash to take as long as a wrong password", func() {
+				Expect(security.IsPasswordHash(unknownUserPasswordHash)).To(BeTrue())
+			})
 		})
 
 		Context("When getting credentials from storage results in error", func() {
@@ -137,7 +142,127 @@ var _ = Describe("Basic Authenticator", func() {
 				Expect(err).To(BeNil())
 				Expect(user).To(Not(BeNil()))
 				Expect(decision).To(Equal(security.Allow))
+				Expect(user.AuthenticationType).To(Equal(web.BasicAuthentication))
+			})
+
+			It("Should replace the stored password with its hash", func() {
+				callCount := credentialsStorage.UpdatePasswordCallCount()
+				request.Header.Add("Authorization", "Basic "+basicHeader)
+				_, decision, err := authenticator.Authenticate(request)
+				Expect(err).To(BeNil())
+				Expect(decision).To(Equal(security.Allow))
+				Expect(credentialsStorage.UpdatePasswordCallCount()).To(Equal(callCount + 1))
+				_, username, hash := credentialsStorage.UpdatePasswordArgsForCall(callCount)
+				Expect(username).To(Equal(user))
+				Expect(security.PasswordMatchesHash([]byte(hash), []byte(password))).To(BeTrue())
+			})
+		})
+
+		Context("When the stored password is hashed", func() {
+			BeforeEach(func() {
+				hash, err := security.HashPassword([]byte(password))
+				Expect(err).ShouldNot(HaveOccurred())
+				credentialsStorage.GetReturns(&types.Credentials{
+					Basic: &types.Basic{
+						Username: user,
+						Password: string(hash),
+					},
+				}, nil)
+			})
+
+			It("Should allow when passwords match", func() {
+				decryptCount := encrypter.DecryptCallCount()
+				updateCount := credentialsStorage.UpdatePasswordCallCount()
+				request.Header.Add("Authorization", "Basic "+basicHeader)
+				user, decision, err := authenticator.Authenticate(request)
+				Expect(err).To(BeNil())
+				Expect(user).To(Not(BeNil()))
+				Expect(decision).To(Equal(security.Allow))
+				Expect(encrypter.DecryptCallCount()).To(Equal(decryptCount))
+				Expect(credentialsStorage.UpdatePasswordCallCount()).To(Equal(updateCount))
+			})
+
+			It("Should deny when passwords do not match", func() {
+				request.SetBasicAuth(user, "not-password")
+				user, decision, err := authenticator.Authenticate(request)
+				Expect(err).To(BeNil())
+				Expect(user).To(BeNil())
+				Expect(decision).To(Equal(security.Deny))
+			})
+
+			It("Should reuse only the successful comparisons", func() {
+				request.SetBasicAuth(user, "not-password")
+				_, decision, err := authenticator.Authenticate(request)
+				Expect(err).To(BeNil())
+				Expect(decision).To(Equal(security.Deny))
+				Expect(authenticator.(*basicAuthenticator).verifiedPasswords.expiresAt).To(BeEmpty())
+
+				request.SetBasicAuth(user, password)
+				_, decision, err = authenticator.Authenticate(request)
+				Expect(err).To(BeNil())
+				Expect(decision).To(Equal(security.Allow))
+				Expect(authenticator.(*basicAuthenticator).verifiedPasswords.expiresAt).To(HaveLen(1))
+			})
+
+			It("Should compare the password again once the stored password changes", func() {
+				request.SetBasicAuth(user, password)
+				_, decision, err := authenticator.Authenticate(request)
+				Expect(err).To(BeNil())
+				Expect(decision).To(Equal(security.Allow))
+
+				hash, err := security.HashPassword([]byte("new-password"))
+				Expect(err).ShouldNot(HaveOccurred())
+				credentialsStorage.GetReturns(&types.Credentials{
+					Basic: &types.Basic{
+						Username: user,
+						Password: string(hash),
+					},
+				}, nil)
+				_, decision, err = authenticator.Authenticate(request)
+				Expect(err).To(BeNil())
+				Expect(decision).To(Equal(security.Deny))
 			})
 		})
 	})
 })
+
+func BenchmarkAuthenticateWithHashedPassword(b *testing.B) {
+	hash, err := security.HashPassword([]byte("password"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	credentialsStorage := &storagefakes.FakeCredentials{}
+	credentialsStorage.GetReturns(&types.Credentials{
+		Basic: &types.Basic{
+			Username: "user",
+			Password: string(hash),
+		},
+	}, nil)
+	authenticator := &basicAuthenticator{CredentialStorage: credentialsStorage, Encrypter: &securityfakes.FakeEncrypter{}}
+	request, err := http.NewRequest(http.MethodGet, "https://example.com", nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	request.SetBasicAuth("user", "password")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, decision, _ := authenticator.Authenticate(request); decision != security.Allow {
+			b.Fatalf("expected the request to be allowed but the decision was %v", decision)
+		}
+	}
+}
+
+func BenchmarkPasswordMatchesHash(b *testing.B) {
+	hash, err := security.HashPassword([]byte("password"))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !security.PasswordMatchesHash(hash, []byte("password")) {
+			b.Fatal("expected the password to match its hash")
+		}
+	}
+}
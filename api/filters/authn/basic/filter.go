@@ -1,10 +1,13 @@
 package basic
 
 import (
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/Peripli/service-manager/pkg/security"
 	"github.com/Peripli/service-manager/pkg/security/middlewares"
+	"github.com/Peripli/service-manager/pkg/util"
 	"github.com/Peripli/service-manager/pkg/web"
 	"github.com/Peripli/service-manager/storage"
 )
@@ -13,12 +16,13 @@ import (
 const BasicAuthnFilterName string = "BasicAuthnFilter"
 
 // NewFilter returns a web.Filter for basic auth using the provided
-// credentials storage in order to validate the credentials
-func NewFilter(storage storage.Credentials, encrypter security.Encrypter) web.Filter {
+// credentials storage in order to validate the credentials and the provided
+// lockouts in order to reject repeated failed attempts
+func NewFilter(storage storage.Credentials, encrypter security.Encrypter, lockouts *Lockouts) web.Filter {
 	return &basicAuthnFilter{
 		Filter: middlewares.NewAuthnMiddleware(
 			BasicAuthnFilterName,
-			&basicAuthenticator{CredentialStorage: storage, Encrypter: encrypter},
+			&basicAuthenticator{CredentialStorage: storage, Encrypter: encrypter, Lockouts: lockouts},
 		),
 	}
 }
@@ -28,6 +32,28 @@ type basicAuthnFilter struct {
 	web.Filter
 }
 
+// Run implements the web.Filter interface and responds with 429 Too Many Requests while the username or the client
+// of the request is locked out
+func (ba *basicAuthnFilter) Run(request *web.Request, next web.Handler) (*web.Response, error) {
+	response, err := ba.Filter.Run(request, next)
+	lockedOut, ok := err.(*lockedOutError)
+	if !ok {
+		return response, err
+	}
+
+	response, err = util.NewJSONResponse(http.StatusTooManyRequests, &util.HTTPError{
+		ErrorType:   "TooManyRequests",
+		Description: lockedOut.Error(),
+		StatusCode:  http.StatusTooManyRequests,
+	})
+	if err != nil {
+		return nil, err
+	}
+	retryAfterSeconds := int(math.Ceil(lockedOut.retryAfter.Seconds()))
+	response.Header.Set("Retry-After", strconv.Itoa(retryAfterSeconds))
+	return response, nil
+}
+
 // FilterMatchers implements the web.Filter interface and returns the conditions on which the filter should be executed
 func (ba *basicAuthnFilter) FilterMatchers() []web.FilterMatcher {
 	return []web.FilterMatcher{
@@ -0,0 +1,323 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package basic
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/health"
+	"github.com/Peripli/service-manager/pkg/log"
+)
+
+// LockoutSettings configures the lockout of basic authentication after repeated failed attempts
+type LockoutSettings struct {
+	// MaxFailedAttemptsPerUser is the count of consecutive failed attempts for a username from a client after which
+	// the username is locked out for that client. Zero disables the lockout of usernames.
+	MaxFailedAttemptsPerUser int
+	// MaxFailedAttemptsPerClient is the count of consecutive failed attempts from a client IP after which it is locked
+	// out. Zero disables the lockout of clients.
+	MaxFailedAttemptsPerClient int
+	// Duration is the duration of the first lockout. Every following lockout doubles it.
+	Duration time.Duration
+	// MaxDuration caps the duration of a lockout. Failed attempts older than it are forgotten.
+	MaxDuration time.Duration
+	// TrustedProxies are the networks of the proxies whose X-Forwarded-For header identifies the client. The header
+	// of requests from any other address is ignored.
+	TrustedProxies []*net.IPNet
+}
+
+// maxTrackedKeys caps the count of tracked usernames and of tracked clients, so that failed attempts with random
+// usernames or from many clients cannot exhaust the memory. Once it is reached, the failed attempts which were
+// recorded first are forgotten.
+const maxTrackedKeys = 10000
+
+// failedAttempts tracks the failed attempts of a single username or client
+type failedAttempts struct {
+	count       int
+	lockouts    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// Lockouts tracks the failed basic authentication attempts per username and client IP and per client IP and locks
+// them out with an exponential backoff once the configured thresholds are reached. The usernames are locked out only
+// for the clients which failed to authenticate, so that anyone knowing a username cannot lock out its platform.
+type Lockouts struct {
+	settings LockoutSettings
+	now      func() time.Time
+
+	mutex                 sync.Mutex
+	users                 map[string]*failedAttempts
+	clients               map[string]*failedAttempts
+	lockoutCount          int64
+	lastPrune             time.Time
+	lastSaturationWarning time.Time
+}
+
+var _ health.Indicator = &Lockouts{}
+
+// NewLockouts returns Lockouts with the provided settings
+func NewLockouts(settings LockoutSettings) *Lockouts {
+	return &Lockouts{
+		settings: settings,
+		now:      time.Now,
+		users:    make(map[string]*failedAttempts),
+		clients:  make(map[string]*failedAttempts),
+	}
+}
+
+// Name returns the name of the basic authentication lockouts component
+func (l *Lockouts) Name() string {
+	return "basic_authentication"
+}
+
+// Health returns the count of the currently locked out usernames and clients and of all lockouts so far
+func (l *Lockouts) Health() *health.Health {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	return health.New().Up().WithDetails(map[string]interface{}{
+		"locked_users":   countLocked(l.users, now),
+		"locked_clients": countLocked(l.clients, now),
+		"lockouts":       l.lockoutCount,
+	})
+}
+
+// retryAfter returns the remaining duration of the lockout of the username for the client or of the client, or zero
+// if neither is locked out
+func (l *Lockouts) retryAfter(username, client string) time.Duration {
+	if l == nil {
+		return 0
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	var remaining time.Duration
+	for _, attempts := range []*failedAttempts{l.users[userKey(username, client)], l.clients[client]} {
+		if attempts != nil && attempts.lockedUntil.After(now) {
+			if d := attempts.lockedUntil.Sub(now); d > remaining {
+				remaining = d
+			}
+		}
+	}
+	return remaining
+}
+
+// recordFailure records a failed attempt of the username from the client and locks out whichever reached its
+// threshold
+func (l *Lockouts) recordFailure(ctx context.Context, username, client string) {
+	if l == nil {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	l.prune(now)
+	if duration := l.fail(ctx, l.users, userKey(username, client), l.settings.MaxFailedAttemptsPerUser, now); duration > 0 {
+		log.C(ctx).Warnf("Basic authentication of user %s from client %s is locked out for %s after %d failed attempts",
+			username, client, duration, l.settings.MaxFailedAttemptsPerUser)
+	}
+	if duration := l.fail(ctx, l.clients, client, l.settings.MaxFailedAttemptsPerClient, now); duration > 0 {
+		log.C(ctx).Warnf("Basic authentication from client %s is locked out for %s after %d failed attempts",
+			client, duration, l.settings.MaxFailedAttemptsPerClient)
+	}
+}
+
+// recordSuccess forgets the failed attempts of the username from the client. The failed attempts of the client are
+// kept, so that knowing one password does not allow guessing the passwords of other usernames.
+func (l *Lockouts) recordSuccess(username, client string) {
+	if l == nil {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.users, userKey(username, client))
+}
+
+// userKey identifies the failed attempts of a username from a client. The client is an IP address, so it cannot
+// contain the separator.
+func userKey(username, client string) string {
+	return client + "/" + username
+}
+
+func (l *Lockouts) fail(ctx context.Context, attemptsByKey map[string]*failedAttempts, key string, maxAttempts int, now time.Time) time.Duration {
+	if maxAttempts <= 0 || key == "" {
+		return 0
+	}
+	attempts, found := attemptsByKey[key]
+	if !found && len(attemptsByKey) >= maxTrackedKeys {
+		l.pruneExpired(now)
+		if len(attemptsByKey) >= maxTrackedKeys {
+			l.evictOldest(ctx, attemptsByKey, now)
+		}
+	}
+	if !found || now.Sub(attempts.lastFailure) > l.settings.MaxDuration {
+		attempts = &failedAttempts{}
+		attemptsByKey[key] = attempts
+	}
+	attempts.count++
+	attempts.lastFailure = now
+	if attempts.count < maxAttempts {
+		return 0
+	}
+
+	duration := l.lockoutDuration(attempts.lockouts)
+	attempts.count = 0
+	attempts.lockouts++
+	attempts.lockedUntil = now.Add(duration)
+	l.lockoutCount++
+	return duration
+}
+
+// lockoutDuration doubles the lockout duration for every previous lockout up to the max duration
+func (l *Lockouts) lockoutDuration(previousLockouts int) time.Duration {
+	duration := float64(l.settings.Duration) * math.Pow(2, float64(previousLockouts))
+	if duration > float64(l.settings.MaxDuration) {
+		return l.settings.MaxDuration
+	}
+	return time.Duration(duration)
+}
+
+// prune forgets the failed attempts which are no longer relevant at most once per lockout duration
+func (l *Lockouts) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < l.settings.Duration {
+		return
+	}
+	l.lastPrune = now
+	l.pruneExpired(now)
+}
+
+// evictOldest forgets the failed attempts which were recorded first when too many keys are tracked. The keys which are
+// locked out are forgotten only if all tracked keys are locked out. The saturation is reported at most once per
+// lockout duration.
+func (l *Lockouts) evictOldest(ctx context.Context, attemptsByKey map[string]*failedAttempts, now time.Time) {
+	var oldestKey string
+	var oldest *failedAttempts
+	for key, attempts := range attemptsByKey {
+		if oldest == nil || isEvictedBefore(attempts, oldest, now) {
+			oldestKey, oldest = key, attempts
+		}
+	}
+	delete(attemptsByKey, oldestKey)
+	if now.Sub(l.lastSaturationWarning) >= l.settings.Duration {
+		l.lastSaturationWarning = now
+		log.C(ctx).Warnf("Failed basic authentication attempts of more than %d keys are tracked. "+
+			"The failed attempts which were recorded first are forgotten.", maxTrackedKeys)
+	}
+}
+
+func isEvictedBefore(attempts, other *failedAttempts, now time.Time) bool {
+	locked, otherLocked := attempts.lockedUntil.After(now), other.lockedUntil.After(now)
+	if locked != otherLocked {
+		return otherLocked
+	}
+	return attempts.lastFailure.Before(other.lastFailure)
+}
+
+func (l *Lockouts) pruneExpired(now time.Time) {
+	for _, attemptsByKey := range []map[string]*failedAttempts{l.users, l.clients} {
+		for key, attempts := range attemptsByKey {
+			if now.Sub(attempts.lastFailure) > l.settings.MaxDuration && !attempts.lockedUntil.After(now) {
+				delete(attemptsByKey, key)
+			}
+		}
+	}
+}
+
+func countLocked(attemptsByKey map[string]*failedAttempts, now time.Time) int {
+	count := 0
+	for _, attempts := range attemptsByKey {
+		if attempts.lockedUntil.After(now) {
+			count++
+		}
+	}
+	return count
+}
+
+// clientIP returns the IP of the client of the request. The X-Forwarded-For addresses are only considered when the
+// request comes from a trusted proxy and the rightmost address which is not a trusted proxy is the client.
+func (l *Lockouts) clientIP(request *http.Request) string {
+	client, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		client = request.RemoteAddr
+	}
+	if l == nil || !l.isTrustedProxy(client) {
+		return client
+	}
+	addresses := strings.Split(request.Header.Get("X-Forwarded-For"), ",")
+	for i := len(addresses) - 1; i >= 0; i-- {
+		address := strings.TrimSpace(addresses[i])
+		if address == "" {
+			continue
+		}
+		client = address
+		if !l.isTrustedProxy(address) {
+			break
+		}
+	}
+	return client
+}
+
+func (l *Lockouts) isTrustedProxy(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range l.settings.TrustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTrustedProxies parses comma separated IP addresses and CIDR networks of trusted proxies such as
+// 10.0.0.1,192.168.0.0/16
+func ParseTrustedProxies(proxies string) ([]*net.IPNet, error) {
+	result := make([]*net.IPNet, 0)
+	if strings.TrimSpace(proxies) == "" {
+		return result, nil
+	}
+	for _, proxy := range strings.Split(proxies, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if ip := net.ParseIP(proxy); ip != nil {
+			bits := 8 * len(ip)
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			result = append(result, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q must be an IP address or a CIDR network", proxy)
+		}
+		result = append(result, network)
+	}
+	return result, nil
+}
@@ -0,0 +1,239 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package basic
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage/storagefakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lockouts", func() {
+	var (
+		lockouts *Lockouts
+		now      time.Time
+		ctx      context.Context
+	)
+
+	failTimes := func(count int, username, client string) {
+		for i := 0; i < count; i++ {
+			lockouts.recordFailure(ctx, username, client)
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Now()
+		lockouts = NewLockouts(LockoutSettings{
+			MaxFailedAttemptsPerUser:   3,
+			MaxFailedAttemptsPerClient: 5,
+			Duration:                   time.Minute,
+			MaxDuration:                3 * time.Minute,
+		})
+		lockouts.now = func() time.Time {
+			return now
+		}
+	})
+
+	It("locks out a username for a client after the max failed attempts from the client", func() {
+		failTimes(2, "user", "client-1")
+		Expect(lockouts.retryAfter("user", "client-1")).To(BeZero())
+
+		lockouts.recordFailure(ctx, "user", "client-1")
+		Expect(lockouts.retryAfter("user", "client-1")).To(Equal(time.Minute))
+		Expect(lockouts.retryAfter("other-user", "client-1")).To(BeZero())
+	})
+
+	It("does not lock out a username for the clients which did not fail", func() {
+		failTimes(3, "user", "client-1")
+		Expect(lockouts.retryAfter("user", "client-2")).To(BeZero())
+
+		failTimes(2, "user", "client-2")
+		Expect(lockouts.retryAfter("user", "client-2")).To(BeZero())
+	})
+
+	It("locks out a client after the max failed attempts regardless of the usernames", func() {
+		for _, username := range []string{"user-1", "user-2", "user-3", "user-4", "user-5"} {
+			lockouts.recordFailure(ctx, username, "client")
+		}
+		Expect(lockouts.retryAfter("user-6", "client")).To(Equal(time.Minute))
+		Expect(lockouts.retryAfter("user-6", "other-client")).To(BeZero())
+	})
+
+	It("doubles the duration of every following lockout up to the max duration", func() {
+		failTimes(3, "user", "")
+		Expect(lockouts.retryAfter("user", "")).To(Equal(time.Minute))
+
+		now = now.Add(time.Minute)
+		failTimes(3, "user", "")
+		Expect(lockouts.retryAfter("user", "")).To(Equal(2 * time.Minute))
+
+		now = now.Add(2 * time.Minute)
+		failTimes(3, "user", "")
+		Expect(lockouts.retryAfter("user", "")).To(Equal(3 * time.Minute))
+	})
+
+	It("forgets the failed attempts of a username after a successful attempt", func() {
+		failTimes(2, "user", "")
+		lockouts.recordSuccess("user", "")
+		failTimes(2, "user", "")
+		Expect(lockouts.retryAfter("user", "")).To(BeZero())
+	})
+
+	It("forgets failed attempts older than the max duration", func() {
+		failTimes(2, "user", "")
+		now = now.Add(4 * time.Minute)
+		lockouts.recordFailure(ctx, "user", "")
+		Expect(lockouts.retryAfter("user", "")).To(BeZero())
+	})
+
+	It("does not lock out when the max failed attempts are zero", func() {
+		lockouts.settings.MaxFailedAttemptsPerUser = 0
+		lockouts.settings.MaxFailedAttemptsPerClient = 0
+		failTimes(10, "user", "client")
+		Expect(lockouts.retryAfter("user", "client")).To(BeZero())
+	})
+
+	It("reports the lockouts in its health", func() {
+		failTimes(3, "user", "client")
+		failTimes(2, "other-user", "client")
+		health := lockouts.Health()
+		Expect(health.Details).To(HaveKeyWithValue("locked_users", 1))
+		Expect(health.Details).To(HaveKeyWithValue("locked_clients", 1))
+		Expect(health.Details).To(HaveKeyWithValue("lockouts", int64(2)))
+
+		now = now.Add(time.Minute)
+		health = lockouts.Health()
+		Expect(health.Details).To(HaveKeyWithValue("locked_users", 0))
+		Expect(health.Details).To(HaveKeyWithValue("lockouts", int64(2)))
+	})
+
+	It("forgets the oldest failed attempts once the max tracked keys are reached", func() {
+		for i := 0; i < maxTrackedKeys; i++ {
+			now = now.Add(time.Millisecond)
+			lockouts.recordFailure(ctx, fmt.Sprintf("user-%d", i), "")
+		}
+		failTimes(3, "user", "")
+		Expect(lockouts.retryAfter("user", "")).To(Equal(time.Minute))
+		Expect(lockouts.users).To(HaveLen(maxTrackedKeys))
+		Expect(lockouts.users).ToNot(HaveKey(userKey("user-0", "")))
+		Expect(lockouts.users).To(HaveKey(userKey("user-1", "")))
+	})
+
+	It("keeps the locked out keys once the max tracked keys are reached", func() {
+		failTimes(3, "user", "")
+		for i := 0; i < maxTrackedKeys; i++ {
+			now = now.Add(time.Millisecond)
+			lockouts.recordFailure(ctx, fmt.Sprintf("user-%d", i), "")
+		}
+		Expect(lockouts.retryAfter("user", "")).ToNot(BeZero())
+		Expect(lockouts.users).To(HaveLen(maxTrackedKeys))
+		Expect(lockouts.users).ToNot(HaveKey(userKey("user-0", "")))
+	})
+
+	Describe("clientIP", func() {
+		var request *http.Request
+
+		BeforeEach(func() {
+			trustedProxies, err := ParseTrustedProxies("10.0.0.1, 192.168.0.0/16")
+			Expect(err).ShouldNot(HaveOccurred())
+			lockouts.settings.TrustedProxies = trustedProxies
+			request = &http.Request{RemoteAddr: "10.0.0.1:4000", Header: http.Header{}}
+		})
+
+		It("returns the rightmost forwarded address which is not a trusted proxy", func() {
+			request.Header.Set("X-Forwarded-For", "1.1.1.1, 2.2.2.2, 192.168.1.1")
+			Expect(lockouts.clientIP(request)).To(Equal("2.2.2.2"))
+		})
+
+		It("ignores the forwarded addresses of requests which do not come from a trusted proxy", func() {
+			request.RemoteAddr = "3.3.3.3:4000"
+			request.Header.Set("X-Forwarded-For", "2.2.2.2")
+			Expect(lockouts.clientIP(request)).To(Equal("3.3.3.3"))
+		})
+
+		It("returns the remote address when the request is not forwarded", func() {
+			Expect(lockouts.clientIP(request)).To(Equal("10.0.0.1"))
+		})
+	})
+
+	Describe("ParseTrustedProxies", func() {
+		It("parses IP addresses and CIDR networks", func() {
+			trustedProxies, err := ParseTrustedProxies("10.0.0.1,::1,192.168.0.0/16")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(trustedProxies).To(HaveLen(3))
+			Expect(trustedProxies[0].String()).To(Equal("10.0.0.1/32"))
+			Expect(trustedProxies[1].String()).To(Equal("::1/128"))
+			Expect(trustedProxies[2].String()).To(Equal("192.168.0.0/16"))
+		})
+
+		It("returns an error for an invalid proxy", func() {
+			_, err := ParseTrustedProxies("10.0.0.1,proxy")
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("filter", func() {
+		var (
+			filter             web.Filter
+			credentialsStorage *storagefakes.FakeCredentials
+			request            *web.Request
+		)
+
+		BeforeEach(func() {
+			credentialsStorage = &storagefakes.FakeCredentials{}
+			credentialsStorage.GetReturns(nil, util.ErrNotFoundInStorage)
+			filter = NewFilter(credentialsStorage, nil, lockouts)
+
+			httpRequest, err := http.NewRequest(http.MethodGet, "https://example.com", nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			httpRequest.SetBasicAuth("user", "password")
+			request = &web.Request{Request: httpRequest}
+		})
+
+		run := func() (*web.Response, error) {
+			return filter.Run(request, web.HandlerFunc(func(request *web.Request) (*web.Response, error) {
+				return &web.Response{StatusCode: http.StatusOK}, nil
+			}))
+		}
+
+		It("responds with too many requests and retry after while locked out", func() {
+			for i := 0; i < 3; i++ {
+				_, err := run()
+				Expect(err.(*util.HTTPError).StatusCode).To(Equal(http.StatusUnauthorized))
+			}
+			now = now.Add(30 * time.Second)
+
+			response, err := run()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(response.StatusCode).To(Equal(http.StatusTooManyRequests))
+			Expect(response.Header.Get("Retry-After")).To(Equal("30"))
+			Expect(credentialsStorage.GetCallCount()).To(Equal(3))
+
+			request.RemoteAddr = "1.1.1.1:4000"
+			_, err = run()
+			Expect(err.(*util.HTTPError).StatusCode).To(Equal(http.StatusUnauthorized))
+		})
+	})
+})
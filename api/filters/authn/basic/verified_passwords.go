@@ -0,0 +1,95 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package basic
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/security"
+)
+
+const (
+	// verifiedPasswordTTL is the duration for which a successful comparison of a password with its hash is reused
+	verifiedPasswordTTL = time.Minute
+	// maxVerifiedPasswords caps the count of reused comparisons
+	maxVerifiedPasswords = 10000
+)
+
+// verifiedPasswords reuses the successful comparisons of passwords with their hashes for a short time, as the
+// platforms authenticate every OSB request and the hash comparison is deliberately slow. The comparisons are keyed
+// by a hash of the password hash and the password, so that a changed password is compared again and the passwords
+// are not kept in memory. The zero value is ready to use.
+type verifiedPasswords struct {
+	mutex     sync.Mutex
+	expiresAt map[[sha256.Size]byte]time.Time
+	lastPrune time.Time
+}
+
+// matches returns whether the password matches the hash
+func (vp *verifiedPasswords) matches(hash []byte, password []byte) bool {
+	now := time.Now()
+	key := verificationKey(hash, password)
+	if vp.isVerified(key, now) {
+		return true
+	}
+	if !security.PasswordMatchesHash(hash, password) {
+		return false
+	}
+	vp.verified(key, now)
+	return true
+}
+
+func verificationKey(hash []byte, password []byte) [sha256.Size]byte {
+	digest := sha256.New()
+	digest.Write(hash)
+	digest.Write([]byte{0})
+	digest.Write(password)
+	var key [sha256.Size]byte
+	copy(key[:], digest.Sum(nil))
+	return key
+}
+
+func (vp *verifiedPasswords) isVerified(key [sha256.Size]byte, now time.Time) bool {
+	vp.mutex.Lock()
+	defer vp.mutex.Unlock()
+
+	expiresAt, found := vp.expiresAt[key]
+	return found && now.Before(expiresAt)
+}
+
+func (vp *verifiedPasswords) verified(key [sha256.Size]byte, now time.Time) {
+	vp.mutex.Lock()
+	defer vp.mutex.Unlock()
+
+	if vp.expiresAt == nil {
+		vp.expiresAt = make(map[[sha256.Size]byte]time.Time)
+	}
+	if now.Sub(vp.lastPrune) >= verifiedPasswordTTL || len(vp.expiresAt) >= maxVerifiedPasswords {
+		vp.lastPrune = now
+		for verifiedKey, expiresAt := range vp.expiresAt {
+			if !now.Before(expiresAt) {
+				delete(vp.expiresAt, verifiedKey)
+			}
+		}
+	}
+	// the comparison is simply not reused when too many passwords were verified recently
+	if len(vp.expiresAt) < maxVerifiedPasswords {
+		vp.expiresAt[key] = now.Add(verifiedPasswordTTL)
+	}
+}
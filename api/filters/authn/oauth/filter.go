@@ -43,6 +43,8 @@ func (ba *bearerAuthnFilter) FilterMatchers() []web.FilterMatcher {
 					web.ServiceOfferingsURL+"/**",
 					web.ServicePlansURL+"/**",
 					web.VisibilitiesURL+"/**",
+					web.OperationsURL+"/**",
+					web.ReportsURL+"/**",
 				),
 			},
 		},
@@ -0,0 +1,74 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package authz
+
+import (
+	"github.com/Peripli/service-manager/pkg/security/authorizers"
+	"github.com/Peripli/service-manager/pkg/security/middlewares"
+	"github.com/Peripli/service-manager/pkg/web"
+)
+
+// RBACAuthzFilterName is the name of the role-based authorization filter
+const RBACAuthzFilterName string = "RBACAuthzFilter"
+
+// resources maps the management API paths to the names of the resources used in the permissions
+var resources = map[string]string{
+	web.BrokersURL:          "brokers",
+	web.PlatformsURL:        "platforms",
+	web.ServiceOfferingsURL: "service_offerings",
+	web.ServicePlansURL:     "service_plans",
+	web.VisibilitiesURL:     "visibilities",
+	web.OperationsURL:       "operations",
+	web.ReportsURL:          "reports",
+}
+
+// rbacAuthzFilter authorizes the requests to the management API based on the permissions of the user
+type rbacAuthzFilter struct {
+	web.Filter
+}
+
+// FilterMatchers implements the web.Filter interface and returns the conditions on which the filter should be executed
+func (f *rbacAuthzFilter) FilterMatchers() []web.FilterMatcher {
+	return []web.FilterMatcher{
+		{
+			Matchers: []web.Matcher{
+				web.Path(
+					web.BrokersURL+"/**",
+					web.PlatformsURL+"/**",
+					web.ServiceOfferingsURL+"/**",
+					web.ServicePlansURL+"/**",
+					web.VisibilitiesURL+"/**",
+					web.OperationsURL+"/**",
+					web.ReportsURL+"/**",
+				),
+			},
+		},
+	}
+}
+
+// NewRBACFilter returns a web.Filter for role-based authorization of the management API. The roles of the users
+// authenticated with an OAuth token are read from the provided claim and the platforms have the provided permissions.
+func NewRBACFilter(claim string, roles map[string][]authorizers.Permission, platformPermissions []authorizers.Permission) web.Filter {
+	return &rbacAuthzFilter{
+		Filter: middlewares.NewAuthzMiddleware(RBACAuthzFilterName, &authorizers.RBACAuthorizer{
+			Resources:           resources,
+			Claim:               claim,
+			Roles:               roles,
+			PlatformPermissions: platformPermissions,
+		}),
+	}
+}
@@ -54,92 +54,127 @@ func (pspf *PublicServicePlansFilter) Run(req *web.Request, next web.Handler) (*
 	if err != nil {
 		return nil, err
 	}
+	if req.URL.Query().Get(web.DryRunParam) == "true" {
+		return response, nil
+	}
+	// accepted asynchronous requests reconcile the public plans once their operation is executed
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusCreated {
+		return response, nil
+	}
 	ctx := req.Context()
 	brokerID := gjson.GetBytes(response.Body, "id").String()
 	log.C(ctx).Debugf("Reconciling public plans for broker with id: %s", brokerID)
 	if err := pspf.Repository.InTransaction(ctx, func(ctx context.Context, storage storage.Warehouse) error {
-		soRepository := storage.ServiceOffering()
-		vRepository := storage.Visibility()
-
 		broker, err := pspf.Repository.Broker().Get(ctx, brokerID)
 		if err != nil {
 			return util.HandleStorageError(err, "broker")
 		}
-		catalog, err := soRepository.ListWithServicePlansByBrokerID(ctx, brokerID)
+		return pspf.ReconcilePublicPlans(ctx, storage, broker)
+	}); err != nil {
+		return nil, err
+	}
+	log.C(ctx).Debugf("Successfully finished reconciling public plans for broker with id %s", brokerID)
+	return response, nil
+}
+
+// ReconcilePublicPlans makes sure that exactly the plans of the broker that are public have a public visibility
+func (pspf *PublicServicePlansFilter) ReconcilePublicPlans(ctx context.Context, storage storage.Warehouse, broker *types.Broker) error {
+	soRepository := storage.ServiceOffering()
+	vRepository := storage.Visibility()
+
+	catalog, err := soRepository.ListWithServicePlansByBrokerID(ctx, broker.ID)
+	if err != nil {
+		return err
+	}
+	for _, serviceOffering := range catalog {
+		// a public visibility of the service offering already makes all of its plans public
+		hasPublicServiceOfferingVisibility := false
+		byServiceOfferingID := query.ByField(query.EqualsOperator, "service_offering_id", serviceOffering.ID)
+		serviceOfferingVisibilities, err := vRepository.List(ctx, byServiceOfferingID)
 		if err != nil {
 			return err
 		}
-		for _, serviceOffering := range catalog {
-			for _, servicePlan := range serviceOffering.Plans {
-				planID := servicePlan.ID
-				isPublic, err := pspf.IsCatalogPlanPublicFunc(broker, serviceOffering, servicePlan)
-				if err != nil {
-					return err
-				}
+		for _, visibility := range serviceOfferingVisibilities {
+			if visibility.IsPublic() {
+				hasPublicServiceOfferingVisibility = true
+			}
+		}
 
-				hasPublicVisibility := false
-				byServicePlanID := query.ByField(query.EqualsOperator, "service_plan_id", planID)
-				visibilitiesForPlan, err := vRepository.List(ctx, byServicePlanID)
-				if err != nil {
-					return err
+		for _, servicePlan := range serviceOffering.Plans {
+			planID := servicePlan.ID
+			isPublic, err := pspf.IsCatalogPlanPublicFunc(broker, serviceOffering, servicePlan)
+			if err != nil {
+				return err
+			}
+
+			hasPublicVisibility := hasPublicServiceOfferingVisibility
+			byServicePlanID := query.ByField(query.EqualsOperator, "service_plan_id", planID)
+			visibilitiesForPlan, err := vRepository.List(ctx, byServicePlanID)
+			if err != nil {
+				return err
+			}
+			for _, visibility := range visibilitiesForPlan {
+				// deny visibilities exclude platforms from public plans and are kept regardless of the plan being public
+				if visibility.IsDeny() {
+					continue
 				}
-				for _, visibility := range visibilitiesForPlan {
-					byVisibilityID := query.ByField(query.EqualsOperator, "id", visibility.ID)
-					if isPublic {
-						if visibility.PlatformID == "" {
-							hasPublicVisibility = true
-							continue
-						} else {
-							if err := vRepository.Delete(ctx, byVisibilityID); err != nil {
-								return err
-							}
-						}
+				byVisibilityID := query.ByField(query.EqualsOperator, "id", visibility.ID)
+				if isPublic {
+					if visibility.IsPublic() {
+						hasPublicVisibility = true
+						continue
 					} else {
-						if visibility.PlatformID == "" {
-							if err := vRepository.Delete(ctx, byVisibilityID); err != nil {
-								return err
-							}
-						} else {
-							continue
+						if err := vRepository.Delete(ctx, byVisibilityID); err != nil {
+							return err
 						}
 					}
-				}
-
-				if isPublic && !hasPublicVisibility {
-					UUID, err := uuid.NewV4()
-					if err != nil {
-						return fmt.Errorf("could not generate GUID for visibility: %s", err)
+				} else {
+					if visibility.IsPublic() {
+						if err := vRepository.Delete(ctx, byVisibilityID); err != nil {
+							return err
+						}
+					} else {
+						continue
 					}
+				}
+			}
 
-					currentTime := time.Now().UTC()
-					planID, err := vRepository.Create(ctx, &types.Visibility{
-						ID:            UUID.String(),
-						ServicePlanID: servicePlan.ID,
-						CreatedAt:     currentTime,
-						UpdatedAt:     currentTime,
-					})
-					if err != nil {
-						return util.HandleStorageError(err, "visibility")
-					}
+			if isPublic && !hasPublicVisibility {
+				UUID, err := uuid.NewV4()
+				if err != nil {
+					return fmt.Errorf("could not generate GUID for visibility: %s", err)
+				}
 
-					log.C(ctx).Debugf("Created new public visibility for broker with id %s and plan with id %s", brokerID, planID)
+				currentTime := time.Now().UTC()
+				planID, err := vRepository.Create(ctx, &types.Visibility{
+					ID:            UUID.String(),
+					ServicePlanID: servicePlan.ID,
+					CreatedAt:     currentTime,
+					UpdatedAt:     currentTime,
+				})
+				if err != nil {
+					return util.HandleStorageError(err, "visibility")
 				}
+
+				log.C(ctx).Debugf("Created new public visibility for broker with id %s and plan with id %s", broker.ID, planID)
 			}
 		}
-		return nil
-	}); err != nil {
-		return nil, err
 	}
-	log.C(ctx).Debugf("Successfully finished reconciling public plans for broker with id %s", brokerID)
-	return response, nil
+	return nil
 }
 
 func (pspf *PublicServicePlansFilter) FilterMatchers() []web.FilterMatcher {
 	return []web.FilterMatcher{
 		{
 			Matchers: []web.Matcher{
-				web.Path(web.BrokersURL + "/**"),
-				web.Methods(http.MethodPost, http.MethodPatch),
+				web.Path(web.BrokersURL),
+				web.Methods(http.MethodPost),
+			},
+		},
+		{
+			Matchers: []web.Matcher{
+				web.Path(web.BrokersURL + "/*"),
+				web.Methods(http.MethodPatch),
 			},
 		},
 	}
@@ -0,0 +1,85 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package filters
+
+import (
+	"context"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/storage"
+)
+
+const publicPlansReconciliationLockKey = 113
+
+// PublicPlansReconciliationJob periodically reconciles the public plans of all registered brokers so that changes to
+// the public plans policy apply to the existing plans. Only one Service Manager instance sharing the same storage runs
+// a reconciliation pass at a time.
+type PublicPlansReconciliationJob struct {
+	filter   *PublicServicePlansFilter
+	interval time.Duration
+}
+
+// NewPublicPlansReconciliationJob returns a job that reconciles the public plans every interval in the same way as the filter
+func NewPublicPlansReconciliationJob(filter *PublicServicePlansFilter, interval time.Duration) *PublicPlansReconciliationJob {
+	return &PublicPlansReconciliationJob{
+		filter:   filter,
+		interval: interval,
+	}
+}
+
+// Run reconciles the public plans until the context is done
+func (j *PublicPlansReconciliationJob) Run(ctx context.Context) {
+	log.C(ctx).Infof("Starting periodic public plans reconciliation with interval %s", j.interval)
+	for {
+		j.reconcile(ctx)
+		select {
+		case <-ctx.Done():
+			log.C(ctx).Info("Stopping periodic public plans reconciliation")
+			return
+		case <-time.After(j.interval):
+		}
+	}
+}
+
+func (j *PublicPlansReconciliationJob) reconcile(ctx context.Context) {
+	if err := j.filter.Repository.InTransaction(ctx, func(ctx context.Context, txStorage storage.Warehouse) error {
+		locked, err := txStorage.AdvisoryLock().TryLock(ctx, publicPlansReconciliationLockKey)
+		if err != nil {
+			return err
+		}
+		if !locked {
+			log.C(ctx).Debug("Public plans reconciliation is already running in another Service Manager instance")
+			return nil
+		}
+
+		brokers, err := txStorage.Broker().List(ctx)
+		if err != nil {
+			return err
+		}
+		for _, broker := range brokers {
+			if err := j.filter.ReconcilePublicPlans(ctx, txStorage, broker); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		log.C(ctx).WithError(err).Error("Could not reconcile public plans")
+		return
+	}
+	log.C(ctx).Debug("Successfully finished reconciling public plans")
+}
@@ -0,0 +1,333 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package filters
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/tidwall/gjson"
+)
+
+// PublicPlansPolicy decides whether a service plan is public using a boolean expression configured for SM.
+//
+// The expression may refer to the fields of the plan, its service offering and its broker with paths such as
+// plan.free, plan.metadata.costs, service.catalog_name, service.metadata.provider or broker.labels.env. The paths can be
+// compared with string, number and boolean literals using == and != and combined with &&, || and !. A path that is not
+// compared is true when its value is true, a non-zero number, a "true" string or a non-empty array such as the values
+// of a broker label. A comparison with an array, such as the values of a broker label, is true when any element matches.
+//
+// Example: plan.free && (broker.labels.env == 'prod' || service.metadata.public == true)
+type PublicPlansPolicy struct {
+	expression string
+	root       policyNode
+}
+
+// NewPublicPlansPolicy parses the expression of the policy
+func NewPublicPlansPolicy(expression string) (*PublicPlansPolicy, error) {
+	tokens, err := tokenizePolicy(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public plans policy %q: %s", expression, err)
+	}
+	parser := &policyParser{tokens: tokens}
+	root, err := parser.parse()
+	if err != nil {
+		return nil, fmt.Errorf("invalid public plans policy %q: %s", expression, err)
+	}
+	return &PublicPlansPolicy{
+		expression: expression,
+		root:       root,
+	}, nil
+}
+
+// String returns the expression of the policy
+func (p *PublicPlansPolicy) String() string {
+	return p.expression
+}
+
+// IsCatalogPlanPublic evaluates the policy for the plan. It can be used as IsCatalogPlanPublicFunc of the
+// PublicServicePlansFilter.
+func (p *PublicPlansPolicy) IsCatalogPlanPublic(broker *types.Broker, catalogService *types.ServiceOffering, catalogPlan *types.ServicePlan) (bool, error) {
+	service := *catalogService
+	service.Plans = nil
+	document, err := json.Marshal(map[string]interface{}{
+		"plan":    catalogPlan,
+		"service": &service,
+		"broker": map[string]interface{}{
+			"id":     broker.ID,
+			"name":   broker.Name,
+			"labels": broker.Labels,
+		},
+	})
+	if err != nil {
+		return false, fmt.Errorf("could not evaluate public plans policy: %s", err)
+	}
+	return isTruthy(p.root.evaluate(document)), nil
+}
+
+type policyNode interface {
+	evaluate(document []byte) gjson.Result
+}
+
+type pathNode string
+
+func (n pathNode) evaluate(document []byte) gjson.Result {
+	return gjson.GetBytes(document, string(n))
+}
+
+type literalNode gjson.Result
+
+func (n literalNode) evaluate([]byte) gjson.Result {
+	return gjson.Result(n)
+}
+
+type notNode struct {
+	operand policyNode
+}
+
+func (n *notNode) evaluate(document []byte) gjson.Result {
+	return booleanResult(!isTruthy(n.operand.evaluate(document)))
+}
+
+type logicalNode struct {
+	operator    string
+	left, right policyNode
+}
+
+func (n *logicalNode) evaluate(document []byte) gjson.Result {
+	left := isTruthy(n.left.evaluate(document))
+	if n.operator == "&&" {
+		return booleanResult(left && isTruthy(n.right.evaluate(document)))
+	}
+	return booleanResult(left || isTruthy(n.right.evaluate(document)))
+}
+
+type comparisonNode struct {
+	operator    string
+	left, right policyNode
+}
+
+func (n *comparisonNode) evaluate(document []byte) gjson.Result {
+	equal := resultsEqual(n.left.evaluate(document), n.right.evaluate(document))
+	if n.operator == "==" {
+		return booleanResult(equal)
+	}
+	return booleanResult(!equal)
+}
+
+func resultsEqual(left, right gjson.Result) bool {
+	if left.IsArray() {
+		for _, element := range left.Array() {
+			if resultsEqual(element, right) {
+				return true
+			}
+		}
+		return false
+	}
+	if right.IsArray() {
+		return resultsEqual(right, left)
+	}
+	return left.Exists() && right.Exists() && left.String() == right.String()
+}
+
+func isTruthy(result gjson.Result) bool {
+	if result.IsArray() {
+		return len(result.Array()) > 0
+	}
+	return result.Bool()
+}
+
+func booleanResult(value bool) gjson.Result {
+	if value {
+		return gjson.Result{Type: gjson.True, Raw: "true"}
+	}
+	return gjson.Result{Type: gjson.False, Raw: "false"}
+}
+
+type policyToken struct {
+	value  string
+	quoted bool
+}
+
+func tokenizePolicy(expression string) ([]policyToken, error) {
+	tokens := make([]policyToken, 0)
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'' || r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated string %s", string(runes[i:]))
+			}
+			tokens = append(tokens, policyToken{value: string(runes[i+1 : end]), quoted: true})
+			i = end + 1
+		case i+1 < len(runes) && isPolicyOperator(string(runes[i:i+2])):
+			tokens = append(tokens, policyToken{value: string(runes[i : i+2])})
+			i += 2
+		case r == '(' || r == ')' || r == '!':
+			tokens = append(tokens, policyToken{value: string(r)})
+			i++
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune("()!=&|'\"", runes[end]) {
+				end++
+			}
+			if end == i {
+				return nil, fmt.Errorf("unexpected %q", string(r))
+			}
+			tokens = append(tokens, policyToken{value: string(runes[i:end])})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+func isPolicyOperator(value string) bool {
+	return value == "&&" || value == "||" || value == "==" || value == "!="
+}
+
+type policyParser struct {
+	tokens   []policyToken
+	position int
+}
+
+func (p *policyParser) parse() (policyNode, error) {
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("expression is empty")
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.position < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.position].value)
+	}
+	return node, nil
+}
+
+func (p *policyParser) peek(operator string) bool {
+	return p.position < len(p.tokens) && !p.tokens[p.position].quoted && p.tokens[p.position].value == operator
+}
+
+func (p *policyParser) parseOr() (policyNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("||") {
+		p.position++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{operator: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *policyParser) parseAnd() (policyNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("&&") {
+		p.position++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{operator: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *policyParser) parseNot() (policyNode, error) {
+	if p.peek("!") {
+		p.position++
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *policyParser) parseComparison() (policyNode, error) {
+	if p.peek("(") {
+		p.position++
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peek(")") {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.position++
+		return node, nil
+	}
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek("==") || p.peek("!=") {
+		operator := p.tokens[p.position].value
+		p.position++
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return &comparisonNode{operator: operator, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *policyParser) parseOperand() (policyNode, error) {
+	if p.position >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	token := p.tokens[p.position]
+	p.position++
+	if token.quoted {
+		return literalNode(gjson.Result{Type: gjson.String, Str: token.value}), nil
+	}
+	switch {
+	case token.value == "true" || token.value == "false":
+		return literalNode(booleanResult(token.value == "true")), nil
+	case isPolicyOperator(token.value) || token.value == "(" || token.value == ")" || token.value == "!":
+		return nil, fmt.Errorf("unexpected %q", token.value)
+	case strings.ContainsAny(token.value[:1], "-0123456789"):
+		number := gjson.Parse(token.value)
+		if number.Type != gjson.Number {
+			return nil, fmt.Errorf("invalid number %q", token.value)
+		}
+		return literalNode(number), nil
+	case strings.HasPrefix(token.value, "plan.") || strings.HasPrefix(token.value, "service.") || strings.HasPrefix(token.value, "broker."):
+		return pathNode(token.value), nil
+	default:
+		return nil, fmt.Errorf("unknown path %q: paths must start with plan., service. or broker.", token.value)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package filters
+
+import (
+	"encoding/json"
+
+	"github.com/Peripli/service-manager/pkg/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Public Plans Policy", func() {
+	broker := &types.Broker{
+		ID:   "broker-id",
+		Name: "broker",
+		Labels: types.Labels{
+			"env": {"dev", "prod"},
+		},
+	}
+	service := &types.ServiceOffering{
+		CatalogName: "service",
+		Metadata:    json.RawMessage(`{"provider":"sm","public":true}`),
+	}
+	plan := &types.ServicePlan{
+		CatalogName: "plan",
+		Free:        true,
+		Metadata:    json.RawMessage(`{"tier":"basic","quota":5}`),
+	}
+
+	DescribeTable("evaluation",
+		func(expression string, expected bool) {
+			policy, err := NewPublicPlansPolicy(expression)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy.IsCatalogPlanPublic(broker, service, plan)).To(Equal(expected))
+		},
+		Entry("boolean plan field", "plan.free", true),
+		Entry("negated boolean plan field", "!plan.free", false),
+		Entry("plan metadata string", "plan.metadata.tier == 'basic'", true),
+		Entry("plan metadata number", "plan.metadata.quota == 5", true),
+		Entry("missing metadata", "plan.metadata.missing", false),
+		Entry("missing metadata compared", "plan.metadata.missing == 'basic'", false),
+		Entry("service metadata boolean", `service.metadata.public == true`, true),
+		Entry("service field", `service.catalog_name != "service"`, false),
+		Entry("any broker label value", "broker.labels.env == 'prod'", true),
+		Entry("present broker label", "broker.labels.env", true),
+		Entry("missing broker label", "broker.labels.region", false),
+		Entry("precedence of && over ||", "plan.free || plan.metadata.tier == 'premium' && broker.labels.region", true),
+		Entry("parentheses", "(plan.free || plan.metadata.tier == 'premium') && broker.labels.region", false),
+	)
+
+	DescribeTable("invalid expressions",
+		func(expression string) {
+			_, err := NewPublicPlansPolicy(expression)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("empty", ""),
+		Entry("unknown path", "free"),
+		Entry("unterminated string", "plan.name == 'basic"),
+		Entry("missing parenthesis", "(plan.free"),
+		Entry("dangling operator", "plan.free &&"),
+		Entry("single equals", "plan.name = 'basic'"),
+	)
+})
@@ -30,6 +30,9 @@ import (
 type HTTPHandler struct {
 	Handler            web.Handler
 	requestBodyMaxSize int
+
+	// OptionalBody allows requests without a body and a Content-Type to be handled
+	OptionalBody bool
 }
 
 // NewHTTPHandler creates a new HTTPHandler from the provided web.Handler
@@ -55,7 +58,7 @@ func (h *HTTPHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 func (h *HTTPHandler) serve(res http.ResponseWriter, req *http.Request) error {
 	req.Body = http.MaxBytesReader(res, req.Body, int64(h.requestBodyMaxSize))
 
-	request, err := convertToWebRequest(req)
+	request, err := convertToWebRequest(req, h.OptionalBody)
 	if err != nil {
 		return err
 	}
@@ -82,12 +85,14 @@ func (h *HTTPHandler) serve(res http.ResponseWriter, req *http.Request) error {
 	return nil
 }
 
-func convertToWebRequest(request *http.Request) (*web.Request, error) {
+func convertToWebRequest(request *http.Request, optionalBody bool) (*web.Request, error) {
 	pathParams := mux.Vars(request)
 
 	var body []byte
 	var err error
-	if request.Method == "PUT" || request.Method == "POST" || request.Method == "PATCH" {
+	if optionalBody && request.ContentLength == 0 && request.Header.Get("Content-Type") == "" {
+		body = []byte{}
+	} else if request.Method == "PUT" || request.Method == "POST" || request.Method == "PATCH" {
 		body, err = util.RequestBodyToBytes(request)
 		err = isPayloadTooLargeErr(request.Context(), err)
 	}
@@ -99,6 +99,29 @@ var _ = Describe("Handler", func() {
 			})
 		})
 
+		Context("when http request has no body and no Content-Type", func() {
+			Specify("response contains a proper HTTPError", func() {
+				response := makeRequest(http.MethodPost, "http://example.com", "", map[string]string{})
+
+				validateHTTPErrorOccurred(response, http.StatusUnsupportedMediaType)
+			})
+
+			Context("and the body is optional", func() {
+				BeforeEach(func() {
+					handler.OptionalBody = true
+					fakeHandler.HandleReturns(&web.Response{StatusCode: http.StatusOK}, nil)
+				})
+
+				Specify("the request is handled with an empty body", func() {
+					response := makeRequest(http.MethodPost, "http://example.com", "", map[string]string{})
+
+					Expect(response.Code).To(Equal(http.StatusOK))
+					Expect(fakeHandler.HandleCallCount()).To(Equal(1))
+					Expect(fakeHandler.HandleArgsForCall(0).Body).To(BeEmpty())
+				})
+			})
+		})
+
 		Context("when http request has invalid json body", func() {
 			Specify("response contains a proper HTTPError", func() {
 				response := makeRequest(http.MethodPost, "http://example.com", invalidJSON, map[string]string{
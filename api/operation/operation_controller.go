@@ -0,0 +1,50 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package operation
+
+import (
+	"net/http"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
+)
+
+const (
+	reqOperationID = "operation_id"
+)
+
+// Controller operation controller
+type Controller struct {
+	OperationStorage storage.Operation
+}
+
+var _ web.Controller = &Controller{}
+
+// getOperation handler for GET /v1/operations/:operation_id
+func (c *Controller) getOperation(r *web.Request) (*web.Response, error) {
+	operationID := r.PathParams[reqOperationID]
+	ctx := r.Context()
+	log.C(ctx).Debugf("Getting operation with id %s", operationID)
+
+	operation, err := c.OperationStorage.Get(ctx, operationID)
+	if err = util.HandleStorageError(err, "operation"); err != nil {
+		return nil, err
+	}
+	return util.NewJSONResponse(http.StatusOK, operation)
+}
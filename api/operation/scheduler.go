@@ -0,0 +1,151 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package operation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
+	"github.com/gofrs/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Job is a unit of work executed asynchronously. It returns the id of the resource that it has changed.
+type Job func(ctx context.Context) (string, error)
+
+type scheduledJob struct {
+	operation *types.Operation
+	job       Job
+	logger    *logrus.Entry
+}
+
+// Scheduler executes jobs in a fixed pool of workers and tracks their outcome in operations
+type Scheduler struct {
+	repository storage.Repository
+	jobs       chan *scheduledJob
+}
+
+// NewScheduler starts the specified number of workers that execute the scheduled jobs until the context is done.
+// At most queueSize jobs can wait for a free worker.
+func NewScheduler(ctx context.Context, repository storage.Repository, workersCount, queueSize int) *Scheduler {
+	scheduler := &Scheduler{
+		repository: repository,
+		jobs:       make(chan *scheduledJob, queueSize),
+	}
+	for i := 0; i < workersCount; i++ {
+		go scheduler.work(ctx)
+	}
+	return scheduler
+}
+
+// Schedule stores a new operation in progress for the resource type and enqueues the job for execution.
+// The operation is updated with the outcome of the job once it finishes.
+func (s *Scheduler) Schedule(ctx context.Context, operationType types.OperationType, resourceType string, job Job) (*types.Operation, error) {
+	UUID, err := uuid.NewV4()
+	if err != nil {
+		return nil, fmt.Errorf("could not generate GUID for operation: %s", err)
+	}
+	currentTime := time.Now().UTC()
+	operation := &types.Operation{
+		ID:           UUID.String(),
+		Type:         operationType,
+		State:        types.InProgress,
+		ResourceType: resourceType,
+		CreatedAt:    currentTime,
+		UpdatedAt:    currentTime,
+	}
+	if _, err := s.repository.Operation().Create(ctx, operation); err != nil {
+		return nil, util.HandleStorageError(err, "operation")
+	}
+
+	select {
+	case s.jobs <- &scheduledJob{operation: operation, job: job, logger: log.C(ctx)}:
+		log.C(ctx).Debugf("Scheduled %s operation with id %s for %s", operationType, operation.ID, resourceType)
+		return operation, nil
+	default:
+		err := &util.HTTPError{
+			ErrorType:   "ServiceUnavailable",
+			Description: "too many operations are in progress, retry later",
+			StatusCode:  http.StatusServiceUnavailable,
+		}
+		s.finish(ctx, operation, "", err)
+		return nil, err
+	}
+}
+
+func (s *Scheduler) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case scheduled := <-s.jobs:
+			// the job keeps the logger of the request that scheduled it so that its logs can be correlated
+			jobCtx := log.ContextWithLogger(ctx, scheduled.logger)
+			resourceID, err := scheduled.job(jobCtx)
+			s.finish(jobCtx, scheduled.operation, resourceID, err)
+		}
+	}
+}
+
+func (s *Scheduler) finish(ctx context.Context, operation *types.Operation, resourceID string, jobErr error) {
+	operation.ResourceID = resourceID
+	operation.UpdatedAt = time.Now().UTC()
+	if jobErr == nil {
+		operation.State = types.Succeeded
+	} else {
+		log.C(ctx).WithError(jobErr).Errorf("Operation with id %s failed", operation.ID)
+		operation.State = types.Failed
+		operation.Errors = operationErrors(jobErr)
+	}
+	if err := s.repository.Operation().Update(ctx, operation); err != nil {
+		log.C(ctx).WithError(err).Errorf("Could not update operation with id %s", operation.ID)
+	}
+}
+
+// operationErrors returns the errors of a failed operation in the same way as they are returned in API responses
+func operationErrors(err error) json.RawMessage {
+	httpErr, ok := err.(*util.HTTPError)
+	if !ok {
+		httpErr = &util.HTTPError{
+			ErrorType:   "InternalError",
+			Description: "Internal server error",
+		}
+	}
+	errBytes, err := json.Marshal(httpErr)
+	if err != nil {
+		return nil
+	}
+	return errBytes
+}
+
+// NewAcceptedResponse returns the response for a request that has started the operation
+func NewAcceptedResponse(operation *types.Operation) (*web.Response, error) {
+	response, err := util.NewJSONResponse(http.StatusAccepted, operation)
+	if err != nil {
+		return nil, err
+	}
+	response.Header.Set("Location", web.OperationsURL+"/"+operation.ID)
+	return response, nil
+}
@@ -0,0 +1,61 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package osb
+
+import (
+	"net/http"
+
+	"github.com/Peripli/service-manager/pkg/web"
+)
+
+const (
+	// AggregatedBrokerID is the path segment under which SM serves the services of all brokers as a single OSB broker
+	AggregatedBrokerID = "aggregated"
+
+	instanceIDPathParam = "instance_id"
+
+	aggregatedBaseURL = web.OSBURL + "/" + AggregatedBrokerID
+
+	aggregatedCatalogURL                        = aggregatedBaseURL + "/v2/catalog"
+	aggregatedServiceInstanceURL                = aggregatedBaseURL + "/v2/service_instances/{" + instanceIDPathParam + "}"
+	aggregatedServiceInstanceLastOperationURL   = aggregatedServiceInstanceURL + "/last_operation"
+	aggregatedServiceBindingURL                 = aggregatedServiceInstanceURL + "/service_bindings/{binding_id}"
+	aggregatedServiceBindingLastOperationURL    = aggregatedServiceBindingURL + "/last_operation"
+	aggregatedServiceBindingAdaptCredentialsURL = aggregatedServiceBindingURL + "/adapt_credentials"
+)
+
+// Routes implements api.Controller.Routes by providing the routes for the aggregated OSB API. The aggregated
+// controller must be registered before the OSB controller so that its routes take precedence.
+func (c *aggregatedController) Routes() []web.Route {
+	return []web.Route{
+		{Endpoint: web.Endpoint{Method: http.MethodGet, Path: aggregatedCatalogURL}, Handler: c.catalog},
+
+		{Endpoint: web.Endpoint{Method: http.MethodGet, Path: aggregatedServiceInstanceURL}, Handler: c.forwardToInstanceBroker},
+		{Endpoint: web.Endpoint{Method: http.MethodPut, Path: aggregatedServiceInstanceURL}, Handler: c.provision},
+		{Endpoint: web.Endpoint{Method: http.MethodPatch, Path: aggregatedServiceInstanceURL}, Handler: c.forwardToInstanceBroker},
+		{Endpoint: web.Endpoint{Method: http.MethodDelete, Path: aggregatedServiceInstanceURL}, Handler: c.forwardToInstanceBroker},
+
+		{Endpoint: web.Endpoint{Method: http.MethodGet, Path: aggregatedServiceBindingURL}, Handler: c.forwardToInstanceBroker},
+		{Endpoint: web.Endpoint{Method: http.MethodPut, Path: aggregatedServiceBindingURL}, Handler: c.forwardToInstanceBroker},
+		{Endpoint: web.Endpoint{Method: http.MethodDelete, Path: aggregatedServiceBindingURL}, Handler: c.forwardToInstanceBroker},
+
+		{Endpoint: web.Endpoint{Method: http.MethodGet, Path: aggregatedServiceInstanceLastOperationURL}, Handler: c.forwardToInstanceBroker},
+		{Endpoint: web.Endpoint{Method: http.MethodGet, Path: aggregatedServiceBindingLastOperationURL}, Handler: c.forwardToInstanceBroker},
+
+		{Endpoint: web.Endpoint{Method: http.MethodPost, Path: aggregatedServiceBindingAdaptCredentialsURL}, Handler: c.forwardToInstanceBroker},
+	}
+}
@@ -0,0 +1,363 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package osb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// aggregatedController implements api.Controller by serving the services of all brokers that are visible to the
+// calling platform as a single OSB broker. The services and plans are identified by their SM generated ids and
+// the OSB calls are routed to the broker that provides the plan or, once provisioned, the service instance.
+type aggregatedController struct {
+	osb            *controller
+	repository     storage.Repository
+	catalogFetcher *StorageCatalogFetcher
+}
+
+var _ web.Controller = &aggregatedController{}
+
+// NewAggregatedController returns new aggregated OSB controller
+func NewAggregatedController(repository storage.Repository, brokerFetcher BrokerFetcher, catalogFetcher *StorageCatalogFetcher) web.Controller {
+	return &aggregatedController{
+		osb: &controller{
+			brokerFetcher:  brokerFetcher,
+			catalogFetcher: catalogFetcher,
+		},
+		repository:     repository,
+		catalogFetcher: catalogFetcher,
+	}
+}
+
+func (c *aggregatedController) catalog(r *web.Request) (*web.Response, error) {
+	ctx := r.Context()
+	platformID, err := platformIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.C(ctx).Debugf("Fetching aggregated catalog for platform with id %s", platformID)
+
+	visibilities, err := c.platformVisibilities(ctx, platformID)
+	if err != nil {
+		return nil, util.HandleStorageError(err, "visibility")
+	}
+	brokers, err := c.repository.Broker().List(ctx)
+	if err != nil {
+		return nil, util.HandleStorageError(err, "broker")
+	}
+	services := make([]*types.ServiceOffering, 0)
+	for _, broker := range brokers {
+		if broker.State == types.BrokerSuspended {
+			continue
+		}
+		brokerServices, err := c.catalogFetcher.fetchServices(ctx, broker.ID, broker)
+		if err != nil {
+			return nil, err
+		}
+		for _, service := range brokerServices {
+			plans := make([]*types.ServicePlan, 0, len(service.Plans))
+			for _, plan := range service.Plans {
+				if types.IsPlanVisible(visibilities, plan) {
+					plans = append(plans, plan)
+				}
+			}
+			if len(plans) == 0 {
+				continue
+			}
+			service.Plans = plans
+			services = append(services, service)
+		}
+	}
+	return util.NewJSONResponse(http.StatusOK, &types.ServiceOfferings{
+		ServiceOfferings: services,
+	})
+}
+
+func (c *aggregatedController) provision(r *web.Request) (*web.Response, error) {
+	ctx := r.Context()
+	instanceID := r.PathParams[instanceIDPathParam]
+	platformID, err := platformIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	servicePlan, serviceOffering, err := c.resolveVisiblePlan(ctx, platformID, gjson.GetBytes(r.Body, "plan_id").String())
+	if err != nil {
+		return nil, err
+	}
+	if serviceID := gjson.GetBytes(r.Body, "service_id").String(); serviceID != serviceOffering.ID {
+		return nil, &util.HTTPError{
+			ErrorType:   "BadRequest",
+			Description: fmt.Sprintf("service plan %s does not belong to service %s", servicePlan.ID, serviceID),
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
+	log.C(ctx).Debugf("Routing provisioning of service instance %s to broker with id %s", instanceID, serviceOffering.BrokerID)
+
+	remembered, err := c.rememberInstance(ctx, &types.AggregatedInstance{
+		ID:            instanceID,
+		BrokerID:      serviceOffering.BrokerID,
+		PlatformID:    platformID,
+		ServicePlanID: servicePlan.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.translateIDs(ctx, r); err != nil {
+		return nil, err
+	}
+	response, err := c.forward(r, serviceOffering.BrokerID)
+	if remembered && (err != nil || response.StatusCode >= http.StatusBadRequest) {
+		c.forgetInstance(ctx, instanceID)
+	}
+	return response, err
+}
+
+func (c *aggregatedController) forwardToInstanceBroker(r *web.Request) (*web.Response, error) {
+	ctx := r.Context()
+	instanceID := r.PathParams[instanceIDPathParam]
+	_, isBindingRequest := r.PathParams["binding_id"]
+	platformID, err := platformIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, err := c.repository.AggregatedInstance().Get(ctx, instanceID)
+	if err == nil && instance.PlatformID != platformID {
+		err = util.ErrNotFoundInStorage
+	}
+	if err == util.ErrNotFoundInStorage && r.Method == http.MethodDelete && !isBindingRequest {
+		return util.NewJSONResponse(http.StatusGone, map[string]string{})
+	}
+	if err != nil {
+		return nil, util.HandleStorageError(err, "service_instance")
+	}
+
+	if planID := gjson.GetBytes(r.Body, "plan_id"); planID.Exists() && r.Method == http.MethodPatch {
+		_, serviceOffering, err := c.resolveVisiblePlan(ctx, platformID, planID.String())
+		if err != nil {
+			return nil, err
+		}
+		if serviceOffering.BrokerID != instance.BrokerID {
+			return nil, &util.HTTPError{
+				ErrorType:   "BadRequest",
+				Description: fmt.Sprintf("service instance %s cannot be updated to a plan of another broker", instanceID),
+				StatusCode:  http.StatusBadRequest,
+			}
+		}
+	}
+	if err := c.translateIDs(ctx, r); err != nil {
+		return nil, err
+	}
+	response, err := c.forward(r, instance.BrokerID)
+	if err != nil {
+		return nil, err
+	}
+	if !isBindingRequest && isInstanceGone(r, response) {
+		c.forgetInstance(ctx, instanceID)
+	}
+	return response, nil
+}
+
+// isInstanceGone returns whether the broker reported that the service instance was deleted
+func isInstanceGone(r *web.Request, response *web.Response) bool {
+	if r.Method == http.MethodDelete {
+		return response.StatusCode == http.StatusOK || response.StatusCode == http.StatusGone
+	}
+	return strings.HasSuffix(r.URL.Path, "/last_operation") && response.StatusCode == http.StatusGone
+}
+
+func (c *aggregatedController) forward(r *web.Request, brokerID string) (*web.Response, error) {
+	logger := log.C(r.Context())
+	response, err := c.osb.proxy(r, logger, brokerID)
+	if err != nil {
+		logger.WithError(err).Errorf("error proxying call to service broker with id %s", brokerID)
+		return nil, brokerUnreachableError(brokerID)
+	}
+	return response, nil
+}
+
+// rememberInstance stores the broker of the service instance and returns whether it was not already known
+func (c *aggregatedController) rememberInstance(ctx context.Context, instance *types.AggregatedInstance) (bool, error) {
+	existing, err := c.repository.AggregatedInstance().Get(ctx, instance.ID)
+	if err == nil {
+		if existing.BrokerID != instance.BrokerID || existing.PlatformID != instance.PlatformID {
+			return false, &util.HTTPError{
+				ErrorType:   "Conflict",
+				Description: fmt.Sprintf("service instance %s already exists", instance.ID),
+				StatusCode:  http.StatusConflict,
+			}
+		}
+		return false, nil
+	}
+	if err != util.ErrNotFoundInStorage {
+		return false, util.HandleStorageError(err, "service_instance")
+	}
+	currentTime := time.Now().UTC()
+	instance.CreatedAt = currentTime
+	instance.UpdatedAt = currentTime
+	if _, err := c.repository.AggregatedInstance().Create(ctx, instance); err != nil {
+		return false, util.HandleStorageError(err, "service_instance")
+	}
+	return true, nil
+}
+
+func (c *aggregatedController) forgetInstance(ctx context.Context, instanceID string) {
+	byID := query.ByField(query.EqualsOperator, "id", instanceID)
+	if err := c.repository.AggregatedInstance().Delete(ctx, byID); err != nil && err != util.ErrNotFoundInStorage {
+		log.C(ctx).WithError(err).Errorf("Could not delete the broker mapping of service instance %s", instanceID)
+	}
+}
+
+// resolveVisiblePlan returns the active service plan with the specified SM id and its service offering if the plan
+// is visible to the platform
+func (c *aggregatedController) resolveVisiblePlan(ctx context.Context, platformID, planID string) (*types.ServicePlan, *types.ServiceOffering, error) {
+	unknownPlanErr := &util.HTTPError{
+		ErrorType:   "BadRequest",
+		Description: fmt.Sprintf("service plan %s is not available", planID),
+		StatusCode:  http.StatusBadRequest,
+	}
+	if planID == "" {
+		return nil, nil, unknownPlanErr
+	}
+	servicePlan, err := c.repository.ServicePlan().Get(ctx, planID)
+	if err == util.ErrNotFoundInStorage {
+		return nil, nil, unknownPlanErr
+	}
+	if err != nil {
+		return nil, nil, util.HandleStorageError(err, "service_plan")
+	}
+	if !servicePlan.Active {
+		return nil, nil, unknownPlanErr
+	}
+	visibilities, err := c.platformVisibilities(ctx, platformID)
+	if err != nil {
+		return nil, nil, util.HandleStorageError(err, "visibility")
+	}
+	if !types.IsPlanVisible(visibilities, servicePlan) {
+		return nil, nil, unknownPlanErr
+	}
+	serviceOffering, err := c.repository.ServiceOffering().Get(ctx, servicePlan.ServiceOfferingID)
+	if err != nil {
+		return nil, nil, util.HandleStorageError(err, "service_offering")
+	}
+	return servicePlan, serviceOffering, nil
+}
+
+// platformVisibilities returns the visibilities that currently apply to the platform
+func (c *aggregatedController) platformVisibilities(ctx context.Context, platformID string) ([]*types.Visibility, error) {
+	platform, err := c.repository.Platform().Get(ctx, platformID)
+	if err != nil {
+		return nil, err
+	}
+	visibilities, err := c.repository.Visibility().List(ctx, query.ByField(query.EqualsOrNilOperator, "platform_id", platformID))
+	if err != nil {
+		return nil, err
+	}
+	return types.NewVisibilityResolver(visibilities, time.Now()).Resolve(platform), nil
+}
+
+// translateIDs replaces the SM generated service and plan ids in the request with the ids from the broker catalogs
+func (c *aggregatedController) translateIDs(ctx context.Context, r *web.Request) error {
+	catalogIDs := map[string]func(ctx context.Context, id string) (string, error){
+		"service_id": c.serviceCatalogID,
+		"plan_id":    c.planCatalogID,
+	}
+	for field, catalogID := range catalogIDs {
+		for _, path := range []string{field, "previous_values." + field} {
+			value := gjson.GetBytes(r.Body, path)
+			if !value.Exists() {
+				continue
+			}
+			id, err := catalogID(ctx, value.String())
+			if err != nil {
+				return err
+			}
+			if r.Body, err = sjson.SetBytes(r.Body, path, id); err != nil {
+				return err
+			}
+		}
+	}
+
+	params := r.URL.Query()
+	for field, catalogID := range catalogIDs {
+		value := params.Get(field)
+		if value == "" {
+			continue
+		}
+		id, err := catalogID(ctx, value)
+		if err != nil {
+			return err
+		}
+		params.Set(field, id)
+	}
+	r.URL.RawQuery = params.Encode()
+	return nil
+}
+
+func (c *aggregatedController) serviceCatalogID(ctx context.Context, id string) (string, error) {
+	serviceOffering, err := c.repository.ServiceOffering().Get(ctx, id)
+	if err == util.ErrNotFoundInStorage {
+		return id, nil
+	}
+	if err != nil {
+		return "", util.HandleStorageError(err, "service_offering")
+	}
+	return serviceOffering.CatalogID, nil
+}
+
+func (c *aggregatedController) planCatalogID(ctx context.Context, id string) (string, error) {
+	servicePlan, err := c.repository.ServicePlan().Get(ctx, id)
+	if err == util.ErrNotFoundInStorage {
+		return id, nil
+	}
+	if err != nil {
+		return "", util.HandleStorageError(err, "service_plan")
+	}
+	return servicePlan.CatalogID, nil
+}
+
+// platformIDFromContext returns the id of the platform that performs the request
+func platformIDFromContext(ctx context.Context) (string, error) {
+	forbiddenErr := &util.HTTPError{
+		ErrorType:   "Forbidden",
+		Description: "the aggregated broker is available only to platforms",
+		StatusCode:  http.StatusForbidden,
+	}
+	user, ok := web.UserFromContext(ctx)
+	if !ok {
+		return "", forbiddenErr
+	}
+	platform := &types.Platform{}
+	if err := user.Data.Data(platform); err != nil || platform.ID == "" {
+		return "", forbiddenErr
+	}
+	return platform.ID, nil
+}
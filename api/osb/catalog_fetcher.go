@@ -3,32 +3,115 @@ package osb
 import (
 	"context"
 
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/query"
 	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
 	"github.com/Peripli/service-manager/storage"
 )
 
 // StorageCatalogFetcher fetches the broker's catalog from SM DB
 type StorageCatalogFetcher struct {
 	CatalogStorage storage.ServiceOffering
+	// CatalogOverrideStorage is optional - when set the SM-managed catalog overrides are applied to the fetched catalog
+	CatalogOverrideStorage storage.CatalogOverride
+	// BrokerStorage is optional - when set the catalog of a suspended broker is empty and the naming policy of the
+	// broker is applied to the fetched catalog
+	BrokerStorage storage.Broker
 }
 
 // FetchCatalog implements osb.CatalogFetcher and fetches the catalog for the broker with the specified broker id from SM DB
 func (scf *StorageCatalogFetcher) FetchCatalog(ctx context.Context, brokerID string) (*types.ServiceOfferings, error) {
-	catalog, err := scf.CatalogStorage.ListWithServicePlansByBrokerID(ctx, brokerID)
+	var broker *types.Broker
+	if scf.BrokerStorage != nil {
+		// only the state and the naming policy of the broker are needed so its credentials are not decrypted
+		var err error
+		if broker, err = scf.BrokerStorage.Get(ctx, brokerID); err != nil {
+			return nil, util.HandleStorageError(err, "broker")
+		}
+		if broker.State == types.BrokerSuspended {
+			log.C(ctx).Debugf("Broker with id %s is suspended. Its services are omitted from the catalog", brokerID)
+			return &types.ServiceOfferings{
+				ServiceOfferings: []*types.ServiceOffering{},
+			}, nil
+		}
+	}
+	services, err := scf.fetchServices(ctx, brokerID, broker)
 	if err != nil {
 		return nil, err
 	}
 
 	// SM generates its own ids for the services and plans - currently for the platform we want to provide the original catalog id
-	for _, service := range catalog {
+	for _, service := range services {
 		service.ID = service.CatalogID
-		service.Name = service.CatalogName
 		for _, plan := range service.Plans {
 			plan.ID = plan.CatalogID
-			plan.Name = plan.CatalogName
 		}
 	}
 	return &types.ServiceOfferings{
-		ServiceOfferings: catalog,
+		ServiceOfferings: services,
 	}, nil
 }
+
+// fetchServices returns the services and plans of the broker as offered to the platforms. The SM generated ids are kept.
+// The naming policy of the broker is applied unless the broker is nil.
+func (scf *StorageCatalogFetcher) fetchServices(ctx context.Context, brokerID string, broker *types.Broker) ([]*types.ServiceOffering, error) {
+	catalog, err := scf.CatalogStorage.ListWithServicePlansByBrokerID(ctx, brokerID)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := scf.fetchOverrides(ctx, brokerID)
+	if err != nil {
+		return nil, err
+	}
+
+	services := make([]*types.ServiceOffering, 0, len(catalog))
+	for _, service := range catalog {
+		serviceOverride := overrides.ForServiceOffering(brokerID, service.CatalogID)
+		if serviceOverride.IsHidden() {
+			continue
+		}
+		if err := serviceOverride.ApplyToServiceOffering(service); err != nil {
+			return nil, err
+		}
+		service.Name = service.CatalogName
+		// inactive plans are no longer offered by the broker and are kept only until their visibilities are removed
+		activePlans := make([]*types.ServicePlan, 0, len(service.Plans))
+		for _, plan := range service.Plans {
+			if !plan.Active {
+				continue
+			}
+			planOverride := overrides.ForServicePlan(brokerID, service.CatalogID, plan.CatalogID)
+			if planOverride.IsHidden() {
+				continue
+			}
+			if err := planOverride.ApplyToServicePlan(plan); err != nil {
+				return nil, err
+			}
+			plan.Name = plan.CatalogName
+			activePlans = append(activePlans, plan)
+		}
+		if len(activePlans) == 0 {
+			continue
+		}
+		service.Plans = activePlans
+		services = append(services, service)
+	}
+	if broker != nil && broker.NamingPolicy != nil {
+		if err := broker.NamingPolicy.Apply(broker, services); err != nil {
+			return nil, err
+		}
+	}
+	return services, nil
+}
+
+func (scf *StorageCatalogFetcher) fetchOverrides(ctx context.Context, brokerID string) (types.CatalogOverridesIndex, error) {
+	if scf.CatalogOverrideStorage == nil {
+		return types.CatalogOverridesIndex{}, nil
+	}
+	overrides, err := scf.CatalogOverrideStorage.List(ctx, query.ByField(query.EqualsOperator, "broker_id", brokerID))
+	if err != nil {
+		return nil, err
+	}
+	return types.NewCatalogOverridesIndex(overrides), nil
+}
@@ -91,16 +91,20 @@ func (c *controller) handler(f func(r *web.Request, logger *logrus.Entry, id str
 		response, err := f(request, logger, brokerID)
 		if err != nil {
 			logger.WithError(err).Errorf("error proxying call to service broker with id %s", brokerID)
-			return nil, &util.HTTPError{
-				ErrorType:   "ServiceBrokerErr",
-				Description: fmt.Sprintf("could not reach service broker with id %s", brokerID),
-				StatusCode:  http.StatusBadGateway,
-			}
+			return nil, brokerUnreachableError(brokerID)
 		}
 		return response, nil
 	}
 }
 
+func brokerUnreachableError(brokerID string) error {
+	return &util.HTTPError{
+		ErrorType:   "ServiceBrokerErr",
+		Description: fmt.Sprintf("could not reach service broker with id %s", brokerID),
+		StatusCode:  http.StatusBadGateway,
+	}
+}
+
 func (c *controller) catalog(r *web.Request, logger *logrus.Entry, brokerID string) (*web.Response, error) {
 	ctx := r.Context()
 	if c.catalogFetcher == nil {
@@ -130,6 +134,15 @@ func (c *controller) proxy(r *web.Request, logger *logrus.Entry, brokerID string
 	}
 	logger.Debugf("Fetched broker %s with id %s accessible at %s", broker.ID, broker.Name, broker.BrokerURL)
 
+	// provision and bind are the only OSB operations that use PUT
+	if broker.State == types.BrokerSuspended && r.Method == http.MethodPut {
+		logger.Infof("Rejecting request to suspended broker with id %s", brokerID)
+		return util.NewJSONResponse(http.StatusUnprocessableEntity, &util.HTTPError{
+			ErrorType:   "BrokerSuspended",
+			Description: fmt.Sprintf("service broker %s is suspended and does not accept new service instances and bindings", broker.Name),
+		})
+	}
+
 	targetBrokerURL, _ := url.Parse(broker.BrokerURL)
 
 	m := osbPathPattern.FindStringSubmatch(r.URL.Path)
@@ -0,0 +1,115 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package platform
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/security"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
+)
+
+type credentialsRotationRequest struct {
+	// Overlap is the duration such as 30m for which the current credentials remain valid after the rotation
+	Overlap *string `json:"overlap"`
+}
+
+// Validate implements InputValidator and verifies the overlap is a non-negative duration
+func (crr *credentialsRotationRequest) Validate() error {
+	if crr.Overlap == nil {
+		return nil
+	}
+	overlap, err := time.ParseDuration(*crr.Overlap)
+	if err != nil {
+		return fmt.Errorf("invalid overlap: %s", err)
+	}
+	if overlap < 0 {
+		return fmt.Errorf("overlap must not be negative")
+	}
+	return nil
+}
+
+// rotateCredentials handler for POST /v1/platforms/:platform_id/credentials/rotate
+func (c *Controller) rotateCredentials(r *web.Request) (*web.Response, error) {
+	platformID := r.PathParams[reqPlatformID]
+	ctx := r.Context()
+	logger := log.C(ctx)
+	logger.Debugf("Rotating credentials of platform with id %s", platformID)
+
+	overlap := c.CredentialsOverlap
+	if len(r.Body) != 0 {
+		rotationRequest := &credentialsRotationRequest{}
+		if err := util.BytesToObject(r.Body, rotationRequest); err != nil {
+			return nil, err
+		}
+		if rotationRequest.Overlap != nil {
+			// the duration is already verified by Validate
+			overlap, _ = time.ParseDuration(*rotationRequest.Overlap)
+		}
+	}
+
+	credentials, err := types.GenerateCredentials()
+	if err != nil {
+		logger.Error("Could not generate credentials for platform")
+		return nil, err
+	}
+	plainPassword := credentials.Basic.Password
+	transformedPassword, err := security.HashPassword([]byte(plainPassword))
+	if err != nil {
+		return nil, err
+	}
+	credentials.Basic.Password = string(transformedPassword)
+
+	// the platform is locked so that concurrent rotations do not drop the credentials set by one another
+	var platform *types.Platform
+	if err := c.Repository.InTransaction(ctx, func(ctx context.Context, txStorage storage.Warehouse) error {
+		if platform, err = txStorage.Platform().GetForUpdate(ctx, platformID); err != nil {
+			return util.HandleStorageError(err, "platform")
+		}
+
+		currentTime := time.Now().UTC()
+		if overlap > 0 {
+			platform.PreviousCredentials = platform.Credentials
+			platform.PreviousCredentialsExpireAt = currentTime.Add(overlap)
+		} else {
+			// the previous credentials are invalidated right away by letting them expire now
+			platform.PreviousCredentialsExpireAt = currentTime
+		}
+		platform.Credentials = credentials
+		platform.UpdatedAt = currentTime
+
+		if err := txStorage.Platform().Update(ctx, platform); err != nil {
+			return util.HandleStorageError(err, "platform")
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	logger.Infof("Rotated credentials of platform with id %s. Previous credentials expire at %s",
+		platformID, util.ToRFCFormat(platform.PreviousCredentialsExpireAt))
+
+	// the plaintext password is returned only once and cannot be retrieved afterwards
+	platform.Credentials.Basic.Password = plainPassword
+	return util.NewJSONResponse(http.StatusOK, platform)
+}
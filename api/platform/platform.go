@@ -68,5 +68,13 @@ func (c *Controller) Routes() []web.Route {
 			},
 			Handler: c.patchPlatform,
 		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodPost,
+				Path:   web.PlatformsURL + "/{platform_id}/credentials/rotate",
+			},
+			Handler:      c.rotateCredentials,
+			OptionalBody: true,
+		},
 	}
 }
@@ -29,6 +29,7 @@ import (
 	"github.com/Peripli/service-manager/pkg/web"
 	"github.com/Peripli/service-manager/storage"
 	"github.com/gofrs/uuid"
+	"github.com/tidwall/sjson"
 )
 
 const (
@@ -38,7 +39,13 @@ const (
 // Controller platform controller
 type Controller struct {
 	PlatformStorage storage.Platform
-	Encrypter       security.Encrypter
+
+	// Repository is used for the platform changes which have to be made in a transaction
+	Repository storage.Repository
+
+	// CredentialsOverlap is the default duration for which the current credentials of a platform remain valid after
+	// they are rotated
+	CredentialsOverlap time.Duration
 }
 
 var _ web.Controller = &Controller{}
@@ -72,7 +79,7 @@ func (c *Controller) createPlatform(r *web.Request) (*web.Response, error) {
 		return nil, err
 	}
 	plainPassword := credentials.Basic.Password
-	transformedPassword, err := c.Encrypter.Encrypt(ctx, []byte(plainPassword))
+	transformedPassword, err := security.HashPassword([]byte(plainPassword))
 	if err != nil {
 		return nil, err
 	}
@@ -158,6 +165,14 @@ func (c *Controller) patchPlatform(r *web.Request) (*web.Response, error) {
 
 	createdAt := platform.CreatedAt
 
+	changes, err := query.LabelChangesFromJSON(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	if r.Body, err = sjson.DeleteBytes(r.Body, "labels"); err != nil {
+		return nil, err
+	}
+
 	if err := util.BytesToObject(r.Body, platform); err != nil {
 		return nil, err
 	}
@@ -166,7 +181,7 @@ func (c *Controller) patchPlatform(r *web.Request) (*web.Response, error) {
 	platform.CreatedAt = createdAt
 	platform.UpdatedAt = time.Now().UTC()
 
-	if err := c.PlatformStorage.Update(ctx, platform); err != nil {
+	if err := c.PlatformStorage.Update(ctx, platform, changes...); err != nil {
 		return nil, util.HandleStorageError(err, "platform")
 	}
 
@@ -0,0 +1,233 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
+)
+
+const (
+	// platformsParam is a label query selecting the platforms in the report such as region = eu10
+	platformsParam = "platforms"
+	// servicePlansParam is a field query selecting the service plans in the report such as catalog_name = small
+	servicePlansParam = "service_plans"
+	formatParam       = "format"
+
+	jsonFormat = "json"
+	csvFormat  = "csv"
+)
+
+// Controller implements api.Controller by providing reports API logic
+type Controller struct {
+	Repository storage.Repository
+}
+
+var _ web.Controller = &Controller{}
+
+// visibilityMatrixEntry reports that a service plan is visible to a platform
+type visibilityMatrixEntry struct {
+	PlatformID          string `json:"platform_id"`
+	PlatformName        string `json:"platform_name"`
+	BrokerID            string `json:"broker_id"`
+	BrokerName          string `json:"broker_name"`
+	ServiceOfferingID   string `json:"service_offering_id"`
+	ServiceOfferingName string `json:"service_offering_name"`
+	ServicePlanID       string `json:"service_plan_id"`
+	ServicePlanName     string `json:"service_plan_name"`
+	// Public specifies whether the plan is visible to the platform through a public visibility
+	Public bool `json:"public"`
+}
+
+type visibilityMatrix struct {
+	Entries []*visibilityMatrixEntry `json:"entries"`
+}
+
+var visibilityMatrixCSVHeader = []string{
+	"platform_id", "platform_name", "broker_id", "broker_name", "service_offering_id", "service_offering_name",
+	"service_plan_id", "service_plan_name", "public",
+}
+
+func (e *visibilityMatrixEntry) csvRecord() []string {
+	return []string{
+		e.PlatformID, e.PlatformName, e.BrokerID, e.BrokerName, e.ServiceOfferingID, e.ServiceOfferingName,
+		e.ServicePlanID, e.ServicePlanName, strconv.FormatBool(e.Public),
+	}
+}
+
+func (c *Controller) getVisibilityMatrix(r *web.Request) (*web.Response, error) {
+	ctx := r.Context()
+	log.C(ctx).Debug("Building visibility matrix report")
+
+	format := r.URL.Query().Get(formatParam)
+	if format == "" {
+		format = jsonFormat
+	}
+	if format != jsonFormat && format != csvFormat {
+		return nil, &util.HTTPError{
+			ErrorType:   "BadRequest",
+			Description: fmt.Sprintf("unsupported report format %s: supported formats are %s and %s", format, jsonFormat, csvFormat),
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
+	platformCriteria, err := parseSelector(query.LabelQuery, r.URL.Query().Get(platformsParam))
+	if err != nil {
+		return nil, util.HandleSelectionError(err)
+	}
+	servicePlanCriteria, err := parseSelector(query.FieldQuery, r.URL.Query().Get(servicePlansParam))
+	if err != nil {
+		return nil, util.HandleSelectionError(err)
+	}
+
+	matrix, err := c.buildVisibilityMatrix(ctx, platformCriteria, servicePlanCriteria)
+	if err != nil {
+		return nil, err
+	}
+	if format == csvFormat {
+		return newCSVResponse(matrix)
+	}
+	return util.NewJSONResponse(http.StatusOK, matrix)
+}
+
+// buildVisibilityMatrix resolves the visibilities of the selected platforms in the same way as the aggregated broker
+// does. The inactive plans, the plans hidden by catalog overrides and the plans of suspended brokers are not offered
+// to the platforms and are left out.
+func (c *Controller) buildVisibilityMatrix(ctx context.Context, platformCriteria, servicePlanCriteria []query.Criterion) (*visibilityMatrix, error) {
+	platforms, err := c.Repository.Platform().List(ctx, platformCriteria...)
+	if err != nil {
+		return nil, util.HandleSelectionError(err, "platform")
+	}
+	servicePlans, err := c.Repository.ServicePlan().List(ctx, servicePlanCriteria...)
+	if err != nil {
+		return nil, util.HandleSelectionError(err, "service_plan")
+	}
+	serviceOfferings, err := c.Repository.ServiceOffering().List(ctx)
+	if err != nil {
+		return nil, util.HandleStorageError(err, "service_offering")
+	}
+	brokers, err := c.Repository.Broker().List(ctx)
+	if err != nil {
+		return nil, util.HandleStorageError(err, "broker")
+	}
+	visibilities, err := c.Repository.Visibility().List(ctx)
+	if err != nil {
+		return nil, util.HandleStorageError(err, "visibility")
+	}
+	overrides, err := c.Repository.CatalogOverride().List(ctx)
+	if err != nil {
+		return nil, util.HandleStorageError(err, "catalog_override")
+	}
+	overridesIndex := types.NewCatalogOverridesIndex(overrides)
+
+	serviceOfferingsByID := make(map[string]*types.ServiceOffering, len(serviceOfferings))
+	for _, serviceOffering := range serviceOfferings {
+		serviceOfferingsByID[serviceOffering.ID] = serviceOffering
+	}
+	brokersByID := make(map[string]*types.Broker, len(brokers))
+	for _, broker := range brokers {
+		brokersByID[broker.ID] = broker
+	}
+
+	resolver := types.NewVisibilityResolver(visibilities, time.Now())
+	entries := make([]*visibilityMatrixEntry, 0)
+	for _, platform := range platforms {
+		platformVisibilities := resolver.Resolve(platform)
+		for _, servicePlan := range servicePlans {
+			if !servicePlan.Active || !types.IsPlanVisible(platformVisibilities, servicePlan) {
+				continue
+			}
+			serviceOffering, found := serviceOfferingsByID[servicePlan.ServiceOfferingID]
+			if !found {
+				continue
+			}
+			broker, found := brokersByID[serviceOffering.BrokerID]
+			if !found || broker.State == types.BrokerSuspended {
+				continue
+			}
+			if overridesIndex.ForServiceOffering(broker.ID, serviceOffering.CatalogID).IsHidden() ||
+				overridesIndex.ForServicePlan(broker.ID, serviceOffering.CatalogID, servicePlan.CatalogID).IsHidden() {
+				continue
+			}
+			entries = append(entries, &visibilityMatrixEntry{
+				PlatformID:          platform.ID,
+				PlatformName:        platform.Name,
+				BrokerID:            broker.ID,
+				BrokerName:          broker.Name,
+				ServiceOfferingID:   serviceOffering.ID,
+				ServiceOfferingName: serviceOffering.Name,
+				ServicePlanID:       servicePlan.ID,
+				ServicePlanName:     servicePlan.Name,
+				Public:              isPublic(platformVisibilities, servicePlan),
+			})
+		}
+	}
+	return &visibilityMatrix{Entries: entries}, nil
+}
+
+// parseSelector parses the selector of the report. A missing selector selects everything.
+func parseSelector(criteriaType query.CriterionType, selector string) ([]query.Criterion, error) {
+	if selector == "" {
+		return nil, nil
+	}
+	return query.Parse(criteriaType, selector)
+}
+
+func isPublic(visibilities []*types.Visibility, servicePlan *types.ServicePlan) bool {
+	for _, visibility := range visibilities {
+		if visibility.IsPublic() && visibility.Covers(servicePlan) {
+			return true
+		}
+	}
+	return false
+}
+
+func newCSVResponse(matrix *visibilityMatrix) (*web.Response, error) {
+	body := &bytes.Buffer{}
+	writer := csv.NewWriter(body)
+	if err := writer.Write(visibilityMatrixCSVHeader); err != nil {
+		return nil, err
+	}
+	for _, entry := range matrix.Entries {
+		if err := writer.Write(entry.csvRecord()); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+
+	headers := http.Header{}
+	headers.Add("Content-Type", "text/csv")
+	return &web.Response{
+		StatusCode: http.StatusOK,
+		Header:     headers,
+		Body:       body.Bytes(),
+	}, nil
+}
@@ -0,0 +1,76 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package service_offering
+
+import (
+	"context"
+
+	"github.com/Peripli/service-manager/api/catalog_override"
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+)
+
+func (c *Controller) overrides() *catalog_override.Handler {
+	return &catalog_override.Handler{
+		CatalogOverrideStorage: c.CatalogOverrideStorage,
+		Kind:                   "service offering",
+		PathParam:              reqServiceOfferingID,
+		TargetFunc:             c.overrideTarget,
+	}
+}
+
+func (c *Controller) overrideTarget(ctx context.Context, serviceOfferingID string) (*catalog_override.Target, error) {
+	serviceOffering, err := c.ServiceOfferingStorage.Get(ctx, serviceOfferingID)
+	if err = util.HandleStorageError(err, "service_offering"); err != nil {
+		return nil, err
+	}
+	return &catalog_override.Target{
+		BrokerID:         serviceOffering.BrokerID,
+		ServiceCatalogID: serviceOffering.CatalogID,
+	}, nil
+}
+
+// applyOverrides changes the service offerings as specified by their catalog overrides. Hidden service offerings
+// are removed only if omitHidden is set.
+func (c *Controller) applyOverrides(ctx context.Context, serviceOfferings []*types.ServiceOffering, omitHidden bool) ([]*types.ServiceOffering, error) {
+	if len(serviceOfferings) == 0 {
+		return serviceOfferings, nil
+	}
+	brokerIDs := make([]string, 0, len(serviceOfferings))
+	for _, serviceOffering := range serviceOfferings {
+		brokerIDs = append(brokerIDs, serviceOffering.BrokerID)
+	}
+	overrides, err := c.CatalogOverrideStorage.List(ctx, query.ByField(query.InOperator, "broker_id", brokerIDs...))
+	if err != nil {
+		return nil, err
+	}
+	index := types.NewCatalogOverridesIndex(overrides)
+
+	result := make([]*types.ServiceOffering, 0, len(serviceOfferings))
+	for _, serviceOffering := range serviceOfferings {
+		override := index.ForServiceOffering(serviceOffering.BrokerID, serviceOffering.CatalogID)
+		if omitHidden && override.IsHidden() {
+			continue
+		}
+		if err := override.ApplyToServiceOffering(serviceOffering); err != nil {
+			return nil, err
+		}
+		result = append(result, serviceOffering)
+	}
+	return result, nil
+}
@@ -25,6 +25,7 @@ import (
 
 // Routes returns slice of routes which handle service offering operations
 func (c *Controller) Routes() []web.Route {
+	overrides := c.overrides()
 	return []web.Route{
 		{
 			Endpoint: web.Endpoint{
@@ -33,6 +34,27 @@ func (c *Controller) Routes() []web.Route {
 			},
 			Handler: c.getServiceOffering,
 		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodGet,
+				Path:   web.ServiceOfferingsURL + "/{service_offering_id}/override",
+			},
+			Handler: overrides.Get,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodPut,
+				Path:   web.ServiceOfferingsURL + "/{service_offering_id}/override",
+			},
+			Handler: overrides.Set,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodDelete,
+				Path:   web.ServiceOfferingsURL + "/{service_offering_id}/override",
+			},
+			Handler: overrides.Delete,
+		},
 		{
 			Endpoint: web.Endpoint{
 				Method: http.MethodGet,
@@ -34,6 +34,7 @@ const reqServiceOfferingID = "service_offering_id"
 // Controller implements api.Controller by providing service offerings API logic
 type Controller struct {
 	ServiceOfferingStorage storage.ServiceOffering
+	CatalogOverrideStorage storage.CatalogOverride
 }
 
 func (c *Controller) getServiceOffering(r *web.Request) (*web.Response, error) {
@@ -45,6 +46,9 @@ func (c *Controller) getServiceOffering(r *web.Request) (*web.Response, error) {
 	if err = util.HandleStorageError(err, "service_offering"); err != nil {
 		return nil, err
 	}
+	if _, err := c.applyOverrides(ctx, []*types.ServiceOffering{serviceOffering}, false); err != nil {
+		return nil, err
+	}
 	return util.NewJSONResponse(http.StatusOK, serviceOffering)
 }
 
@@ -58,6 +62,9 @@ func (c *Controller) listServiceOfferings(r *web.Request) (*web.Response, error)
 	if err != nil {
 		return nil, util.HandleSelectionError(err)
 	}
+	if serviceOfferings, err = c.applyOverrides(ctx, serviceOfferings, true); err != nil {
+		return nil, err
+	}
 
 	return util.NewJSONResponse(http.StatusOK, struct {
 		ServiceOfferings []*types.ServiceOffering `json:"service_offerings"`
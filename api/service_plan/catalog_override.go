@@ -0,0 +1,96 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package service_plan
+
+import (
+	"context"
+
+	"github.com/Peripli/service-manager/api/catalog_override"
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+)
+
+func (c *Controller) overrides() *catalog_override.Handler {
+	return &catalog_override.Handler{
+		CatalogOverrideStorage: c.CatalogOverrideStorage,
+		Kind:                   "service plan",
+		PathParam:              reqServicePlanID,
+		TargetFunc:             c.overrideTarget,
+	}
+}
+
+func (c *Controller) overrideTarget(ctx context.Context, servicePlanID string) (*catalog_override.Target, error) {
+	servicePlan, err := c.ServicePlanStorage.Get(ctx, servicePlanID)
+	if err = util.HandleStorageError(err, "service_plan"); err != nil {
+		return nil, err
+	}
+	serviceOffering, err := c.ServiceOfferingStorage.Get(ctx, servicePlan.ServiceOfferingID)
+	if err = util.HandleStorageError(err, "service_offering"); err != nil {
+		return nil, err
+	}
+	return &catalog_override.Target{
+		BrokerID:         serviceOffering.BrokerID,
+		ServiceCatalogID: serviceOffering.CatalogID,
+		PlanCatalogID:    servicePlan.CatalogID,
+	}, nil
+}
+
+// applyOverrides changes the service plans as specified by their catalog overrides. Plans which are hidden
+// by their own override or by the override of their service offering are removed only if omitHidden is set.
+func (c *Controller) applyOverrides(ctx context.Context, servicePlans []*types.ServicePlan, omitHidden bool) ([]*types.ServicePlan, error) {
+	if len(servicePlans) == 0 {
+		return servicePlans, nil
+	}
+	serviceOfferingIDs := make([]string, 0, len(servicePlans))
+	for _, servicePlan := range servicePlans {
+		serviceOfferingIDs = append(serviceOfferingIDs, servicePlan.ServiceOfferingID)
+	}
+	serviceOfferings, err := c.ServiceOfferingStorage.List(ctx, query.ByField(query.InOperator, "id", serviceOfferingIDs...))
+	if err != nil {
+		return nil, err
+	}
+	serviceOfferingsByID := make(map[string]*types.ServiceOffering, len(serviceOfferings))
+	brokerIDs := make([]string, 0, len(serviceOfferings))
+	for _, serviceOffering := range serviceOfferings {
+		serviceOfferingsByID[serviceOffering.ID] = serviceOffering
+		brokerIDs = append(brokerIDs, serviceOffering.BrokerID)
+	}
+	overrides, err := c.CatalogOverrideStorage.List(ctx, query.ByField(query.InOperator, "broker_id", brokerIDs...))
+	if err != nil {
+		return nil, err
+	}
+	index := types.NewCatalogOverridesIndex(overrides)
+
+	result := make([]*types.ServicePlan, 0, len(servicePlans))
+	for _, servicePlan := range servicePlans {
+		serviceOffering, found := serviceOfferingsByID[servicePlan.ServiceOfferingID]
+		if !found {
+			result = append(result, servicePlan)
+			continue
+		}
+		override := index.ForServicePlan(serviceOffering.BrokerID, serviceOffering.CatalogID, servicePlan.CatalogID)
+		if omitHidden && (override.IsHidden() || index.ForServiceOffering(serviceOffering.BrokerID, serviceOffering.CatalogID).IsHidden()) {
+			continue
+		}
+		if err := override.ApplyToServicePlan(servicePlan); err != nil {
+			return nil, err
+		}
+		result = append(result, servicePlan)
+	}
+	return result, nil
+}
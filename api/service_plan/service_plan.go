@@ -25,6 +25,7 @@ import (
 
 // Routes returns slice of routes which handle service plan operations
 func (c *Controller) Routes() []web.Route {
+	overrides := c.overrides()
 	return []web.Route{
 		{
 			Endpoint: web.Endpoint{
@@ -33,6 +34,27 @@ func (c *Controller) Routes() []web.Route {
 			},
 			Handler: c.getServicePlan,
 		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodGet,
+				Path:   web.ServicePlansURL + "/{service_plan_id}/override",
+			},
+			Handler: overrides.Get,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodPut,
+				Path:   web.ServicePlansURL + "/{service_plan_id}/override",
+			},
+			Handler: overrides.Set,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodDelete,
+				Path:   web.ServicePlansURL + "/{service_plan_id}/override",
+			},
+			Handler: overrides.Delete,
+		},
 		{
 			Endpoint: web.Endpoint{
 				Method: http.MethodGet,
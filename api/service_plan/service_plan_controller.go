@@ -31,7 +31,9 @@ const reqServicePlanID = "service_plan_id"
 
 // Controller implements api.Controller by providing service plans API logic
 type Controller struct {
-	ServicePlanStorage storage.ServicePlan
+	ServicePlanStorage     storage.ServicePlan
+	ServiceOfferingStorage storage.ServiceOffering
+	CatalogOverrideStorage storage.CatalogOverride
 }
 
 func (c *Controller) getServicePlan(r *web.Request) (*web.Response, error) {
@@ -43,6 +45,9 @@ func (c *Controller) getServicePlan(r *web.Request) (*web.Response, error) {
 	if err = util.HandleStorageError(err, "service_plan"); err != nil {
 		return nil, err
 	}
+	if _, err := c.applyOverrides(ctx, []*types.ServicePlan{servicePlan}, false); err != nil {
+		return nil, err
+	}
 	return util.NewJSONResponse(http.StatusOK, servicePlan)
 }
 
@@ -56,6 +61,9 @@ func (c *Controller) ListServicePlans(r *web.Request) (*web.Response, error) {
 	if err != nil {
 		return nil, util.HandleSelectionError(err)
 	}
+	if servicePlans, err = c.applyOverrides(ctx, servicePlans, true); err != nil {
+		return nil, err
+	}
 
 	return util.NewJSONResponse(http.StatusOK, &types.ServicePlans{
 		ServicePlans: servicePlans,
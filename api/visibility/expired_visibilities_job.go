@@ -0,0 +1,87 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package visibility
+
+import (
+	"context"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/storage"
+)
+
+const expiredVisibilitiesCleanupLockKey = 114
+
+// ExpiredVisibilitiesCleanupJob periodically deletes the visibilities whose expires_at has passed. Only one Service
+// Manager instance sharing the same storage runs a cleanup pass at a time.
+type ExpiredVisibilitiesCleanupJob struct {
+	repository storage.Repository
+	interval   time.Duration
+}
+
+// NewExpiredVisibilitiesCleanupJob returns a job that deletes the expired visibilities every interval
+func NewExpiredVisibilitiesCleanupJob(repository storage.Repository, interval time.Duration) *ExpiredVisibilitiesCleanupJob {
+	return &ExpiredVisibilitiesCleanupJob{
+		repository: repository,
+		interval:   interval,
+	}
+}
+
+// Run deletes the expired visibilities until the context is done
+func (j *ExpiredVisibilitiesCleanupJob) Run(ctx context.Context) {
+	log.C(ctx).Infof("Starting periodic expired visibilities cleanup with interval %s", j.interval)
+	for {
+		j.cleanup(ctx)
+		select {
+		case <-ctx.Done():
+			log.C(ctx).Info("Stopping periodic expired visibilities cleanup")
+			return
+		case <-time.After(j.interval):
+		}
+	}
+}
+
+func (j *ExpiredVisibilitiesCleanupJob) cleanup(ctx context.Context) {
+	if err := j.repository.InTransaction(ctx, func(ctx context.Context, txStorage storage.Warehouse) error {
+		locked, err := txStorage.AdvisoryLock().TryLock(ctx, expiredVisibilitiesCleanupLockKey)
+		if err != nil {
+			return err
+		}
+		if !locked {
+			log.C(ctx).Debug("Expired visibilities cleanup is already running in another Service Manager instance")
+			return nil
+		}
+
+		expired, err := txStorage.Visibility().DeleteExpired(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+		for _, visibility := range expired {
+			log.C(ctx).WithFields(map[string]interface{}{
+				"visibility_id":       visibility.ID,
+				"platform_id":         visibility.PlatformID,
+				"platform_selector":   visibility.PlatformSelector,
+				"service_plan_id":     visibility.ServicePlanID,
+				"service_offering_id": visibility.ServiceOfferingID,
+				"expires_at":          visibility.ExpiresAt,
+			}).Info("Deleted expired visibility")
+		}
+		return nil
+	}); err != nil {
+		log.C(ctx).WithError(err).Error("Could not delete expired visibilities")
+	}
+}
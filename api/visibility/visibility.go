@@ -33,6 +33,13 @@ func (c *Controller) Routes() []web.Route {
 			},
 			Handler: c.createVisibility,
 		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodPost,
+				Path:   web.VisibilitiesURL + "/bulk",
+			},
+			Handler: c.bulkVisibilities,
+		},
 		{
 			Endpoint: web.Endpoint{
 				Method: http.MethodGet,
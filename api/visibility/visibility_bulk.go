@@ -0,0 +1,318 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package visibility
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
+)
+
+const (
+	bulkCreateOperation = "create"
+	bulkDeleteOperation = "delete"
+
+	// bulkSelectorsItem identifies the visibilities created for the selectors of the request
+	bulkSelectorsItem = "selectors"
+)
+
+// errBulkItemFailed aborts the transaction of the bulk request once one of its operations has failed
+var errBulkItemFailed = errors.New("bulk visibility operation failed")
+
+type bulkVisibilitiesRequest struct {
+	Create    []*types.Visibility      `json:"create"`
+	Delete    []string                 `json:"delete"`
+	Selectors *bulkVisibilitySelectors `json:"selectors"`
+}
+
+// bulkVisibilitySelectors creates a visibility for each of the selected service plans on each of the selected platforms
+type bulkVisibilitySelectors struct {
+	// ServicePlans is a field query selecting the service plans such as catalog_name in [small||medium]
+	ServicePlans string `json:"service_plans"`
+	// Platforms is a label query selecting the platforms such as region = eu10
+	Platforms string       `json:"platforms"`
+	Labels    types.Labels `json:"labels"`
+}
+
+// bulkVisibilityResult is the outcome of a single operation of the bulk request. Item identifies the operation in the
+// request such as create[0], delete[1] or selectors[2] for the visibilities created for the selectors.
+type bulkVisibilityResult struct {
+	Item       string            `json:"item"`
+	Operation  string            `json:"operation"`
+	ID         string            `json:"id,omitempty"`
+	Visibility *types.Visibility `json:"visibility,omitempty"`
+
+	ErrorType   string `json:"error,omitempty"`
+	Description string `json:"description,omitempty"`
+	StatusCode  int    `json:"-"`
+}
+
+type bulkVisibilitiesResponse struct {
+	ErrorType   string                  `json:"error,omitempty"`
+	Description string                  `json:"description,omitempty"`
+	Results     []*bulkVisibilityResult `json:"results"`
+}
+
+// bulkVisibilityCreate is a visibility to be created together with the item of the request it originates from
+type bulkVisibilityCreate struct {
+	item       string
+	visibility *types.Visibility
+}
+
+// Validate implements InputValidator and verifies the request contains operations
+func (bvr *bulkVisibilitiesRequest) Validate() error {
+	if len(bvr.Create) == 0 && len(bvr.Delete) == 0 && bvr.Selectors == nil {
+		return errors.New("missing visibility operations")
+	}
+	return nil
+}
+
+// validateOperations verifies all operations of the request and returns a failed result for each invalid one
+func (bvr *bulkVisibilitiesRequest) validateOperations() []*bulkVisibilityResult {
+	failed := make([]*bulkVisibilityResult, 0)
+	invalid := func(operation, item string, err error) {
+		failed = append(failed, failedBulkItem(operation, item, &util.HTTPError{
+			ErrorType:   "BadRequest",
+			Description: err.Error(),
+			StatusCode:  http.StatusBadRequest,
+		}))
+	}
+	for i, visibility := range bvr.Create {
+		if visibility == nil {
+			invalid(bulkCreateOperation, bulkItem(bulkCreateOperation, i), errors.New("missing visibility"))
+		} else if err := visibility.Validate(); err != nil {
+			invalid(bulkCreateOperation, bulkItem(bulkCreateOperation, i), err)
+		}
+	}
+	for i, visibilityID := range bvr.Delete {
+		if visibilityID == "" {
+			invalid(bulkDeleteOperation, bulkItem(bulkDeleteOperation, i), errors.New("missing visibility id"))
+		}
+	}
+	if bvr.Selectors != nil {
+		if err := bvr.Selectors.Validate(); err != nil {
+			invalid(bulkCreateOperation, bulkSelectorsItem, err)
+		}
+	}
+	return failed
+}
+
+// Validate implements InputValidator and verifies both selectors are valid queries
+func (bvs *bulkVisibilitySelectors) Validate() error {
+	if bvs.ServicePlans == "" || bvs.Platforms == "" {
+		return errors.New("both service_plans and platforms are required")
+	}
+	if _, err := query.Parse(query.FieldQuery, bvs.ServicePlans); err != nil {
+		return fmt.Errorf("invalid service_plans query: %s", err)
+	}
+	if _, err := query.Parse(query.LabelQuery, bvs.Platforms); err != nil {
+		return fmt.Errorf("invalid platforms query: %s", err)
+	}
+	return bvs.Labels.Validate()
+}
+
+func (c *Controller) bulkVisibilities(r *web.Request) (*web.Response, error) {
+	ctx := r.Context()
+	log.C(ctx).Debug("Executing bulk visibility operations")
+
+	bulkRequest := &bulkVisibilitiesRequest{}
+	if err := util.BytesToObject(r.Body, bulkRequest); err != nil {
+		return nil, err
+	}
+	if failed := bulkRequest.validateOperations(); len(failed) != 0 {
+		return bulkFailureResponse(failed)
+	}
+
+	results := make([]*bulkVisibilityResult, 0, len(bulkRequest.Create)+len(bulkRequest.Delete))
+	failed := make([]*bulkVisibilityResult, 0)
+	// fail records the failed operation if its error can be reported to the client and aborts the transaction
+	fail := func(operation, item string, err error) error {
+		httpErr, ok := err.(*util.HTTPError)
+		if !ok {
+			return err
+		}
+		failed = append(failed, failedBulkItem(operation, item, httpErr))
+		return errBulkItemFailed
+	}
+	err := c.Repository.InTransaction(ctx, func(ctx context.Context, txStorage storage.Warehouse) error {
+		// all missing visibilities are reported before any operation is executed
+		missing, err := missingBulkDeletes(ctx, txStorage, bulkRequest.Delete)
+		if err != nil {
+			return err
+		}
+		if len(missing) != 0 {
+			failed = missing
+			return errBulkItemFailed
+		}
+
+		creates := make([]*bulkVisibilityCreate, 0, len(bulkRequest.Create))
+		for i, visibility := range bulkRequest.Create {
+			creates = append(creates, &bulkVisibilityCreate{
+				item:       bulkItem(bulkCreateOperation, i),
+				visibility: visibility,
+			})
+		}
+		if bulkRequest.Selectors != nil {
+			selected, err := selectVisibilities(ctx, txStorage, bulkRequest.Selectors)
+			if err != nil {
+				return fail(bulkCreateOperation, bulkSelectorsItem, err)
+			}
+			for i, visibility := range selected {
+				creates = append(creates, &bulkVisibilityCreate{
+					item:       bulkItem(bulkSelectorsItem, i),
+					visibility: visibility,
+				})
+			}
+		}
+
+		for _, create := range creates {
+			if err := newVisibility(create.visibility); err != nil {
+				return err
+			}
+			if _, err := txStorage.Visibility().Create(ctx, create.visibility); err != nil {
+				return fail(bulkCreateOperation, create.item, util.HandleStorageError(err, "visibility"))
+			}
+			results = append(results, &bulkVisibilityResult{
+				Item:       create.item,
+				Operation:  bulkCreateOperation,
+				ID:         create.visibility.ID,
+				Visibility: create.visibility,
+			})
+		}
+		for i, visibilityID := range bulkRequest.Delete {
+			item := bulkItem(bulkDeleteOperation, i)
+			byID := query.ByField(query.EqualsOperator, "id", visibilityID)
+			if err := txStorage.Visibility().Delete(ctx, byID); err != nil {
+				return fail(bulkDeleteOperation, item, util.HandleStorageError(err, "visibility"))
+			}
+			results = append(results, &bulkVisibilityResult{
+				Item:      item,
+				Operation: bulkDeleteOperation,
+				ID:        visibilityID,
+			})
+		}
+		return nil
+	})
+	if err == errBulkItemFailed {
+		return bulkFailureResponse(failed)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.C(ctx).Debugf("Successfully executed %d bulk visibility operations", len(results))
+	return util.NewJSONResponse(http.StatusOK, &bulkVisibilitiesResponse{
+		Results: results,
+	})
+}
+
+// missingBulkDeletes returns a failed result for each visibility to be deleted that does not exist
+func missingBulkDeletes(ctx context.Context, txStorage storage.Warehouse, visibilityIDs []string) ([]*bulkVisibilityResult, error) {
+	failed := make([]*bulkVisibilityResult, 0)
+	if len(visibilityIDs) == 0 {
+		return failed, nil
+	}
+	visibilities, err := txStorage.Visibility().List(ctx, query.ByField(query.InOperator, "id", visibilityIDs...))
+	if err != nil {
+		return nil, util.HandleSelectionError(err, "visibility")
+	}
+	existing := make(map[string]bool, len(visibilities))
+	for _, visibility := range visibilities {
+		existing[visibility.ID] = true
+	}
+	for i, visibilityID := range visibilityIDs {
+		if !existing[visibilityID] {
+			failed = append(failed, failedBulkItem(bulkDeleteOperation, bulkItem(bulkDeleteOperation, i), &util.HTTPError{
+				ErrorType:   "NotFound",
+				Description: "could not find such visibility",
+				StatusCode:  http.StatusNotFound,
+			}))
+		}
+	}
+	return failed, nil
+}
+
+// selectVisibilities returns a visibility for each pair of selected service plan and platform
+func selectVisibilities(ctx context.Context, txStorage storage.Warehouse, selectors *bulkVisibilitySelectors) ([]*types.Visibility, error) {
+	planCriteria, err := query.Parse(query.FieldQuery, selectors.ServicePlans)
+	if err != nil {
+		return nil, util.HandleSelectionError(err)
+	}
+	servicePlans, err := txStorage.ServicePlan().List(ctx, planCriteria...)
+	if err != nil {
+		return nil, util.HandleSelectionError(err, "service_plan")
+	}
+	platformCriteria, err := query.Parse(query.LabelQuery, selectors.Platforms)
+	if err != nil {
+		return nil, util.HandleSelectionError(err)
+	}
+	platforms, err := txStorage.Platform().List(ctx, platformCriteria...)
+	if err != nil {
+		return nil, util.HandleSelectionError(err, "platform")
+	}
+
+	visibilities := make([]*types.Visibility, 0, len(servicePlans)*len(platforms))
+	for _, servicePlan := range servicePlans {
+		for _, platform := range platforms {
+			visibilities = append(visibilities, &types.Visibility{
+				PlatformID:    platform.ID,
+				ServicePlanID: servicePlan.ID,
+				Labels:        selectors.Labels,
+			})
+		}
+	}
+	return visibilities, nil
+}
+
+func bulkItem(name string, index int) string {
+	return fmt.Sprintf("%s[%d]", name, index)
+}
+
+// failedBulkItem reports why an operation of the bulk request failed
+func failedBulkItem(operation, item string, err *util.HTTPError) *bulkVisibilityResult {
+	return &bulkVisibilityResult{
+		Item:        item,
+		Operation:   operation,
+		ErrorType:   err.ErrorType,
+		Description: err.Description,
+		StatusCode:  err.StatusCode,
+	}
+}
+
+// bulkFailureResponse reports the failed operations of the bulk request. As all operations run in the same
+// transaction, none of them is applied. The error is the one shared by all failures or a bad request otherwise.
+func bulkFailureResponse(failed []*bulkVisibilityResult) (*web.Response, error) {
+	errorType, statusCode := failed[0].ErrorType, failed[0].StatusCode
+	for _, result := range failed {
+		if result.StatusCode != statusCode {
+			errorType, statusCode = "BadRequest", http.StatusBadRequest
+		}
+	}
+	return util.NewJSONResponse(statusCode, &bulkVisibilitiesResponse{
+		ErrorType:   errorType,
+		Description: fmt.Sprintf("%d of the bulk visibility operations failed and none were applied", len(failed)),
+		Results:     failed,
+	})
+}
@@ -40,19 +40,13 @@ func (c *Controller) createVisibility(r *web.Request) (*web.Response, error) {
 		return nil, err
 	}
 
-	UUID, err := uuid.NewV4()
-	if err != nil {
-		return nil, fmt.Errorf("could not generate GUID for visibility: %s", err)
+	if err := newVisibility(visibility); err != nil {
+		return nil, err
 	}
 
-	visibility.ID = UUID.String()
-
-	currentTime := time.Now().UTC()
-	visibility.CreatedAt = currentTime
-	visibility.UpdatedAt = currentTime
-
 	var visibilityID string
-	err = c.Repository.InTransaction(ctx, func(ctx context.Context, storage storage.Warehouse) error {
+	err := c.Repository.InTransaction(ctx, func(ctx context.Context, storage storage.Warehouse) error {
+		var err error
 		logger.Debugf("Creating visibility and labels...")
 		visibilityID, err = storage.Visibility().Create(ctx, visibility)
 		return err
@@ -65,6 +59,24 @@ func (c *Controller) createVisibility(r *web.Request) (*web.Response, error) {
 	return util.NewJSONResponse(http.StatusCreated, visibility)
 }
 
+// newVisibility assigns an id and creation timestamps to a visibility that is about to be created. Visibilities without
+// an effect allow access to the plans they cover.
+func newVisibility(visibility *types.Visibility) error {
+	UUID, err := uuid.NewV4()
+	if err != nil {
+		return fmt.Errorf("could not generate GUID for visibility: %s", err)
+	}
+	visibility.ID = UUID.String()
+
+	currentTime := time.Now().UTC()
+	visibility.CreatedAt = currentTime
+	visibility.UpdatedAt = currentTime
+	if visibility.Effect == "" {
+		visibility.Effect = types.VisibilityAllow
+	}
+	return nil
+}
+
 func (c *Controller) getVisibility(r *web.Request) (*web.Response, error) {
 	visibilityID := r.PathParams[reqVisibilityID]
 	ctx := r.Context()
@@ -105,11 +117,90 @@ func (c *Controller) listVisibilities(r *web.Request) (*web.Response, error) {
 	if err != nil {
 		return nil, util.HandleSelectionError(err)
 	}
+	if p.ID != "" {
+		if visibilities, err = c.platformVisibilities(ctx, p.ID, visibilities); err != nil {
+			return nil, err
+		}
+	}
 	return util.NewJSONResponse(http.StatusOK, types.Visibilities{
 		Visibilities: visibilities,
 	})
 }
 
+// platformVisibilities evaluates the platform selectors of the visibilities against the labels of the platform and
+// leaves out the visibilities outside of their validity period. The visibilities selecting the platform are reported
+// as visibilities for the platform and the visibilities of service offerings are reported as visibilities for each of
+// their active plans. Deny visibilities are not reported and hide the plans they cover from the other visibilities.
+func (c *Controller) platformVisibilities(ctx context.Context, platformID string, visibilities []*types.Visibility) ([]*types.Visibility, error) {
+	platform, err := c.Repository.Platform().Get(ctx, platformID)
+	if err != nil {
+		return nil, util.HandleStorageError(err, "platform")
+	}
+	applicable := make([]*types.Visibility, 0, len(visibilities))
+	denials := make([]*types.Visibility, 0)
+	serviceOfferingIDs := make([]string, 0)
+	for _, visibility := range types.NewVisibilityResolver(visibilities, time.Now()).Resolve(platform) {
+		if visibility.PlatformSelector != "" {
+			visibility.PlatformID = platform.ID
+		}
+		if visibility.ServiceOfferingID != "" {
+			serviceOfferingIDs = append(serviceOfferingIDs, visibility.ServiceOfferingID)
+		}
+		if visibility.IsDeny() {
+			denials = append(denials, visibility)
+		} else {
+			applicable = append(applicable, visibility)
+		}
+	}
+	if len(serviceOfferingIDs) == 0 && len(denials) == 0 {
+		return applicable, nil
+	}
+
+	servicePlans := make([]*types.ServicePlan, 0)
+	if len(serviceOfferingIDs) != 0 {
+		byServiceOfferingIDs := query.ByField(query.InOperator, "service_offering_id", serviceOfferingIDs...)
+		if servicePlans, err = c.Repository.ServicePlan().List(ctx, byServiceOfferingIDs); err != nil {
+			return nil, util.HandleStorageError(err, "service_plan")
+		}
+	}
+	servicePlansByID := make(map[string]*types.ServicePlan, len(servicePlans))
+	for _, servicePlan := range servicePlans {
+		servicePlansByID[servicePlan.ID] = servicePlan
+	}
+
+	result := make([]*types.Visibility, 0, len(applicable))
+	for _, visibility := range applicable {
+		if visibility.ServiceOfferingID == "" {
+			servicePlan, found := servicePlansByID[visibility.ServicePlanID]
+			if !found {
+				// the plan is not part of a service offering with visibilities so only plan denials can cover it
+				servicePlan = &types.ServicePlan{ID: visibility.ServicePlanID}
+			}
+			if !isDenied(denials, servicePlan) {
+				result = append(result, visibility)
+			}
+			continue
+		}
+		for _, servicePlan := range servicePlans {
+			if servicePlan.Active && visibility.Covers(servicePlan) && !isDenied(denials, servicePlan) {
+				planVisibility := *visibility
+				planVisibility.ServicePlanID = servicePlan.ID
+				result = append(result, &planVisibility)
+			}
+		}
+	}
+	return result, nil
+}
+
+func isDenied(denials []*types.Visibility, servicePlan *types.ServicePlan) bool {
+	for _, denial := range denials {
+		if denial.Covers(servicePlan) {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Controller) deleteAllVisibilities(r *web.Request) (*web.Response, error) {
 	ctx := r.Context()
 	log.C(ctx).Debugf("Deleting visibilities...")
@@ -156,6 +247,14 @@ func (c *Controller) patchVisibility(r *web.Request) (*web.Response, error) {
 	if err := util.BytesToObject(r.Body, visibility); err != nil {
 		return nil, err
 	}
+	// unlike on creation an empty effect is not defaulted, as the effect of an existing visibility cannot be reset
+	if visibility.Effect != types.VisibilityAllow && visibility.Effect != types.VisibilityDeny {
+		return nil, &util.HTTPError{
+			ErrorType:   "BadRequest",
+			Description: fmt.Sprintf("visibility effect must be one of %s or %s", types.VisibilityAllow, types.VisibilityDeny),
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
 
 	visibility.ID = visibilityID
 	visibility.CreatedAt = createdAt
@@ -19,6 +19,7 @@ package config_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/Peripli/service-manager/api"
 	cfg "github.com/Peripli/service-manager/config"
@@ -119,6 +120,118 @@ var _ = Describe("config", func() {
 				assertErrorDuringValidate()
 			})
 		})
+
+		Context("when API catalog resync interval is negative", func() {
+			It("returns an error", func() {
+				config.API.CatalogResyncInterval = -time.Minute
+				assertErrorDuringValidate()
+			})
+		})
+
+		Context("when API catalog history limit is negative", func() {
+			It("returns an error", func() {
+				config.API.CatalogHistoryLimit = -1
+				assertErrorDuringValidate()
+			})
+		})
+
+		Context("when API protected plans grace period is negative", func() {
+			It("returns an error", func() {
+				config.API.ProtectedPlansGracePeriod = -time.Minute
+				assertErrorDuringValidate()
+			})
+		})
+
+		Context("when API expired visibilities cleanup interval is negative", func() {
+			It("returns an error", func() {
+				config.API.ExpiredVisibilitiesCleanupInterval = -time.Minute
+				assertErrorDuringValidate()
+			})
+		})
+
+		Context("when API platform credentials overlap is negative", func() {
+			It("returns an error", func() {
+				config.API.PlatformCredentialsOverlap = -time.Minute
+				assertErrorDuringValidate()
+			})
+		})
+
+		Context("when API basic auth max failed attempts is negative", func() {
+			It("returns an error", func() {
+				config.API.BasicAuthMaxFailedAttempts = -1
+				assertErrorDuringValidate()
+			})
+		})
+
+		Context("when API basic auth max failed attempts per client is negative", func() {
+			It("returns an error", func() {
+				config.API.BasicAuthMaxFailedAttemptsPerClient = -1
+				assertErrorDuringValidate()
+			})
+		})
+
+		Context("when API basic auth lockout duration is not positive", func() {
+			It("returns an error", func() {
+				config.API.BasicAuthLockoutDuration = 0
+				assertErrorDuringValidate()
+			})
+		})
+
+		Context("when API basic auth max lockout duration is less than the lockout duration", func() {
+			It("returns an error", func() {
+				config.API.BasicAuthMaxLockoutDuration = config.API.BasicAuthLockoutDuration - time.Second
+				assertErrorDuringValidate()
+			})
+		})
+
+		Context("when API basic auth trusted proxies are invalid", func() {
+			It("returns an error", func() {
+				config.API.BasicAuthTrustedProxies = "10.0.0.1,proxy"
+				assertErrorDuringValidate()
+			})
+		})
+
+		Context("when API authorization claim is missing", func() {
+			It("returns an error", func() {
+				config.API.AuthorizationClaim = ""
+				assertErrorDuringValidate()
+			})
+		})
+
+		Context("when API authorization roles are invalid", func() {
+			It("returns an error", func() {
+				config.API.AuthorizationRoles = "admin=brokers:delete"
+				assertErrorDuringValidate()
+			})
+		})
+
+		Context("when API platform permissions are invalid", func() {
+			It("returns an error", func() {
+				config.API.PlatformPermissions = "brokers"
+				assertErrorDuringValidate()
+			})
+		})
+
+		Context("when API operations pool size is not positive", func() {
+			It("returns an error", func() {
+				config.API.OperationsPoolSize = 0
+				assertErrorDuringValidate()
+			})
+		})
+
+		Context("when API operations queue size is negative", func() {
+			It("returns an error", func() {
+				config.API.OperationsQueueSize = -1
+				assertErrorDuringValidate()
+			})
+		})
+
+		Context("when API broker health down threshold is not a valid percentage", func() {
+			It("returns an error", func() {
+				config.API.BrokerHealthDownThreshold = 101
+				assertErrorDuringValidate()
+			})
+		})
 	})
 
 	Describe("New", func() {
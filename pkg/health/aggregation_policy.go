@@ -39,3 +39,30 @@ func (*DefaultAggregationPolicy) Apply(healths map[string]*Health) *Health {
 	}
 	return New().WithStatus(overallStatus).WithDetails(details)
 }
+
+// ThresholdAggregationPolicy aggregates the healths by constructing a new Health based on the given
+// where the overall health status is negative only if the percentage of negative healths reaches the threshold
+type ThresholdAggregationPolicy struct {
+	// DownThreshold is the percentage of negative healths at which the overall health status becomes negative
+	DownThreshold float64
+}
+
+// Apply aggregates the given healths
+func (p *ThresholdAggregationPolicy) Apply(healths map[string]*Health) *Health {
+	if len(healths) == 0 {
+		return New().WithDetail("error", "no health indicators registered").Unknown()
+	}
+	downCount := 0
+	details := make(map[string]interface{})
+	for k, v := range healths {
+		if v.Status == StatusDown {
+			downCount++
+		}
+		details[k] = v
+	}
+	overallStatus := StatusUp
+	if float64(downCount)*100 >= p.DownThreshold*float64(len(healths)) {
+		overallStatus = StatusDown
+	}
+	return New().WithStatus(overallStatus).WithDetails(details)
+}
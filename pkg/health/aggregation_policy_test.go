@@ -65,3 +65,52 @@ var _ = Describe("Healthcheck AggregationPolicy", func() {
 		})
 	})
 })
+
+var _ = Describe("Healthcheck ThresholdAggregationPolicy", func() {
+
+	aggregationPolicy := &ThresholdAggregationPolicy{DownThreshold: 50}
+	var healths map[string]*Health
+
+	BeforeEach(func() {
+		healths = map[string]*Health{
+			"test1": New().Up(),
+			"test2": New().Up(),
+			"test3": New().Up(),
+			"test4": New().Up(),
+		}
+	})
+
+	When("No healths are provided", func() {
+		It("Returns UNKNOWN and an error detail", func() {
+			aggregatedHealth := aggregationPolicy.Apply(nil)
+			Expect(aggregatedHealth.Status).To(Equal(StatusUnknown))
+			Expect(aggregatedHealth.Details["error"]).ToNot(BeNil())
+		})
+	})
+
+	When("The percentage of DOWN healths is below the threshold", func() {
+		It("Returns UP", func() {
+			healths["test1"] = New().Down()
+			aggregatedHealth := aggregationPolicy.Apply(healths)
+			Expect(aggregatedHealth.Status).To(Equal(StatusUp))
+		})
+	})
+
+	When("The percentage of DOWN healths reaches the threshold", func() {
+		It("Returns DOWN", func() {
+			healths["test1"] = New().Down()
+			healths["test2"] = New().Down()
+			aggregatedHealth := aggregationPolicy.Apply(healths)
+			Expect(aggregatedHealth.Status).To(Equal(StatusDown))
+		})
+	})
+
+	When("Aggregating healths", func() {
+		It("Includes them as overall details", func() {
+			aggregatedHealth := aggregationPolicy.Apply(healths)
+			for name, h := range healths {
+				Expect(aggregatedHealth.Details[name]).To(Equal(h))
+			}
+		})
+	})
+})
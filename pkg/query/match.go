@@ -0,0 +1,71 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package query
+
+import (
+	"strconv"
+
+	"github.com/Peripli/service-manager/pkg/util/slice"
+)
+
+// MatchLabels returns whether the labels satisfy all label criteria. A criterion is satisfied when any of the values
+// of its label satisfies the operator so a label that is not present satisfies no criterion. Field criteria are ignored.
+func MatchLabels(criteria []Criterion, labels map[string][]string) bool {
+	for _, criterion := range criteria {
+		if criterion.Type != LabelQuery {
+			continue
+		}
+		matched := false
+		for _, value := range labels[criterion.LeftOp] {
+			if criterion.matchValue(value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Criterion) matchValue(value string) bool {
+	switch c.Operator {
+	case EqualsOperator, EqualsOrNilOperator:
+		return value == c.RightOp[0]
+	case NotEqualsOperator:
+		return value != c.RightOp[0]
+	case InOperator:
+		return slice.StringsAnyEquals(c.RightOp, value)
+	case NotInOperator:
+		return !slice.StringsAnyEquals(c.RightOp, value)
+	case GreaterThanOperator, LessThanOperator:
+		left, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return false
+		}
+		right, err := strconv.ParseFloat(c.RightOp[0], 64)
+		if err != nil {
+			return false
+		}
+		if c.Operator == GreaterThanOperator {
+			return left > right
+		}
+		return left < right
+	}
+	return false
+}
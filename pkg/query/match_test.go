@@ -0,0 +1,57 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package query
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Match labels", func() {
+	labels := map[string][]string{
+		"region": {"eu10", "eu20"},
+		"tier":   {"3"},
+	}
+
+	DescribeTable("MatchLabels",
+		func(selector string, expected bool) {
+			criteria, err := Parse(LabelQuery, selector)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(MatchLabels(criteria, labels)).To(Equal(expected))
+		},
+		Entry("equals any value", "region = eu20", true),
+		Entry("equals no value", "region = us10", false),
+		Entry("not equals", "tier != 3", false),
+		Entry("in", "region in [us10||eu10]", true),
+		Entry("not in", "tier notin [1||2]", true),
+		Entry("greater than", "tier gt 2", true),
+		Entry("less than", "tier lt 2", false),
+		Entry("missing label", "zone notin [a]", false),
+		Entry("all criteria", "region = eu10|tier = 4", false),
+	)
+
+	It("fails to parse an empty query", func() {
+		_, err := Parse(LabelQuery, "")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("fails to parse an invalid query", func() {
+		_, err := Parse(LabelQuery, "region in eu10")
+		Expect(err).To(HaveOccurred())
+	})
+})
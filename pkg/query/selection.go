@@ -215,6 +215,18 @@ func BuildCriteriaFromRequest(request *web.Request) ([]Criterion, error) {
 	return criteria, nil
 }
 
+// Parse parses a query of the specified type, such as the value of a labelQuery parameter, into criteria
+func Parse(criteriaType CriterionType, input string) ([]Criterion, error) {
+	criteria, err := process(input, criteriaType)
+	if err != nil {
+		return nil, err
+	}
+	if len(criteria) == 0 {
+		return nil, fmt.Errorf("empty %s", criteriaType)
+	}
+	return mergeCriteria(nil, criteria)
+}
+
 type ByLeftOp []Criterion
 
 func (c ByLeftOp) Len() int {
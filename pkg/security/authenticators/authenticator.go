@@ -134,8 +134,9 @@ func (a *OauthAuthenticator) Authenticate(request *http.Request) (*web.UserConte
 		return nil, security.Deny, err
 	}
 	return &web.UserContext{
-		Name: claims.Username,
-		Data: &oidcData{TokenData: idToken},
+		Name:               claims.Username,
+		Data:               &oidcData{TokenData: idToken},
+		AuthenticationType: web.BearerAuthentication,
 	}, security.Allow, nil
 }
 
@@ -383,6 +383,7 @@ var _ = Describe("OIDC Authenticator", func() {
 
 							Expect(user).To(Not(BeNil()))
 							Expect(user.Name).To(Equal(expectedUserName))
+							Expect(user.AuthenticationType).To(Equal(web.BearerAuthentication))
 							Expect(decision).To(Equal(security.Allow))
 							Expect(err).To(BeNil())
 
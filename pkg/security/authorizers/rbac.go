@@ -0,0 +1,213 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+// Package authorizers contains the authorizers of the Service Manager
+package authorizers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Peripli/service-manager/pkg/security"
+	"github.com/Peripli/service-manager/pkg/web"
+)
+
+const (
+	// ReadAction is the action of the requests which do not modify resources
+	ReadAction = "read"
+	// WriteAction is the action of the requests which modify resources
+	WriteAction = "write"
+
+	wildcard = "*"
+)
+
+// Permission allows an action on a resource and has the form resource:action such as brokers:write.
+// The wildcard * can be used for the resource, the action or the whole permission.
+type Permission string
+
+// NewPermission returns the permission for the action on the resource
+func NewPermission(resource, action string) Permission {
+	return Permission(resource + ":" + action)
+}
+
+// ParsePermission parses and validates a permission
+func ParsePermission(permission string) (Permission, error) {
+	permission = strings.TrimSpace(permission)
+	if permission == wildcard {
+		return Permission(permission), nil
+	}
+	parts := strings.Split(permission, ":")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("permission %q must have the form resource:action", permission)
+	}
+	switch parts[1] {
+	case ReadAction, WriteAction, wildcard:
+	default:
+		return "", fmt.Errorf("permission %q must have one of the actions %s, %s or %s", permission, ReadAction, WriteAction, wildcard)
+	}
+	return Permission(permission), nil
+}
+
+// ParsePermissions parses comma separated permissions such as brokers:read,visibilities:*
+func ParsePermissions(permissions string) ([]Permission, error) {
+	result := make([]Permission, 0)
+	if strings.TrimSpace(permissions) == "" {
+		return result, nil
+	}
+	for _, permission := range strings.Split(permissions, ",") {
+		parsed, err := ParsePermission(permission)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, parsed)
+	}
+	return result, nil
+}
+
+// ParseRoles parses semicolon separated roles with their comma separated permissions such as
+// admin=*;viewer=*:read,operations:write
+func ParseRoles(roles string) (map[string][]Permission, error) {
+	result := make(map[string][]Permission)
+	if strings.TrimSpace(roles) == "" {
+		return result, nil
+	}
+	for _, role := range strings.Split(roles, ";") {
+		parts := strings.SplitN(role, "=", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || name == "" {
+			return nil, fmt.Errorf("role %q must have the form role=permission,permission", role)
+		}
+		permissions, err := ParsePermissions(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("role %s: %s", name, err)
+		}
+		result[name] = append(result[name], permissions...)
+	}
+	return result, nil
+}
+
+// Implies returns whether the permission allows everything that the required permission allows
+func (p Permission) Implies(required Permission) bool {
+	if p == wildcard || p == required {
+		return true
+	}
+	resource, action := p.split()
+	requiredResource, requiredAction := required.split()
+	return (resource == wildcard || resource == requiredResource) && (action == wildcard || action == requiredAction)
+}
+
+func (p Permission) split() (string, string) {
+	parts := strings.SplitN(string(p), ":", 2)
+	if len(parts) != 2 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// RBACAuthorizer authorizes requests to resources based on the permissions granted to the roles of the users
+type RBACAuthorizer struct {
+	// Resources maps the URL paths to the names of the resources they manage
+	Resources map[string]string
+	// Claim is the claim of the OAuth tokens which contains the roles of the users such as scope
+	Claim string
+	// Roles maps the roles of the users authenticated with an OAuth token to their permissions
+	Roles map[string][]Permission
+	// PlatformPermissions are the permissions of the platforms authenticated with their basic credentials
+	PlatformPermissions []Permission
+}
+
+var _ security.Authorizer = &RBACAuthorizer{}
+
+// Authorize allows the request if the user has a permission for its resource and action. It abstains for requests
+// to unknown resources and for users authenticated in a way it does not know of.
+func (a *RBACAuthorizer) Authorize(request *http.Request) (security.Decision, error) {
+	user, ok := web.UserFromContext(request.Context())
+	if !ok {
+		return security.Abstain, nil
+	}
+	required, found := a.requiredPermission(request)
+	if !found {
+		return security.Abstain, nil
+	}
+
+	var permissions []Permission
+	switch user.AuthenticationType {
+	case web.BasicAuthentication:
+		permissions = a.PlatformPermissions
+	case web.BearerAuthentication:
+		roles, err := a.roles(user)
+		if err != nil {
+			return security.Deny, fmt.Errorf("could not read the roles of user %s: %s", user.Name, err)
+		}
+		for _, role := range roles {
+			permissions = append(permissions, a.Roles[role]...)
+		}
+	default:
+		return security.Abstain, nil
+	}
+
+	for _, permission := range permissions {
+		if permission.Implies(required) {
+			return security.Allow, nil
+		}
+	}
+	return security.Deny, fmt.Errorf("permission %s is required to %s %s", required, request.Method, request.URL.Path)
+}
+
+func (a *RBACAuthorizer) requiredPermission(request *http.Request) (Permission, bool) {
+	path := strings.TrimSuffix(request.URL.Path, "/")
+	for resourcePath, resource := range a.Resources {
+		if path == resourcePath || strings.HasPrefix(path, resourcePath+"/") {
+			action := WriteAction
+			if request.Method == http.MethodGet || request.Method == http.MethodHead {
+				action = ReadAction
+			}
+			return NewPermission(resource, action), true
+		}
+	}
+	return "", false
+}
+
+// roles reads the roles of the user from the claim which is either a list or a space separated string as scope
+// in RFC 6749 section 3.3
+func (a *RBACAuthorizer) roles(user *web.UserContext) ([]string, error) {
+	if user.Data == nil {
+		return nil, nil
+	}
+	claims := make(map[string]interface{})
+	if err := user.Data.Data(&claims); err != nil {
+		return nil, err
+	}
+	switch value := claims[a.Claim].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return strings.Fields(value), nil
+	case []interface{}:
+		roles := make([]string, 0, len(value))
+		for _, role := range value {
+			roleName, ok := role.(string)
+			if !ok {
+				return nil, fmt.Errorf("claim %s must contain strings", a.Claim)
+			}
+			roles = append(roles, roleName)
+		}
+		return roles, nil
+	default:
+		return nil, fmt.Errorf("claim %s must be a string or a list of strings", a.Claim)
+	}
+}
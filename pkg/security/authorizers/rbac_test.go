@@ -0,0 +1,150 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package authorizers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Peripli/service-manager/pkg/security"
+	"github.com/Peripli/service-manager/pkg/web"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+func TestAuthorizers(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Authorizers Suite")
+}
+
+type claimsData struct {
+	claims string
+}
+
+func (d *claimsData) Data(v interface{}) error {
+	return json.Unmarshal([]byte(d.claims), v)
+}
+
+var _ = Describe("RBAC Authorizer", func() {
+	var authorizer *RBACAuthorizer
+
+	newRequest := func(method, path string, user *web.UserContext) *http.Request {
+		request, err := http.NewRequest(method, "https://example.com"+path, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		if user != nil {
+			request = request.WithContext(web.ContextWithUser(request.Context(), user))
+		}
+		return request
+	}
+
+	bearerUser := func(claims string) *web.UserContext {
+		return &web.UserContext{
+			Name:               "user",
+			Data:               &claimsData{claims: claims},
+			AuthenticationType: web.BearerAuthentication,
+		}
+	}
+
+	BeforeEach(func() {
+		roles, err := ParseRoles("admin=*;viewer=*:read;broker-admin=brokers:*")
+		Expect(err).ShouldNot(HaveOccurred())
+		platformPermissions, err := ParsePermissions("brokers:read")
+		Expect(err).ShouldNot(HaveOccurred())
+		authorizer = &RBACAuthorizer{
+			Resources: map[string]string{
+				"/v1/service_brokers": "brokers",
+				"/v1/visibilities":    "visibilities",
+			},
+			Claim:               "scope",
+			Roles:               roles,
+			PlatformPermissions: platformPermissions,
+		}
+	})
+
+	DescribeTable("users authenticated with a token",
+		func(claims, method, path string, expected security.Decision) {
+			decision, err := authorizer.Authorize(newRequest(method, path, bearerUser(claims)))
+			Expect(decision).To(Equal(expected))
+			if expected == security.Deny {
+				Expect(err).To(HaveOccurred())
+			} else {
+				Expect(err).ShouldNot(HaveOccurred())
+			}
+		},
+		Entry("admin writes", `{"scope":["admin"]}`, http.MethodDelete, "/v1/service_brokers/1", security.Allow),
+		Entry("viewer reads", `{"scope":["viewer"]}`, http.MethodGet, "/v1/visibilities", security.Allow),
+		Entry("viewer writes", `{"scope":["viewer"]}`, http.MethodPost, "/v1/visibilities", security.Deny),
+		Entry("resource role writes its resource", `{"scope":"other broker-admin"}`, http.MethodPatch, "/v1/service_brokers/1", security.Allow),
+		Entry("resource role writes another resource", `{"scope":"broker-admin"}`, http.MethodPost, "/v1/visibilities", security.Deny),
+		Entry("unknown role", `{"scope":["unknown"]}`, http.MethodGet, "/v1/visibilities", security.Deny),
+		Entry("missing claim", `{}`, http.MethodGet, "/v1/visibilities", security.Deny),
+		Entry("unknown resource", `{}`, http.MethodGet, "/v1/info", security.Abstain),
+	)
+
+	It("denies users whose claim is not a list of strings", func() {
+		decision, err := authorizer.Authorize(newRequest(http.MethodGet, "/v1/visibilities", bearerUser(`{"scope":[1]}`)))
+		Expect(decision).To(Equal(security.Deny))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("grants the platform permissions to platforms", func() {
+		platform := &web.UserContext{Name: "platform", AuthenticationType: web.BasicAuthentication}
+		decision, err := authorizer.Authorize(newRequest(http.MethodGet, "/v1/service_brokers", platform))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(decision).To(Equal(security.Allow))
+
+		decision, err = authorizer.Authorize(newRequest(http.MethodGet, "/v1/visibilities", platform))
+		Expect(err).To(HaveOccurred())
+		Expect(decision).To(Equal(security.Deny))
+	})
+
+	It("abstains for unauthenticated requests", func() {
+		decision, err := authorizer.Authorize(newRequest(http.MethodGet, "/v1/visibilities", nil))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(decision).To(Equal(security.Abstain))
+	})
+
+	It("abstains for users authenticated in an unknown way", func() {
+		decision, err := authorizer.Authorize(newRequest(http.MethodGet, "/v1/visibilities", &web.UserContext{Name: "user"}))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(decision).To(Equal(security.Abstain))
+	})
+
+	Describe("ParseRoles", func() {
+		It("parses roles with multiple permissions", func() {
+			roles, err := ParseRoles(" admin = * ; viewer=brokers:read, visibilities:read")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(roles).To(Equal(map[string][]Permission{
+				"admin":  {"*"},
+				"viewer": {"brokers:read", "visibilities:read"},
+			}))
+		})
+
+		DescribeTable("rejects invalid roles",
+			func(roles string) {
+				_, err := ParseRoles(roles)
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("without permissions", "admin"),
+			Entry("without name", "=*"),
+			Entry("with a permission without action", "admin=brokers"),
+			Entry("with an unknown action", "admin=brokers:delete"),
+		)
+	})
+})
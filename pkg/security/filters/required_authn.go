@@ -57,6 +57,8 @@ func (raf *requiredAuthnFilter) FilterMatchers() []web.FilterMatcher {
 					web.ServiceOfferingsURL+"/**",
 					web.ServicePlansURL+"/**",
 					web.VisibilitiesURL+"/**",
+					web.OperationsURL+"/**",
+					web.ReportsURL+"/**",
 				),
 			},
 		},
@@ -0,0 +1,38 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package security
+
+import (
+	"golang.org/x/crypto/bcrypt"
+)
+
+// HashPassword returns a salted bcrypt hash of the password. Passwords that never need to be recovered such as the
+// platform passwords are stored hashed instead of encrypted.
+func HashPassword(password []byte) ([]byte, error) {
+	return bcrypt.GenerateFromPassword(password, bcrypt.DefaultCost)
+}
+
+// IsPasswordHash returns whether the stored password is a hash produced by HashPassword
+func IsPasswordHash(stored []byte) bool {
+	_, err := bcrypt.Cost(stored)
+	return err == nil
+}
+
+// PasswordMatchesHash compares the password with the hash in constant time
+func PasswordMatchesHash(hash []byte, password []byte) bool {
+	return bcrypt.CompareHashAndPassword(hash, password) == nil
+}
@@ -0,0 +1,50 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package security_test
+
+import (
+	"github.com/Peripli/service-manager/pkg/security"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Password hashing", func() {
+	password := []byte("password")
+
+	It("matches the hashed password", func() {
+		hash, err := security.HashPassword(password)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(security.IsPasswordHash(hash)).To(BeTrue())
+		Expect(security.PasswordMatchesHash(hash, password)).To(BeTrue())
+		Expect(security.PasswordMatchesHash(hash, []byte("not-password"))).To(BeFalse())
+	})
+
+	It("salts the hashes", func() {
+		hash1, err := security.HashPassword(password)
+		Expect(err).ToNot(HaveOccurred())
+		hash2, err := security.HashPassword(password)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hash1).ToNot(Equal(hash2))
+	})
+
+	It("does not recognize encrypted passwords as hashes", func() {
+		encrypted, err := security.Encrypt(password, make([]byte, 32))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(security.IsPasswordHash(encrypted)).To(BeFalse())
+		Expect(security.IsPasswordHash(password)).To(BeFalse())
+	})
+})
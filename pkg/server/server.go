@@ -106,7 +106,9 @@ func registerControllers(API *web.API, router *mux.Router, config *Settings) {
 		for _, route := range ctrl.Routes() {
 			log.D().Debugf("Registering endpoint: %s %s", route.Endpoint.Method, route.Endpoint.Path)
 			handler := web.Filters(API.Filters).ChainMatching(route)
-			router.Handle(route.Endpoint.Path, api.NewHTTPHandler(handler, config.MaxBodyBytes)).Methods(route.Endpoint.Method)
+			httpHandler := api.NewHTTPHandler(handler, config.MaxBodyBytes)
+			httpHandler.OptionalBody = route.OptionalBody
+			router.Handle(route.Endpoint.Path, httpHandler).Methods(route.Endpoint.Method)
 		}
 	}
 }
@@ -25,8 +25,11 @@ import (
 	"time"
 
 	"github.com/Peripli/service-manager/api"
+	"github.com/Peripli/service-manager/api/broker"
 	"github.com/Peripli/service-manager/api/healthcheck"
+	"github.com/Peripli/service-manager/api/visibility"
 	"github.com/Peripli/service-manager/config"
+	"github.com/Peripli/service-manager/pkg/health"
 	"github.com/Peripli/service-manager/pkg/log"
 	"github.com/Peripli/service-manager/pkg/security"
 	"github.com/Peripli/service-manager/pkg/server"
@@ -49,6 +52,8 @@ type ServiceManagerBuilder struct {
 	Storage storage.Storage
 	ctx     context.Context
 	cfg     *server.Settings
+
+	catalogResyncInterval time.Duration
 }
 
 // ServiceManager  struct
@@ -119,12 +124,33 @@ func New(ctx context.Context, cancel context.CancelFunc, env env.Environment) *S
 	}
 
 	API.AddHealthIndicator(&storage.HealthIndicator{Pinger: storage.PingFunc(smStorage.Ping)})
+	if cfg.API.BrokerHealthIndicators {
+		API.AddHealthIndicator(&broker.HealthIndicator{
+			BrokerStorage:     smStorage.Broker(),
+			AggregationPolicy: &health.ThresholdAggregationPolicy{DownThreshold: cfg.API.BrokerHealthDownThreshold},
+		})
+	}
+
+	if cfg.API.PublicPlansPolicy != "" && cfg.API.PublicPlansReconciliationInterval > 0 {
+		publicPlansFilter, err := api.NewPublicServicePlansFilter(smStorage, cfg.API)
+		if err != nil {
+			panic(fmt.Sprintf("error creating public plans filter: %s", err))
+		}
+		publicPlansJob := filters.NewPublicPlansReconciliationJob(publicPlansFilter, cfg.API.PublicPlansReconciliationInterval)
+		go publicPlansJob.Run(ctx)
+	}
+
+	if cfg.API.ExpiredVisibilitiesCleanupInterval > 0 {
+		cleanupJob := visibility.NewExpiredVisibilitiesCleanupJob(smStorage, cfg.API.ExpiredVisibilitiesCleanupInterval)
+		go cleanupJob.Run(ctx)
+	}
 
 	return &ServiceManagerBuilder{
-		ctx:     ctx,
-		cfg:     cfg.Server,
-		API:     API,
-		Storage: smStorage,
+		ctx:                   ctx,
+		cfg:                   cfg.Server,
+		API:                   API,
+		Storage:               smStorage,
+		catalogResyncInterval: cfg.API.CatalogResyncInterval,
 	}
 }
 
@@ -132,6 +158,8 @@ func New(ctx context.Context, cancel context.CancelFunc, env env.Environment) *S
 func (smb *ServiceManagerBuilder) Build() *ServiceManager {
 	// setup server and add relevant global middleware
 	smb.installHealth()
+	smb.installPublicPlansReconciliation()
+	smb.startCatalogResync()
 
 	srv := server.New(smb.cfg, smb.API)
 	srv.Use(filters.NewRecoveryMiddleware())
@@ -148,6 +176,45 @@ func (smb *ServiceManagerBuilder) installHealth() {
 	}
 }
 
+// installPublicPlansReconciliation lets the broker controllers reconcile the public plans of every catalog they store
+// with the registered public plans filter, as the filter only sees the broker registrations and updates
+func (smb *ServiceManagerBuilder) installPublicPlansReconciliation() {
+	for _, filter := range smb.Filters {
+		publicPlansFilter, ok := filter.(*filters.PublicServicePlansFilter)
+		if !ok {
+			continue
+		}
+		for _, brokerController := range smb.brokerControllers() {
+			brokerController.ReconcilePublicPlansFunc = publicPlansFilter.ReconcilePublicPlans
+		}
+	}
+}
+
+// startCatalogResync starts the periodic catalog resync with the broker controller of the API, so that the resynced
+// catalogs are stored in the same way as the ones of the API
+func (smb *ServiceManagerBuilder) startCatalogResync() {
+	if smb.catalogResyncInterval <= 0 {
+		return
+	}
+	brokerControllers := smb.brokerControllers()
+	if len(brokerControllers) == 0 {
+		log.C(smb.ctx).Warn("Periodic catalog resync is not started as no broker controller is registered")
+		return
+	}
+	catalogResyncJob := broker.NewCatalogResyncJob(brokerControllers[0], smb.catalogResyncInterval)
+	go catalogResyncJob.Run(smb.ctx)
+}
+
+func (smb *ServiceManagerBuilder) brokerControllers() []*broker.Controller {
+	brokerControllers := make([]*broker.Controller, 0)
+	for _, controller := range smb.Controllers {
+		if brokerController, ok := controller.(*broker.Controller); ok {
+			brokerControllers = append(brokerControllers, brokerController)
+		}
+	}
+	return brokerControllers
+}
+
 // Run starts the Service Manager
 func (sm *ServiceManager) Run() {
 	log.C(sm.ctx).Info("Running Service Manager...")
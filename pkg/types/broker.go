@@ -31,6 +31,18 @@ type Brokers struct {
 	Brokers []*Broker `json:"service_brokers"`
 }
 
+// BrokerState is the state of a service broker
+type BrokerState string
+
+const (
+	// BrokerActive represents a broker whose services can be provisioned and bound
+	BrokerActive BrokerState = "active"
+
+	// BrokerSuspended represents a broker that is temporarily taken out of service. Its services are not offered to the
+	// platforms and new service instances and bindings are rejected.
+	BrokerSuspended BrokerState = "suspended"
+)
+
 // Broker broker struct
 type Broker struct {
 	ID          string       `json:"id"`
@@ -41,6 +53,22 @@ type Broker struct {
 	BrokerURL   string       `json:"broker_url"`
 	Credentials *Credentials `json:"credentials,omitempty" structs:"-"`
 
+	CatalogSyncedAt time.Time `json:"catalog_synced_at"`
+	// CatalogSyncFailedAt is the time of the latest failed catalog sync. It is reset by a successful sync.
+	CatalogSyncFailedAt time.Time `json:"catalog_sync_failed_at"`
+	CatalogSyncError    string    `json:"catalog_sync_error,omitempty"`
+
+	// CatalogETag identifies the latest catalog fetched from the broker - its ETag or a hash of its content
+	CatalogETag string `json:"-"`
+
+	PinnedCatalogVersion int64 `json:"pinned_catalog_version,omitempty"`
+
+	State BrokerState `json:"state"`
+
+	NamingPolicy *NamingPolicy `json:"naming_policy,omitempty" structs:"-"`
+
+	StaticCatalog json.RawMessage `json:"static_catalog,omitempty" structs:"-"`
+
 	Services []*ServiceOffering `json:"services,omitempty" structs:"-"`
 
 	Labels Labels `json:"labels,omitempty"`
@@ -59,6 +87,12 @@ func (b *Broker) Validate() error {
 		return err
 	}
 
+	if b.NamingPolicy != nil {
+		if err := b.NamingPolicy.Validate(); err != nil {
+			return err
+		}
+	}
+
 	if b.Credentials == nil {
 		return errors.New("missing credentials")
 	}
@@ -66,6 +100,12 @@ func (b *Broker) Validate() error {
 
 }
 
+// HasStaticCatalog returns whether the catalog of the broker is provided on registration instead of being fetched
+// from the catalog endpoint of the broker
+func (b *Broker) HasStaticCatalog() bool {
+	return len(b.StaticCatalog) != 0 && string(b.StaticCatalog) != "null"
+}
+
 // MarshalJSON override json serialization for http response
 func (b *Broker) MarshalJSON() ([]byte, error) {
 	type B Broker
@@ -73,6 +113,9 @@ func (b *Broker) MarshalJSON() ([]byte, error) {
 		*B
 		CreatedAt *string `json:"created_at,omitempty"`
 		UpdatedAt *string `json:"updated_at,omitempty"`
+
+		CatalogSyncedAt     *string `json:"catalog_synced_at,omitempty"`
+		CatalogSyncFailedAt *string `json:"catalog_sync_failed_at,omitempty"`
 	}{
 		B: (*B)(b),
 	}
@@ -84,6 +127,14 @@ func (b *Broker) MarshalJSON() ([]byte, error) {
 		str := util.ToRFCFormat(b.UpdatedAt)
 		toMarshal.UpdatedAt = &str
 	}
+	if !b.CatalogSyncedAt.IsZero() {
+		str := util.ToRFCFormat(b.CatalogSyncedAt)
+		toMarshal.CatalogSyncedAt = &str
+	}
+	if !b.CatalogSyncFailedAt.IsZero() {
+		str := util.ToRFCFormat(b.CatalogSyncFailedAt)
+		toMarshal.CatalogSyncFailedAt = &str
+	}
 
 	hasNoLabels := true
 	for key, values := range b.Labels {
@@ -0,0 +1,54 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package types
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/util"
+)
+
+// BrokerCatalogs struct
+type BrokerCatalogs struct {
+	BrokerCatalogs []*BrokerCatalog `json:"catalogs"`
+}
+
+// BrokerCatalog is a snapshot of the OSB catalog of a service broker
+type BrokerCatalog struct {
+	ID        string          `json:"id"`
+	BrokerID  string          `json:"broker_id"`
+	Version   int64           `json:"version"`
+	Catalog   json.RawMessage `json:"catalog,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// MarshalJSON override json serialization for http response
+func (bc *BrokerCatalog) MarshalJSON() ([]byte, error) {
+	type BC BrokerCatalog
+	toMarshal := struct {
+		*BC
+		CreatedAt *string `json:"created_at,omitempty"`
+	}{
+		BC: (*BC)(bc),
+	}
+	if !bc.CreatedAt.IsZero() {
+		str := util.ToRFCFormat(bc.CreatedAt)
+		toMarshal.CreatedAt = &str
+	}
+	return json.Marshal(toMarshal)
+}
@@ -0,0 +1,47 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package types
+
+// BrokerCheckStatus is the outcome of a single broker check
+type BrokerCheckStatus string
+
+const (
+	// CheckPassed represents a check that found no problems
+	CheckPassed BrokerCheckStatus = "passed"
+
+	// CheckWarning represents a check that found a problem which does not prevent SM from using the broker
+	CheckWarning BrokerCheckStatus = "warning"
+
+	// CheckFailed represents a check that found a problem which prevents SM from using the broker
+	CheckFailed BrokerCheckStatus = "failed"
+
+	// CheckSkipped represents a check that could not be performed because a check it depends on has failed
+	CheckSkipped BrokerCheckStatus = "skipped"
+)
+
+// BrokerCheck is the outcome of verifying a single aspect of a service broker
+type BrokerCheck struct {
+	Name    string            `json:"name"`
+	Status  BrokerCheckStatus `json:"status"`
+	Details []string          `json:"details,omitempty"`
+}
+
+// BrokerCheckReport describes whether a service broker can be used by SM and which problems were found
+type BrokerCheckReport struct {
+	Passed bool           `json:"passed"`
+	Checks []*BrokerCheck `json:"checks"`
+}
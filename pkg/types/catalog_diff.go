@@ -0,0 +1,45 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package types
+
+// CatalogDiff describes the changes in SM DB that storing a broker catalog would make
+type CatalogDiff struct {
+	AddedServiceOfferings   []*ServiceOffering `json:"added_service_offerings"`
+	UpdatedServiceOfferings []*ServiceOffering `json:"updated_service_offerings"`
+	RemovedServiceOfferings []*ServiceOffering `json:"removed_service_offerings"`
+
+	AddedServicePlans   []*ServicePlan `json:"added_service_plans"`
+	UpdatedServicePlans []*ServicePlan `json:"updated_service_plans"`
+	RemovedServicePlans []*ServicePlan `json:"removed_service_plans"`
+
+	// DeactivatedServicePlans are the plans that are no longer in the catalog but are kept as they are protected from removal
+	DeactivatedServicePlans []*ServicePlan `json:"deactivated_service_plans"`
+
+	RemovedVisibilities []*Visibility `json:"removed_visibilities"`
+}
+
+// CatalogRefresh is the outcome of refreshing the catalog of a single broker
+type CatalogRefresh struct {
+	BrokerID string       `json:"broker_id"`
+	Changes  *CatalogDiff `json:"changes,omitempty"`
+	Error    string       `json:"error,omitempty"`
+}
+
+// CatalogRefreshes is the outcome of refreshing the catalogs of multiple brokers
+type CatalogRefreshes struct {
+	CatalogRefreshes []*CatalogRefresh `json:"refreshes"`
+}
@@ -0,0 +1,166 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/util"
+)
+
+// displayNameMetadataKey is the OSB catalog metadata field that platforms show as the name of an offering or plan
+const displayNameMetadataKey = "displayName"
+
+// CatalogOverride contains SM-managed changes to a service offering or plan of a broker catalog. Overrides are
+// stored separately from the catalog and are matched by catalog ids, so they are preserved across catalog resyncs.
+type CatalogOverride struct {
+	ID               string          `json:"id"`
+	BrokerID         string          `json:"broker_id"`
+	ServiceCatalogID string          `json:"service_catalog_id"`
+	PlanCatalogID    string          `json:"plan_catalog_id,omitempty"`
+	DisplayName      string          `json:"display_name,omitempty"`
+	Description      string          `json:"description,omitempty"`
+	Hidden           bool            `json:"hidden"`
+	Metadata         json.RawMessage `json:"metadata,omitempty"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+// MarshalJSON override json serialization for http response
+func (o *CatalogOverride) MarshalJSON() ([]byte, error) {
+	type O CatalogOverride
+	toMarshal := struct {
+		*O
+		CreatedAt *string `json:"created_at,omitempty"`
+		UpdatedAt *string `json:"updated_at,omitempty"`
+	}{
+		O: (*O)(o),
+	}
+	if !o.CreatedAt.IsZero() {
+		str := util.ToRFCFormat(o.CreatedAt)
+		toMarshal.CreatedAt = &str
+	}
+	if !o.UpdatedAt.IsZero() {
+		str := util.ToRFCFormat(o.UpdatedAt)
+		toMarshal.UpdatedAt = &str
+	}
+	return json.Marshal(toMarshal)
+}
+
+// Validate implements InputValidator and verifies all mandatory fields are populated
+func (o *CatalogOverride) Validate() error {
+	if len(o.Metadata) == 0 {
+		return nil
+	}
+	metadata := make(map[string]interface{})
+	if err := json.Unmarshal(o.Metadata, &metadata); err != nil {
+		return errors.New("catalog override metadata must be a JSON object")
+	}
+	return nil
+}
+
+// IsHidden returns whether the overridden offering or plan is hidden from the platforms
+func (o *CatalogOverride) IsHidden() bool {
+	return o != nil && o.Hidden
+}
+
+// ApplyToServiceOffering changes the service offering as specified by the override
+func (o *CatalogOverride) ApplyToServiceOffering(serviceOffering *ServiceOffering) error {
+	if o == nil {
+		return nil
+	}
+	if o.Description != "" {
+		serviceOffering.Description = o.Description
+	}
+	metadata, err := o.applyToMetadata(serviceOffering.Metadata)
+	if err != nil {
+		return err
+	}
+	serviceOffering.Metadata = metadata
+	return nil
+}
+
+// ApplyToServicePlan changes the service plan as specified by the override
+func (o *CatalogOverride) ApplyToServicePlan(servicePlan *ServicePlan) error {
+	if o == nil {
+		return nil
+	}
+	if o.Description != "" {
+		servicePlan.Description = o.Description
+	}
+	metadata, err := o.applyToMetadata(servicePlan.Metadata)
+	if err != nil {
+		return err
+	}
+	servicePlan.Metadata = metadata
+	return nil
+}
+
+// applyToMetadata adds the override metadata and display name to the catalog metadata. Override values take precedence.
+func (o *CatalogOverride) applyToMetadata(catalogMetadata json.RawMessage) (json.RawMessage, error) {
+	if len(o.Metadata) == 0 && o.DisplayName == "" {
+		return catalogMetadata, nil
+	}
+	metadata := make(map[string]interface{})
+	if len(catalogMetadata) != 0 && string(catalogMetadata) != "null" {
+		if err := json.Unmarshal(catalogMetadata, &metadata); err != nil {
+			return nil, fmt.Errorf("could not unmarshal catalog metadata: %s", err)
+		}
+	}
+	if len(o.Metadata) != 0 {
+		overrideMetadata := make(map[string]interface{})
+		if err := json.Unmarshal(o.Metadata, &overrideMetadata); err != nil {
+			return nil, fmt.Errorf("could not unmarshal catalog override metadata: %s", err)
+		}
+		for key, value := range overrideMetadata {
+			metadata[key] = value
+		}
+	}
+	if o.DisplayName != "" {
+		metadata[displayNameMetadataKey] = o.DisplayName
+	}
+	return json.Marshal(metadata)
+}
+
+// CatalogOverridesIndex provides lookup of catalog overrides by the catalog ids of the offerings and plans they apply to
+type CatalogOverridesIndex map[string]*CatalogOverride
+
+// NewCatalogOverridesIndex returns an index of the specified overrides
+func NewCatalogOverridesIndex(overrides []*CatalogOverride) CatalogOverridesIndex {
+	index := make(CatalogOverridesIndex, len(overrides))
+	for _, override := range overrides {
+		index[catalogOverrideKey(override.BrokerID, override.ServiceCatalogID, override.PlanCatalogID)] = override
+	}
+	return index
+}
+
+// ForServiceOffering returns the override of the service offering or nil if it is not overridden
+func (i CatalogOverridesIndex) ForServiceOffering(brokerID, serviceCatalogID string) *CatalogOverride {
+	return i[catalogOverrideKey(brokerID, serviceCatalogID, "")]
+}
+
+// ForServicePlan returns the override of the service plan or nil if it is not overridden
+func (i CatalogOverridesIndex) ForServicePlan(brokerID, serviceCatalogID, planCatalogID string) *CatalogOverride {
+	return i[catalogOverrideKey(brokerID, serviceCatalogID, planCatalogID)]
+}
+
+func catalogOverrideKey(brokerID, serviceCatalogID, planCatalogID string) string {
+	return brokerID + "/" + serviceCatalogID + "/" + planCatalogID
+}
@@ -0,0 +1,51 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package types
+
+// NamingConflictField is the catalog field whose value is duplicated across the registered brokers
+type NamingConflictField string
+
+const (
+	// ServiceNameConflict represents a service offering name, as offered to the platforms, which is not unique
+	ServiceNameConflict NamingConflictField = "service_name"
+
+	// ServiceCatalogIDConflict represents a service offering catalog id which is not unique
+	ServiceCatalogIDConflict NamingConflictField = "service_catalog_id"
+
+	// PlanCatalogIDConflict represents a service plan catalog id which is not unique
+	PlanCatalogIDConflict NamingConflictField = "plan_catalog_id"
+)
+
+// NamingConflicts struct
+type NamingConflicts struct {
+	NamingConflicts []*NamingConflict `json:"conflicts"`
+}
+
+// NamingConflict lists the service offerings and plans which share the same value of a catalog field
+type NamingConflict struct {
+	Field   NamingConflictField    `json:"field"`
+	Value   string                 `json:"value"`
+	Entries []*NamingConflictEntry `json:"entries"`
+}
+
+// NamingConflictEntry identifies a service offering or plan that takes part in a naming conflict
+type NamingConflictEntry struct {
+	BrokerID          string `json:"broker_id"`
+	BrokerName        string `json:"broker_name"`
+	ServiceOfferingID string `json:"service_offering_id"`
+	ServicePlanID     string `json:"service_plan_id,omitempty"`
+}
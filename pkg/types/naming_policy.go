@@ -0,0 +1,107 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package types
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"text/template"
+)
+
+// NamingPolicy namespaces the names of the service offerings and plans of a broker so that they do not collide with
+// the names offered by other brokers. The prefix and suffix are text templates which can refer to the broker name
+// as {{.Name}} and to the first value of a broker label as {{.Labels.<key>}}.
+type NamingPolicy struct {
+	Prefix       string `json:"prefix,omitempty"`
+	Suffix       string `json:"suffix,omitempty"`
+	ApplyToPlans bool   `json:"apply_to_plans,omitempty"`
+}
+
+// namingPolicyData is the data which the naming policy templates are executed with
+type namingPolicyData struct {
+	Name   string
+	Labels map[string]string
+}
+
+// Validate implements InputValidator and verifies all mandatory fields are populated
+func (np *NamingPolicy) Validate() error {
+	if np.Prefix == "" && np.Suffix == "" {
+		return errors.New("naming policy must specify a prefix or a suffix")
+	}
+	if _, err := parseNamingTemplate("prefix", np.Prefix); err != nil {
+		return err
+	}
+	if _, err := parseNamingTemplate("suffix", np.Suffix); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Apply namespaces the names of the specified service offerings of the broker and, if requested, of their plans
+func (np *NamingPolicy) Apply(broker *Broker, serviceOfferings []*ServiceOffering) error {
+	prefix, err := np.render("prefix", np.Prefix, broker)
+	if err != nil {
+		return err
+	}
+	suffix, err := np.render("suffix", np.Suffix, broker)
+	if err != nil {
+		return err
+	}
+	for _, serviceOffering := range serviceOfferings {
+		serviceOffering.Name = prefix + serviceOffering.Name + suffix
+		if !np.ApplyToPlans {
+			continue
+		}
+		for _, servicePlan := range serviceOffering.Plans {
+			servicePlan.Name = prefix + servicePlan.Name + suffix
+		}
+	}
+	return nil
+}
+
+func (np *NamingPolicy) render(name, text string, broker *Broker) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	tmpl, err := parseNamingTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+	data := namingPolicyData{
+		Name:   broker.Name,
+		Labels: make(map[string]string, len(broker.Labels)),
+	}
+	for key, values := range broker.Labels {
+		if len(values) != 0 {
+			data.Labels[key] = values[0]
+		}
+	}
+	result := &bytes.Buffer{}
+	if err := tmpl.Execute(result, data); err != nil {
+		return "", fmt.Errorf("could not apply naming policy %s of broker %s: %s", name, broker.Name, err)
+	}
+	return result.String(), nil
+}
+
+func parseNamingTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid naming policy %s: %s", name, err)
+	}
+	return tmpl, nil
+}
@@ -0,0 +1,79 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package types
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/util"
+)
+
+// OperationType is the type of the change an operation makes to a resource
+type OperationType string
+
+const (
+	// CreateOperation represents an operation that creates a resource
+	CreateOperation OperationType = "create"
+)
+
+// OperationState is the state of an operation
+type OperationState string
+
+const (
+	// InProgress represents an operation that is still running
+	InProgress OperationState = "in progress"
+
+	// Succeeded represents an operation that finished successfully
+	Succeeded OperationState = "succeeded"
+
+	// Failed represents an operation that finished with an error
+	Failed OperationState = "failed"
+)
+
+// Operation tracks an asynchronous change of a Service Manager resource
+type Operation struct {
+	ID           string          `json:"id"`
+	Type         OperationType   `json:"type"`
+	State        OperationState  `json:"state"`
+	ResourceID   string          `json:"resource_id,omitempty"`
+	ResourceType string          `json:"resource_type"`
+	Errors       json.RawMessage `json:"errors,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// MarshalJSON override json serialization for http response
+func (o *Operation) MarshalJSON() ([]byte, error) {
+	type O Operation
+	toMarshal := struct {
+		*O
+		CreatedAt *string `json:"created_at,omitempty"`
+		UpdatedAt *string `json:"updated_at,omitempty"`
+	}{
+		O: (*O)(o),
+	}
+	if !o.CreatedAt.IsZero() {
+		str := util.ToRFCFormat(o.CreatedAt)
+		toMarshal.CreatedAt = &str
+	}
+	if !o.UpdatedAt.IsZero() {
+		str := util.ToRFCFormat(o.UpdatedAt)
+		toMarshal.UpdatedAt = &str
+	}
+	return json.Marshal(toMarshal)
+}
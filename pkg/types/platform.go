@@ -35,6 +35,12 @@ type Platform struct {
 	CreatedAt   time.Time    `json:"created_at"`
 	UpdatedAt   time.Time    `json:"updated_at"`
 	Credentials *Credentials `json:"credentials,omitempty"`
+	Labels      Labels       `json:"labels,omitempty"`
+
+	// PreviousCredentials are the credentials replaced by the last rotation. They remain valid until
+	// PreviousCredentialsExpireAt so that the platform can be reconfigured without downtime.
+	PreviousCredentials         *Credentials `json:"-"`
+	PreviousCredentialsExpireAt time.Time    `json:"-"`
 }
 
 // MarshalJSON override json serialization for http response
@@ -70,5 +76,8 @@ func (p *Platform) Validate() error {
 	if util.HasRFC3986ReservedSymbols(p.ID) {
 		return fmt.Errorf("%s contains invalid character(s)", p.ID)
 	}
+	if err := p.Labels.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
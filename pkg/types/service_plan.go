@@ -47,6 +47,9 @@ type ServicePlan struct {
 	Schemas  json.RawMessage `json:"schemas,omitempty"`
 
 	ServiceOfferingID string `json:"service_offering_id"`
+
+	Active        bool      `json:"active"`
+	DeactivatedAt time.Time `json:"deactivated_at"`
 }
 
 // MarshalJSON override json serialization for http response
@@ -56,6 +59,7 @@ func (sp *ServicePlan) MarshalJSON() ([]byte, error) {
 		CreatedAt *string `json:"created_at,omitempty"`
 		UpdatedAt *string `json:"updated_at,omitempty"`
 		*SP
+		DeactivatedAt *string `json:"deactivated_at,omitempty"`
 	}{
 		SP: (*SP)(sp),
 	}
@@ -68,6 +72,10 @@ func (sp *ServicePlan) MarshalJSON() ([]byte, error) {
 		str := util.ToRFCFormat(sp.UpdatedAt)
 		toMarshal.UpdatedAt = &str
 	}
+	if !sp.DeactivatedAt.IsZero() {
+		str := util.ToRFCFormat(sp.DeactivatedAt)
+		toMarshal.DeactivatedAt = &str
+	}
 	return json.Marshal(toMarshal)
 }
 
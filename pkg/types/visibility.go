@@ -23,6 +23,7 @@ import (
 
 	"errors"
 
+	"github.com/Peripli/service-manager/pkg/query"
 	"github.com/Peripli/service-manager/pkg/util"
 )
 
@@ -31,20 +32,50 @@ type Visibilities struct {
 	Visibilities []*Visibility `json:"visibilities"`
 }
 
+// VisibilityEffect is the effect of a visibility on the plans it covers
+type VisibilityEffect string
+
+const (
+	// VisibilityAllow represents a visibility that makes the plans visible to the platforms
+	VisibilityAllow VisibilityEffect = "allow"
+
+	// VisibilityDeny represents a visibility that hides the plans from the platforms even if other visibilities,
+	// including public ones, make them visible
+	VisibilityDeny VisibilityEffect = "deny"
+)
+
 // Visibility struct
 type Visibility struct {
 	ID            string    `json:"id"`
 	PlatformID    string    `json:"platform_id"`
-	ServicePlanID string    `json:"service_plan_id"`
+	ServicePlanID string    `json:"service_plan_id,omitempty"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 	Labels        Labels    `json:"labels,omitempty"`
+
+	// PlatformSelector is a label query such as region in [eu10||eu20] selecting the platforms to which the plan is
+	// visible. It is an alternative to PlatformID which is evaluated against the labels of the platforms.
+	PlatformSelector string `json:"platform_selector,omitempty"`
+
+	// ServiceOfferingID makes all current and future plans of the service offering visible. It is an alternative to
+	// ServicePlanID.
+	ServiceOfferingID string `json:"service_offering_id,omitempty"`
+
+	// ValidFrom and ExpiresAt optionally bound the period during which the visibility applies. The visibility is
+	// deleted once it expires.
+	ValidFrom time.Time `json:"valid_from"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	Effect VisibilityEffect `json:"effect,omitempty"`
 }
 
 // Validate implements InputValidator and verifies all mandatory fields are populated
 func (v *Visibility) Validate() error {
-	if v.ServicePlanID == "" {
-		return errors.New("missing visibility service plan id")
+	if v.ServicePlanID == "" && v.ServiceOfferingID == "" {
+		return errors.New("missing visibility service plan id or service offering id")
+	}
+	if v.ServicePlanID != "" && v.ServiceOfferingID != "" {
+		return errors.New("visibility cannot have both service plan id and service offering id")
 	}
 	if util.HasRFC3986ReservedSymbols(v.ID) {
 		return fmt.Errorf("%s contains invalid character(s)", v.ID)
@@ -52,9 +83,128 @@ func (v *Visibility) Validate() error {
 	if err := v.Labels.Validate(); err != nil {
 		return err
 	}
+	if v.Effect != "" && v.Effect != VisibilityAllow && v.Effect != VisibilityDeny {
+		return fmt.Errorf("visibility effect must be one of %s or %s", VisibilityAllow, VisibilityDeny)
+	}
+	if v.IsDeny() && v.PlatformID == "" && v.PlatformSelector == "" {
+		return errors.New("deny visibility requires platform id or platform selector")
+	}
+	if !v.ValidFrom.IsZero() && !v.ExpiresAt.IsZero() && !v.ExpiresAt.After(v.ValidFrom) {
+		return errors.New("visibility expires_at must be after valid_from")
+	}
+	if v.PlatformSelector != "" {
+		if v.PlatformID != "" {
+			return errors.New("visibility cannot have both platform id and platform selector")
+		}
+		if _, err := query.Parse(query.LabelQuery, v.PlatformSelector); err != nil {
+			return fmt.Errorf("invalid platform selector: %s", err)
+		}
+	}
 	return nil
 }
 
+// IsPublic returns whether the visibility makes the plan visible to all platforms
+func (v *Visibility) IsPublic() bool {
+	return v.PlatformID == "" && v.PlatformSelector == "" && !v.IsDeny()
+}
+
+// IsDeny returns whether the visibility hides the plans it covers
+func (v *Visibility) IsDeny() bool {
+	return v.Effect == VisibilityDeny
+}
+
+// IsActive returns whether the visibility applies at the specified time according to its validity period
+func (v *Visibility) IsActive(at time.Time) bool {
+	if !v.ValidFrom.IsZero() && at.Before(v.ValidFrom) {
+		return false
+	}
+	return !v.IsExpired(at)
+}
+
+// IsExpired returns whether the visibility has expired at the specified time
+func (v *Visibility) IsExpired(at time.Time) bool {
+	return !v.ExpiresAt.IsZero() && !at.Before(v.ExpiresAt)
+}
+
+// Covers returns whether the visibility is for the plan or for the service offering of the plan
+func (v *Visibility) Covers(plan *ServicePlan) bool {
+	if v.ServiceOfferingID != "" {
+		return v.ServiceOfferingID == plan.ServiceOfferingID
+	}
+	return v.ServicePlanID == plan.ID
+}
+
+// VisibilityResolver determines the visibilities that apply to platforms. The platform selector of each visibility is
+// parsed once so that the visibilities can be resolved for any number of platforms.
+type VisibilityResolver struct {
+	visibilities []*selectedVisibility
+}
+
+type selectedVisibility struct {
+	*Visibility
+	selector []query.Criterion
+}
+
+// NewVisibilityResolver returns a resolver for the visibilities that are active at the specified time. Visibilities
+// with an invalid platform selector apply to no platform.
+func NewVisibilityResolver(visibilities []*Visibility, at time.Time) *VisibilityResolver {
+	resolver := &VisibilityResolver{
+		visibilities: make([]*selectedVisibility, 0, len(visibilities)),
+	}
+	for _, visibility := range visibilities {
+		if !visibility.IsActive(at) {
+			continue
+		}
+		var selector []query.Criterion
+		if visibility.PlatformSelector != "" {
+			var err error
+			if selector, err = query.Parse(query.LabelQuery, visibility.PlatformSelector); err != nil {
+				continue
+			}
+		}
+		resolver.visibilities = append(resolver.visibilities, &selectedVisibility{
+			Visibility: visibility,
+			selector:   selector,
+		})
+	}
+	return resolver
+}
+
+// Resolve returns the visibilities that apply to the platform. The labels of the platform are needed to evaluate the
+// platform selectors of the visibilities.
+func (r *VisibilityResolver) Resolve(platform *Platform) []*Visibility {
+	result := make([]*Visibility, 0)
+	for _, visibility := range r.visibilities {
+		if visibility.appliesTo(platform) {
+			result = append(result, visibility.Visibility)
+		}
+	}
+	return result
+}
+
+func (v *selectedVisibility) appliesTo(platform *Platform) bool {
+	if v.PlatformSelector == "" {
+		return v.PlatformID == "" || v.PlatformID == platform.ID
+	}
+	return query.MatchLabels(v.selector, platform.Labels)
+}
+
+// IsPlanVisible returns whether the visibilities make the plan visible. A deny visibility covering the plan takes
+// precedence over the visibilities allowing it.
+func IsPlanVisible(visibilities []*Visibility, plan *ServicePlan) bool {
+	visible := false
+	for _, visibility := range visibilities {
+		if !visibility.Covers(plan) {
+			continue
+		}
+		if visibility.IsDeny() {
+			return false
+		}
+		visible = true
+	}
+	return visible
+}
+
 // MarshalJSON override json serialization for http response
 func (v *Visibility) MarshalJSON() ([]byte, error) {
 	type V Visibility
@@ -62,6 +212,8 @@ func (v *Visibility) MarshalJSON() ([]byte, error) {
 		*V
 		CreatedAt *string `json:"created_at,omitempty"`
 		UpdatedAt *string `json:"updated_at,omitempty"`
+		ValidFrom *string `json:"valid_from,omitempty"`
+		ExpiresAt *string `json:"expires_at,omitempty"`
 	}{
 		V: (*V)(v),
 	}
@@ -73,6 +225,14 @@ func (v *Visibility) MarshalJSON() ([]byte, error) {
 		str := util.ToRFCFormat(v.UpdatedAt)
 		toMarshal.UpdatedAt = &str
 	}
+	if !v.ValidFrom.IsZero() {
+		str := util.ToRFCFormat(v.ValidFrom)
+		toMarshal.ValidFrom = &str
+	}
+	if !v.ExpiresAt.IsZero() {
+		str := util.ToRFCFormat(v.ExpiresAt)
+		toMarshal.ExpiresAt = &str
+	}
 
 	hasNoLabels := true
 	for key, values := range v.Labels {
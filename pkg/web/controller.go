@@ -29,6 +29,9 @@ type Route struct {
 
 	// Handler is the function that should handle incoming requests for this endpoint
 	Handler HandlerFunc
+
+	// OptionalBody allows requests that trigger an action on this endpoint to be sent without a body
+	OptionalBody bool
 }
 
 // Endpoint is a combination of a Path and an HTTP Method
@@ -18,6 +18,9 @@ const (
 	// PlatformsURL is the URL path to manage platforms
 	PlatformsURL = "/" + apiVersion + "/platforms"
 
+	// OperationsURL is the URL path to fetch operations
+	OperationsURL = "/" + apiVersion + "/operations"
+
 	// OSBURL is the OSB API base URL path
 	OSBURL = "/" + apiVersion + "/osb"
 
@@ -26,4 +29,13 @@ const (
 
 	// InfoURL is the path of the info endpoint
 	InfoURL = "/" + apiVersion + "/info"
+
+	// ReportsURL is the URL path to fetch reports
+	ReportsURL = "/" + apiVersion + "/reports"
 )
+
+// DryRunParam is the query parameter that requests the changes of an operation to be reported without being persisted
+const DryRunParam = "dry_run"
+
+// AsyncParam is the query parameter that requests an operation to be executed asynchronously
+const AsyncParam = "async"
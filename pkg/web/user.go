@@ -1,10 +1,21 @@
 package web
 
+// AuthenticationType is the type of authentication through which the user was identified
+type AuthenticationType string
+
+const (
+	// BasicAuthentication identifies users such as platforms through their basic credentials
+	BasicAuthentication AuthenticationType = "basic"
+	// BearerAuthentication identifies users through an OAuth token
+	BearerAuthentication AuthenticationType = "bearer"
+)
+
 // UserContext holds the information for the current user
 type UserContext struct {
 	Data
 
-	Name string
+	Name               string
+	AuthenticationType AuthenticationType
 }
 
 //go:generate counterfeiter . Data
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"path"
 	"runtime"
+	"time"
 
 	"github.com/Peripli/service-manager/pkg/query"
 
@@ -108,6 +109,21 @@ type Warehouse interface {
 
 	// Security provides access to encryption key management
 	Security() Security
+
+	// BrokerCatalog provides access to broker catalog history db operations
+	BrokerCatalog() BrokerCatalog
+
+	// Operation provides access to operation db operations
+	Operation() Operation
+
+	// CatalogOverride provides access to catalog override db operations
+	CatalogOverride() CatalogOverride
+
+	// AggregatedInstance provides access to the service instances of the aggregated broker
+	AggregatedInstance() AggregatedInstance
+
+	// AdvisoryLock provides access to cluster-wide locks shared by all Service Manager instances
+	AdvisoryLock() AdvisoryLock
 }
 
 // Repository is a storage warehouse that can initiate a transaction
@@ -152,6 +168,9 @@ type Platform interface {
 	// Get retrieves a platform using the provided id from SM DB
 	Get(ctx context.Context, id string) (*types.Platform, error)
 
+	// GetForUpdate retrieves a platform using the provided id from SM DB and locks it until the end of the transaction
+	GetForUpdate(ctx context.Context, id string) (*types.Platform, error)
+
 	// List retrieves all platforms from SM DB
 	List(ctx context.Context, criteria ...query.Criterion) ([]*types.Platform, error)
 
@@ -159,7 +178,62 @@ type Platform interface {
 	Delete(ctx context.Context, criteria ...query.Criterion) error
 
 	// Update updates a platform from SM DB
-	Update(ctx context.Context, platform *types.Platform) error
+	Update(ctx context.Context, platform *types.Platform, labelChanges ...*query.LabelChange) error
+}
+
+// BrokerCatalog interface for broker catalog snapshot db operations
+type BrokerCatalog interface {
+	// Create stores a broker catalog snapshot in SM DB
+	Create(ctx context.Context, brokerCatalog *types.BrokerCatalog) (string, error)
+
+	// List retrieves all broker catalog snapshots from SM DB ordered by version
+	List(ctx context.Context, criteria ...query.Criterion) ([]*types.BrokerCatalog, error)
+
+	// GetLatest retrieves the broker catalog snapshot with the highest version of the broker from SM DB
+	GetLatest(ctx context.Context, brokerID string) (*types.BrokerCatalog, error)
+
+	// Prune deletes all but the latest count broker catalog snapshots of the broker from SM DB.
+	// The snapshot with the retained version is kept regardless of its age.
+	Prune(ctx context.Context, brokerID string, count int, retainedVersion int64) error
+}
+
+// Operation interface for operation db operations
+type Operation interface {
+	// Create stores an operation in SM DB
+	Create(ctx context.Context, operation *types.Operation) (string, error)
+
+	// Get retrieves an operation using the provided id from SM DB
+	Get(ctx context.Context, id string) (*types.Operation, error)
+
+	// Update updates an operation in SM DB
+	Update(ctx context.Context, operation *types.Operation) error
+}
+
+// CatalogOverride interface for catalog override db operations
+type CatalogOverride interface {
+	// Create stores a catalog override in SM DB
+	Create(ctx context.Context, override *types.CatalogOverride) (string, error)
+
+	// List retrieves all catalog overrides from SM DB
+	List(ctx context.Context, criteria ...query.Criterion) ([]*types.CatalogOverride, error)
+
+	// Update updates a catalog override in SM DB
+	Update(ctx context.Context, override *types.CatalogOverride) error
+
+	// Delete deletes catalog overrides from SM DB
+	Delete(ctx context.Context, criteria ...query.Criterion) error
+}
+
+// AggregatedInstance interface for db operations on the service instances of the aggregated broker
+type AggregatedInstance interface {
+	// Create stores the broker of a service instance in SM DB
+	Create(ctx context.Context, instance *types.AggregatedInstance) (string, error)
+
+	// Get retrieves the broker of a service instance using the provided instance id from SM DB
+	Get(ctx context.Context, id string) (*types.AggregatedInstance, error)
+
+	// Delete deletes service instances from SM DB
+	Delete(ctx context.Context, criteria ...query.Criterion) error
 }
 
 // ServiceOffering instance for Service Offerings DB operations
@@ -218,6 +292,9 @@ type Visibility interface {
 
 	// Update updates a visibility from SM DB
 	Update(ctx context.Context, visibility *types.Visibility, labelChanges ...*query.LabelChange) error
+
+	// DeleteExpired deletes the visibilities that have expired at the specified time from SM DB and returns them
+	DeleteExpired(ctx context.Context, at time.Time) ([]*types.Visibility, error)
 }
 
 // Credentials interface for Credentials db operations
@@ -225,6 +302,9 @@ type Visibility interface {
 type Credentials interface {
 	// Get retrieves credentials using the provided username from SM DB
 	Get(ctx context.Context, username string) (*types.Credentials, error)
+
+	// UpdatePassword replaces the stored password of the credentials with the provided username in SM DB
+	UpdatePassword(ctx context.Context, username string, password string) error
 }
 
 // Security interface for encryption key operations
@@ -242,3 +322,16 @@ type Security interface {
 	// Setter provides means to change the encryption  key
 	Setter() security.KeySetter
 }
+
+// AdvisoryLock interface for cluster-wide lock operations
+type AdvisoryLock interface {
+	// TryLock attempts to acquire the lock identified by key without waiting.
+	// Returns true if the lock was acquired. The lock is held until the end of the current transaction
+	// so it should only be used in a transactional warehouse.
+	TryLock(ctx context.Context, key int64) (bool, error)
+
+	// TryLockSession attempts to acquire the lock identified by key without waiting and without a transaction.
+	// The lock is held on a dedicated connection until the returned release function is called. The release function
+	// is nil if the lock is held by someone else. It cannot be used in a transactional warehouse.
+	TryLockSession(ctx context.Context, key int64) (func(), error)
+}
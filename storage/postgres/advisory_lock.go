@@ -0,0 +1,82 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/jmoiron/sqlx"
+)
+
+type advisoryLockStorage struct {
+	db pgDB
+	// sessionDB is the connection pool from which session level locks take their connections. It is nil in a transaction.
+	sessionDB *sqlx.DB
+}
+
+// TryLock attempts to acquire a transaction level advisory lock identified by key.
+// Returns false if the lock is currently held by another transaction
+func (s *advisoryLockStorage) TryLock(ctx context.Context, key int64) (bool, error) {
+	sqlQuery := "SELECT pg_try_advisory_xact_lock($1)"
+	log.C(ctx).Debugf("Executing query %s", sqlQuery)
+	var locked bool
+	if err := s.db.GetContext(ctx, &locked, sqlQuery, key); err != nil {
+		return false, err
+	}
+	return locked, nil
+}
+
+// TryLockSession attempts to acquire a session level advisory lock identified by key on a dedicated connection.
+// Returns a nil release function if the lock is currently held by another session
+func (s *advisoryLockStorage) TryLockSession(ctx context.Context, key int64) (func(), error) {
+	if s.sessionDB == nil {
+		return nil, errors.New("session advisory locks cannot be acquired in a transaction")
+	}
+	conn, err := s.sessionDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlQuery := "SELECT pg_try_advisory_lock($1)"
+	log.C(ctx).Debugf("Executing query %s", sqlQuery)
+	var locked bool
+	if err := conn.QueryRowContext(ctx, sqlQuery, key).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !locked {
+		conn.Close()
+		return nil, nil
+	}
+
+	return func() {
+		unlockQuery := "SELECT pg_advisory_unlock($1)"
+		log.C(ctx).Debugf("Executing query %s", unlockQuery)
+		// the context of the lock may already be done so the lock is released independently of it
+		if _, err := conn.ExecContext(context.Background(), unlockQuery, key); err != nil {
+			log.C(ctx).WithError(err).Errorf("Could not release advisory lock %d. Discarding its connection", key)
+			// a connection returned to the pool would keep holding the lock, so it is discarded instead
+			conn.Raw(func(interface{}) error {
+				return driver.ErrBadConn
+			})
+		}
+		conn.Close()
+	}, nil
+}
@@ -0,0 +1,46 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+)
+
+type aggregatedInstanceStorage struct {
+	db pgDB
+}
+
+func (ais *aggregatedInstanceStorage) Create(ctx context.Context, instance *types.AggregatedInstance) (string, error) {
+	ai := &AggregatedInstance{}
+	ai.FromDTO(instance)
+	return create(ctx, ais.db, aggregatedInstanceTable, ai)
+}
+
+func (ais *aggregatedInstanceStorage) Get(ctx context.Context, id string) (*types.AggregatedInstance, error) {
+	instance := &AggregatedInstance{}
+	if err := get(ctx, ais.db, id, aggregatedInstanceTable, instance); err != nil {
+		return nil, err
+	}
+	return instance.ToDTO(), nil
+}
+
+func (ais *aggregatedInstanceStorage) Delete(ctx context.Context, criteria ...query.Criterion) error {
+	return deleteAllByFieldCriteria(ctx, ais.db, aggregatedInstanceTable, AggregatedInstance{}, criteria)
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+)
+
+type brokerCatalogStorage struct {
+	db pgDB
+}
+
+func (bcs *brokerCatalogStorage) Create(ctx context.Context, brokerCatalog *types.BrokerCatalog) (string, error) {
+	bc := &BrokerCatalog{}
+	bc.FromDTO(brokerCatalog)
+	return create(ctx, bcs.db, brokerCatalogTable, bc)
+}
+
+func (bcs *brokerCatalogStorage) List(ctx context.Context, criteria ...query.Criterion) ([]*types.BrokerCatalog, error) {
+	var brokerCatalogs []BrokerCatalog
+	if err := validateFieldQueryParams(BrokerCatalog{}, criteria); err != nil {
+		return nil, err
+	}
+	baseQuery := fmt.Sprintf(`SELECT * FROM %s`, brokerCatalogTable)
+	sqlQuery, queryParams, err := buildQueryWithParams(bcs.db, baseQuery, brokerCatalogTable, nil, criteria)
+	if err != nil {
+		return nil, err
+	}
+	sqlQuery = strings.TrimSuffix(sqlQuery, ";") + " ORDER BY version;"
+	log.C(ctx).Debugf("Executing query %s", sqlQuery)
+	if err := bcs.db.SelectContext(ctx, &brokerCatalogs, sqlQuery, queryParams...); err != nil || len(brokerCatalogs) == 0 {
+		return []*types.BrokerCatalog{}, err
+	}
+	brokerCatalogDTOs := make([]*types.BrokerCatalog, 0, len(brokerCatalogs))
+	for _, bc := range brokerCatalogs {
+		brokerCatalogDTOs = append(brokerCatalogDTOs, bc.ToDTO())
+	}
+	return brokerCatalogDTOs, nil
+}
+
+func (bcs *brokerCatalogStorage) GetLatest(ctx context.Context, brokerID string) (*types.BrokerCatalog, error) {
+	sqlQuery := fmt.Sprintf(`SELECT * FROM %s WHERE broker_id = $1 ORDER BY version DESC LIMIT 1`, brokerCatalogTable)
+	log.C(ctx).Debugf("Executing query %s", sqlQuery)
+	brokerCatalog := &BrokerCatalog{}
+	if err := bcs.db.GetContext(ctx, brokerCatalog, sqlQuery, brokerID); err != nil {
+		return nil, checkSQLNoRows(err)
+	}
+	return brokerCatalog.ToDTO(), nil
+}
+
+func (bcs *brokerCatalogStorage) Prune(ctx context.Context, brokerID string, count int, retainedVersion int64) error {
+	sqlQuery := fmt.Sprintf(`DELETE FROM %[1]s WHERE broker_id = $1 AND version <> $3 AND version <=
+		(SELECT MAX(version) FROM %[1]s WHERE broker_id = $1) - $2`, brokerCatalogTable)
+	log.C(ctx).Debugf("Executing query %s", sqlQuery)
+	_, err := bcs.db.ExecContext(ctx, sqlQuery, brokerID, count, retainedVersion)
+	return err
+}
@@ -0,0 +1,60 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+)
+
+type catalogOverrideStorage struct {
+	db pgDB
+}
+
+func (cos *catalogOverrideStorage) Create(ctx context.Context, override *types.CatalogOverride) (string, error) {
+	co := &CatalogOverride{}
+	co.FromDTO(override)
+	return create(ctx, cos.db, catalogOverrideTable, co)
+}
+
+func (cos *catalogOverrideStorage) List(ctx context.Context, criteria ...query.Criterion) ([]*types.CatalogOverride, error) {
+	var overrides []CatalogOverride
+	if err := validateFieldQueryParams(CatalogOverride{}, criteria); err != nil {
+		return nil, err
+	}
+	err := listByFieldCriteria(ctx, cos.db, catalogOverrideTable, &overrides, criteria)
+	if err != nil || len(overrides) == 0 {
+		return []*types.CatalogOverride{}, err
+	}
+	overrideDTOs := make([]*types.CatalogOverride, 0, len(overrides))
+	for _, co := range overrides {
+		overrideDTOs = append(overrideDTOs, co.ToDTO())
+	}
+	return overrideDTOs, nil
+}
+
+func (cos *catalogOverrideStorage) Update(ctx context.Context, override *types.CatalogOverride) error {
+	co := &CatalogOverride{}
+	co.FromDTO(override)
+	return update(ctx, cos.db, catalogOverrideTable, co)
+}
+
+func (cos *catalogOverrideStorage) Delete(ctx context.Context, criteria ...query.Criterion) error {
+	return deleteAllByFieldCriteria(ctx, cos.db, catalogOverrideTable, CatalogOverride{}, criteria)
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/Peripli/service-manager/pkg/log"
 	"github.com/Peripli/service-manager/pkg/types"
@@ -31,24 +32,44 @@ type credentialStorage struct {
 
 func (cs *credentialStorage) Get(ctx context.Context, username string) (*types.Credentials, error) {
 	platform := &Platform{}
-	query := fmt.Sprintf("SELECT * FROM %s WHERE username=$1", platformTable)
+	// the previous credentials of a platform remain valid until they expire after a credentials rotation
+	query := fmt.Sprintf(`SELECT * FROM %s WHERE username=$1
+		OR (previous_username=$1 AND previous_credentials_expire_at > $2)`, platformTable)
 	log.C(ctx).Debugf("Executing query %s", query)
 
-	err := cs.db.GetContext(ctx, platform, query, username)
+	err := cs.db.GetContext(ctx, platform, query, username, time.Now().UTC())
 
 	if err != nil {
 		return nil, checkSQLNoRows(err)
 	}
 
+	password := platform.Password
+	if platform.Username != username {
+		password = platform.PreviousPassword.String
+	}
 	bytes, err := json.Marshal(platform.ToDTO())
 	if err != nil {
 		return nil, err
 	}
 	return &types.Credentials{
 		Basic: &types.Basic{
-			Username: platform.Username,
-			Password: platform.Password,
+			Username: username,
+			Password: password,
 		},
 		Details: bytes,
 	}, nil
 }
+
+func (cs *credentialStorage) UpdatePassword(ctx context.Context, username string, password string) error {
+	query := fmt.Sprintf(`UPDATE %s SET
+		password = CASE WHEN username=$1 THEN $2 ELSE password END,
+		previous_password = CASE WHEN previous_username=$1 THEN $2 ELSE previous_password END
+		WHERE username=$1 OR previous_username=$1`, platformTable)
+	log.C(ctx).Debugf("Executing query %s", query)
+
+	result, err := cs.db.ExecContext(ctx, query, username, password)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(ctx, result)
+}
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE brokers DROP COLUMN IF EXISTS catalog_synced_at;
+ALTER TABLE brokers DROP COLUMN IF EXISTS catalog_sync_error;
+ALTER TABLE brokers DROP COLUMN IF EXISTS catalog_sync_failed_at;
+
+COMMIT;
\ No newline at end of file
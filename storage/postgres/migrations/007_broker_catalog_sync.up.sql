@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE brokers ADD COLUMN catalog_synced_at timestamp;
+ALTER TABLE brokers ADD COLUMN catalog_sync_failed_at timestamp;
+ALTER TABLE brokers ADD COLUMN catalog_sync_error text;
+
+COMMIT;
\ No newline at end of file
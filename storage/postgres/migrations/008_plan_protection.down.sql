@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE service_plans DROP COLUMN IF EXISTS active;
+ALTER TABLE service_plans DROP COLUMN IF EXISTS deactivated_at;
+
+COMMIT;
\ No newline at end of file
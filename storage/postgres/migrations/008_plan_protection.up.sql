@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE service_plans ADD COLUMN active boolean NOT NULL DEFAULT true;
+ALTER TABLE service_plans ADD COLUMN deactivated_at timestamp;
+
+COMMIT;
\ No newline at end of file
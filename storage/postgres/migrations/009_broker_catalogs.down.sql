@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE brokers DROP COLUMN IF EXISTS pinned_catalog_version;
+
+DROP TABLE IF EXISTS broker_catalogs;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,15 @@
+BEGIN;
+
+CREATE TABLE broker_catalogs
+(
+  id         varchar(100) PRIMARY KEY,
+  broker_id  varchar(100) NOT NULL REFERENCES brokers (id) ON DELETE CASCADE,
+  version    integer      NOT NULL,
+  catalog    json         NOT NULL,
+  created_at timestamp    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (broker_id, version)
+);
+
+ALTER TABLE brokers ADD COLUMN pinned_catalog_version integer;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,15 @@
+BEGIN;
+
+CREATE TABLE operations
+(
+  id            varchar(100) PRIMARY KEY,
+  type          varchar(255) NOT NULL,
+  state         varchar(255) NOT NULL,
+  resource_id   varchar(100),
+  resource_type varchar(255) NOT NULL,
+  errors        json,
+  created_at    timestamp    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at    timestamp    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMIT;
\ No newline at end of file
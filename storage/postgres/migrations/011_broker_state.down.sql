@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE brokers DROP COLUMN IF EXISTS state;
+
+COMMIT;
\ No newline at end of file
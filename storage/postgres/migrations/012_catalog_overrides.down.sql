@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS catalog_overrides;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,18 @@
+BEGIN;
+
+CREATE TABLE catalog_overrides
+(
+  id                 varchar(100) PRIMARY KEY,
+  broker_id          varchar(100) NOT NULL REFERENCES brokers(id) ON DELETE CASCADE,
+  service_catalog_id varchar(255) NOT NULL,
+  plan_catalog_id    varchar(255) NOT NULL DEFAULT '',
+  display_name       varchar(255),
+  description        text,
+  hidden             boolean      NOT NULL DEFAULT false,
+  metadata           json,
+  created_at         timestamp    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at         timestamp    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (broker_id, service_catalog_id, plan_catalog_id)
+);
+
+COMMIT;
\ No newline at end of file
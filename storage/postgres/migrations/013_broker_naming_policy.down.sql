@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE brokers DROP COLUMN IF EXISTS naming_policy;
+
+COMMIT;
\ No newline at end of file
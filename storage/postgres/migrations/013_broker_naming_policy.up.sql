@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE brokers ADD COLUMN naming_policy json;
+
+COMMIT;
\ No newline at end of file
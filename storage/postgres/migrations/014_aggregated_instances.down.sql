@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS aggregated_instances;
+
+COMMIT;
\ No newline at end of file
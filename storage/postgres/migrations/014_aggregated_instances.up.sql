@@ -0,0 +1,13 @@
+BEGIN;
+
+CREATE TABLE aggregated_instances
+(
+  id              varchar(100) PRIMARY KEY,
+  broker_id       varchar(100) NOT NULL REFERENCES brokers(id) ON DELETE CASCADE,
+  platform_id     varchar(100) NOT NULL REFERENCES platforms(id) ON DELETE CASCADE,
+  service_plan_id varchar(100) NOT NULL,
+  created_at      timestamp    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at      timestamp    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMIT;
\ No newline at end of file
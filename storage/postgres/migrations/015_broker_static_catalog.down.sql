@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE brokers DROP COLUMN IF EXISTS static_catalog;
+
+COMMIT;
\ No newline at end of file
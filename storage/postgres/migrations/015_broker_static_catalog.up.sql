@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE brokers ADD COLUMN static_catalog json;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE brokers ADD COLUMN catalog_etag varchar(255);
+
+COMMIT;
\ No newline at end of file
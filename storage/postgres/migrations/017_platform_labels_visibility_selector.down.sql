@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE visibilities DROP COLUMN IF EXISTS platform_selector;
+
+DROP TABLE IF EXISTS platform_labels;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,16 @@
+BEGIN;
+
+CREATE TABLE platform_labels
+(
+  id            varchar(100) PRIMARY KEY,
+  key           varchar(255) NOT NULL CHECK (key <> ''),
+  val           varchar(255) NOT NULL CHECK (val <> ''),
+  platform_id   varchar(100) NOT NULL REFERENCES platforms (id) ON DELETE CASCADE,
+  created_at    timestamp    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at    timestamp    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (key, val, platform_id)
+);
+
+ALTER TABLE visibilities ADD COLUMN platform_selector text;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,36 @@
+BEGIN;
+
+ALTER TABLE visibilities DROP CONSTRAINT IF EXISTS unique_public_plan_visibility;
+DROP FUNCTION IF EXISTS check_unique_public_plan(varchar, varchar, varchar, varchar, text);
+
+DELETE FROM visibilities WHERE service_offering_id IS NOT NULL;
+ALTER TABLE visibilities DROP CONSTRAINT IF EXISTS visibilities_platform_id_service_offering_id_key;
+ALTER TABLE visibilities DROP CONSTRAINT IF EXISTS visibility_plan_or_offering;
+ALTER TABLE visibilities ALTER COLUMN service_plan_id SET NOT NULL;
+ALTER TABLE visibilities DROP COLUMN IF EXISTS service_offering_id;
+
+CREATE OR REPLACE FUNCTION check_unique_public_plan(spid varchar, pid varchar)
+   RETURNS boolean AS
+   $$
+      DECLARE
+      i int;
+      BEGIN
+         SELECT COUNT(*) INTO i FROM visibilities WHERE service_plan_id = spid AND platform_id IS NULL;
+         IF (i > 0) THEN
+            RETURN false;
+         END IF;
+
+         IF (pid IS NULL) THEN
+            SELECT COUNT(*) INTO i FROM visibilities WHERE service_plan_id = spid AND platform_id IS NOT NULL;
+            IF (i > 0) THEN
+               RETURN false;
+            END IF;
+         END IF;
+
+         RETURN true;
+      END
+   $$ LANGUAGE plpgsql;
+
+ALTER TABLE visibilities ADD CONSTRAINT unique_public_plan_visibility CHECK (check_unique_public_plan(service_plan_id, platform_id));
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,43 @@
+BEGIN;
+
+ALTER TABLE visibilities ADD COLUMN service_offering_id varchar(100) REFERENCES service_offerings(id) ON DELETE CASCADE;
+ALTER TABLE visibilities ALTER COLUMN service_plan_id DROP NOT NULL;
+ALTER TABLE visibilities ADD CONSTRAINT visibility_plan_or_offering CHECK ((service_plan_id IS NULL) <> (service_offering_id IS NULL));
+ALTER TABLE visibilities ADD CONSTRAINT visibilities_platform_id_service_offering_id_key UNIQUE (platform_id, service_offering_id);
+
+ALTER TABLE visibilities DROP CONSTRAINT unique_public_plan_visibility;
+DROP FUNCTION check_unique_public_plan(varchar, varchar);
+
+-- a public visibility is one without platform and platform selector. A public visibility of a plan or of its service
+-- offering makes any other visibility of the plan redundant and a public visibility cannot be added next to other
+-- visibilities of the same plan or service offering.
+CREATE OR REPLACE FUNCTION check_unique_public_plan(vid varchar, spid varchar, soid varchar, pid varchar, selector text)
+   RETURNS boolean AS
+   $$
+      DECLARE
+      i int;
+      BEGIN
+         SELECT COUNT(*) INTO i FROM visibilities
+            WHERE id <> vid AND platform_id IS NULL AND platform_selector IS NULL
+            AND (service_plan_id = spid OR service_offering_id = soid
+               OR service_offering_id = (SELECT service_offering_id FROM service_plans WHERE id = spid));
+         IF (i > 0) THEN
+            RETURN false;
+         END IF;
+
+         IF (pid IS NULL AND selector IS NULL) THEN
+            SELECT COUNT(*) INTO i FROM visibilities
+               WHERE id <> vid AND (service_plan_id = spid OR service_offering_id = soid
+                  OR service_plan_id IN (SELECT id FROM service_plans WHERE service_offering_id = soid));
+            IF (i > 0) THEN
+               RETURN false;
+            END IF;
+         END IF;
+
+         RETURN true;
+      END
+   $$ LANGUAGE plpgsql;
+
+ALTER TABLE visibilities ADD CONSTRAINT unique_public_plan_visibility CHECK (check_unique_public_plan(id, service_plan_id, service_offering_id, platform_id, platform_selector));
+
+COMMIT;
\ No newline at end of file
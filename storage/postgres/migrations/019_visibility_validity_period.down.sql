@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE visibilities DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE visibilities DROP COLUMN IF EXISTS valid_from;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE visibilities ADD COLUMN valid_from timestamp;
+ALTER TABLE visibilities ADD COLUMN expires_at timestamp;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,40 @@
+BEGIN;
+
+DELETE FROM visibilities WHERE effect = 'deny';
+
+ALTER TABLE visibilities DROP CONSTRAINT unique_public_plan_visibility;
+DROP FUNCTION check_unique_public_plan(varchar, varchar, varchar, varchar, text, varchar);
+
+CREATE OR REPLACE FUNCTION check_unique_public_plan(vid varchar, spid varchar, soid varchar, pid varchar, selector text)
+   RETURNS boolean AS
+   $$
+      DECLARE
+      i int;
+      BEGIN
+         SELECT COUNT(*) INTO i FROM visibilities
+            WHERE id <> vid AND platform_id IS NULL AND platform_selector IS NULL
+            AND (service_plan_id = spid OR service_offering_id = soid
+               OR service_offering_id = (SELECT service_offering_id FROM service_plans WHERE id = spid));
+         IF (i > 0) THEN
+            RETURN false;
+         END IF;
+
+         IF (pid IS NULL AND selector IS NULL) THEN
+            SELECT COUNT(*) INTO i FROM visibilities
+               WHERE id <> vid AND (service_plan_id = spid OR service_offering_id = soid
+                  OR service_plan_id IN (SELECT id FROM service_plans WHERE service_offering_id = soid));
+            IF (i > 0) THEN
+               RETURN false;
+            END IF;
+         END IF;
+
+         RETURN true;
+      END
+   $$ LANGUAGE plpgsql;
+
+ALTER TABLE visibilities ADD CONSTRAINT unique_public_plan_visibility CHECK (check_unique_public_plan(id, service_plan_id, service_offering_id, platform_id, platform_selector));
+
+ALTER TABLE visibilities DROP CONSTRAINT IF EXISTS deny_visibility_platform;
+ALTER TABLE visibilities DROP COLUMN IF EXISTS effect;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,46 @@
+BEGIN;
+
+ALTER TABLE visibilities ADD COLUMN effect varchar(10) NOT NULL DEFAULT 'allow' CHECK (effect IN ('allow', 'deny'));
+ALTER TABLE visibilities ADD CONSTRAINT deny_visibility_platform CHECK (effect = 'allow' OR platform_id IS NOT NULL OR platform_selector IS NOT NULL);
+
+ALTER TABLE visibilities DROP CONSTRAINT unique_public_plan_visibility;
+DROP FUNCTION check_unique_public_plan(varchar, varchar, varchar, varchar, text);
+
+-- a public visibility is one without platform and platform selector. A public visibility of a plan or of its service
+-- offering makes any other allowing visibility of the plan redundant and a public visibility cannot be added next to
+-- other allowing visibilities of the same plan or service offering. Deny visibilities exclude platforms from public
+-- plans and may exist next to public visibilities.
+CREATE OR REPLACE FUNCTION check_unique_public_plan(vid varchar, spid varchar, soid varchar, pid varchar, selector text, eff varchar)
+   RETURNS boolean AS
+   $$
+      DECLARE
+      i int;
+      BEGIN
+         IF (eff = 'deny') THEN
+            RETURN true;
+         END IF;
+
+         SELECT COUNT(*) INTO i FROM visibilities
+            WHERE id <> vid AND platform_id IS NULL AND platform_selector IS NULL
+            AND (service_plan_id = spid OR service_offering_id = soid
+               OR service_offering_id = (SELECT service_offering_id FROM service_plans WHERE id = spid));
+         IF (i > 0) THEN
+            RETURN false;
+         END IF;
+
+         IF (pid IS NULL AND selector IS NULL) THEN
+            SELECT COUNT(*) INTO i FROM visibilities
+               WHERE id <> vid AND effect <> 'deny' AND (service_plan_id = spid OR service_offering_id = soid
+                  OR service_plan_id IN (SELECT id FROM service_plans WHERE service_offering_id = soid));
+            IF (i > 0) THEN
+               RETURN false;
+            END IF;
+         END IF;
+
+         RETURN true;
+      END
+   $$ LANGUAGE plpgsql;
+
+ALTER TABLE visibilities ADD CONSTRAINT unique_public_plan_visibility CHECK (check_unique_public_plan(id, service_plan_id, service_offering_id, platform_id, platform_selector, effect));
+
+COMMIT;
\ No newline at end of file
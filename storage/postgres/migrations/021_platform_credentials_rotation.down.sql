@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE platforms DROP COLUMN IF EXISTS previous_credentials_expire_at;
+ALTER TABLE platforms DROP COLUMN IF EXISTS previous_password;
+ALTER TABLE platforms DROP COLUMN IF EXISTS previous_username;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE platforms ADD COLUMN previous_username varchar(255) UNIQUE;
+ALTER TABLE platforms ADD COLUMN previous_password varchar(500);
+ALTER TABLE platforms ADD COLUMN previous_credentials_expire_at timestamp;
+
+COMMIT;
\ No newline at end of file
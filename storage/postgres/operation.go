@@ -0,0 +1,47 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/Peripli/service-manager/pkg/types"
+)
+
+type operationStorage struct {
+	db pgDB
+}
+
+func (ops *operationStorage) Create(ctx context.Context, operation *types.Operation) (string, error) {
+	o := &Operation{}
+	o.FromDTO(operation)
+	return create(ctx, ops.db, operationTable, o)
+}
+
+func (ops *operationStorage) Get(ctx context.Context, id string) (*types.Operation, error) {
+	operation := &Operation{}
+	if err := get(ctx, ops.db, id, operationTable, operation); err != nil {
+		return nil, err
+	}
+	return operation.ToDTO(), nil
+}
+
+func (ops *operationStorage) Update(ctx context.Context, operation *types.Operation) error {
+	o := &Operation{}
+	o.FromDTO(operation)
+	return update(ctx, ops.db, operationTable, o)
+}
@@ -18,6 +18,12 @@ package postgres
 
 import (
 	"context"
+	"fmt"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/log"
+
+	"github.com/Peripli/service-manager/pkg/util"
 
 	"github.com/Peripli/service-manager/pkg/query"
 
@@ -31,39 +37,132 @@ type platformStorage struct {
 func (ps *platformStorage) Create(ctx context.Context, platform *types.Platform) (string, error) {
 	p := &Platform{}
 	p.FromDTO(platform)
-	return create(ctx, ps.db, platformTable, p)
+	id, err := create(ctx, ps.db, platformTable, p)
+	if err != nil {
+		return "", err
+	}
+	return id, ps.createLabels(ctx, id, platform.Labels)
+}
+
+func (ps *platformStorage) createLabels(ctx context.Context, platformID string, labels types.Labels) error {
+	pls := platformLabels{}
+	if err := pls.FromDTO(platformID, labels); err != nil {
+		return err
+	}
+	if err := pls.Validate(); err != nil {
+		return err
+	}
+	for _, label := range pls {
+		if _, err := create(ctx, ps.db, platformLabelsTable, label); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (ps *platformStorage) Get(ctx context.Context, id string) (*types.Platform, error) {
-	platform := &Platform{}
-	if err := get(ctx, ps.db, id, platformTable, platform); err != nil {
+	byID := query.ByField(query.EqualsOperator, "id", id)
+	platforms, err := ps.List(ctx, byID)
+	if err != nil {
 		return nil, err
 	}
-	return platform.ToDTO(), nil
+	if len(platforms) == 0 {
+		return nil, util.ErrNotFoundInStorage
+	}
+	return platforms[0], nil
+}
+
+func (ps *platformStorage) GetForUpdate(ctx context.Context, id string) (*types.Platform, error) {
+	sqlQuery := fmt.Sprintf(`SELECT id FROM %s WHERE id = $1 FOR UPDATE`, platformTable)
+	log.C(ctx).Debugf("Executing query %s", sqlQuery)
+	lockedIDs := make([]string, 0)
+	if err := ps.db.SelectContext(ctx, &lockedIDs, sqlQuery, id); err != nil {
+		return nil, err
+	}
+	if len(lockedIDs) == 0 {
+		return nil, util.ErrNotFoundInStorage
+	}
+	return ps.Get(ctx, id)
 }
 
 func (ps *platformStorage) List(ctx context.Context, criteria ...query.Criterion) ([]*types.Platform, error) {
-	var platforms []Platform
-	if err := validateFieldQueryParams(Platform{}, criteria); err != nil {
+	rows, err := listWithLabelsByCriteria(ctx, ps.db, Platform{}, &PlatformLabel{}, platformTable, criteria)
+	defer func() {
+		if rows == nil {
+			return
+		}
+		if err := rows.Close(); err != nil {
+			log.C(ctx).Errorf("Could not release connection when checking database. Error: %s", err)
+		}
+	}()
+	if err != nil {
 		return nil, err
 	}
-	err := listByFieldCriteria(ctx, ps.db, platformTable, &platforms, criteria)
-	if err != nil || len(platforms) == 0 {
-		return []*types.Platform{}, err
+
+	platforms := make(map[string]*types.Platform)
+	labels := make(map[string]map[string][]string)
+	result := make([]*types.Platform, 0)
+	for rows.Next() {
+		row := struct {
+			*Platform
+			*PlatformLabel `db:"platform_labels"`
+		}{}
+		if err := rows.StructScan(&row); err != nil {
+			return nil, err
+		}
+		platform, ok := platforms[row.Platform.ID]
+		if !ok {
+			platform = row.Platform.ToDTO()
+			platforms[row.Platform.ID] = platform
+			result = append(result, platform)
+		}
+		if labels[platform.ID] == nil {
+			labels[platform.ID] = make(map[string][]string)
+		}
+		labels[platform.ID][row.PlatformLabel.Key.String] = append(labels[platform.ID][row.PlatformLabel.Key.String], row.PlatformLabel.Val.String)
 	}
-	var platformDTOs = make([]*types.Platform, 0, len(platforms))
-	for _, platform := range platforms {
-		platformDTOs = append(platformDTOs, platform.ToDTO())
+
+	for _, p := range result {
+		p.Labels = labels[p.ID]
 	}
-	return platformDTOs, nil
+
+	return result, nil
 }
 
 func (ps *platformStorage) Delete(ctx context.Context, criteria ...query.Criterion) error {
 	return deleteAllByFieldCriteria(ctx, ps.db, platformTable, Platform{}, criteria)
 }
 
-func (ps *platformStorage) Update(ctx context.Context, platform *types.Platform) error {
+func (ps *platformStorage) Update(ctx context.Context, platform *types.Platform, labelChanges ...*query.LabelChange) error {
 	p := &Platform{}
 	p.FromDTO(platform)
-	return update(ctx, ps.db, platformTable, p)
+	if err := update(ctx, ps.db, platformTable, p); err != nil {
+		return err
+	}
+	if err := ps.updateLabels(ctx, p.ID, labelChanges); err != nil {
+		return err
+	}
+	byPlatformID := query.ByField(query.EqualsOperator, "platform_id", p.ID)
+	var labels []*PlatformLabel
+	if err := listByFieldCriteria(ctx, ps.db, platformLabelsTable, &labels, []query.Criterion{byPlatformID}); err != nil {
+		return err
+	}
+	platformLabels := platformLabels(labels)
+	platform.Labels = platformLabels.ToDTO()
+	return nil
+}
+
+func (ps *platformStorage) updateLabels(ctx context.Context, platformID string, updateActions []*query.LabelChange) error {
+	now := time.Now()
+	newLabelFunc := func(labelID string, labelKey string, labelValue string) Labelable {
+		return &PlatformLabel{
+			ID:         toNullString(labelID),
+			Key:        toNullString(labelKey),
+			Val:        toNullString(labelValue),
+			PlatformID: toNullString(platformID),
+			CreatedAt:  &now,
+			UpdatedAt:  &now,
+		}
+	}
+	return updateLabelsAbstract(ctx, newLabelFunc, ps.db, platformID, updateActions)
 }
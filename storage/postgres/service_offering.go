@@ -75,7 +75,9 @@ func (sos *serviceOfferingStorage) ListWithServicePlansByBrokerID(ctx context.Co
 		%[2]s.catalog_name "%[2]s.catalog_name",
 		%[2]s.metadata "%[2]s.metadata",
 		%[2]s.schemas "%[2]s.schemas",
-		%[2]s.service_offering_id "%[2]s.service_offering_id"
+		%[2]s.service_offering_id "%[2]s.service_offering_id",
+		%[2]s.active "%[2]s.active",
+		%[2]s.deactivated_at "%[2]s.deactivated_at"
 	FROM %[1]s 
 	JOIN %[2]s ON %[1]s.id = %[2]s.service_offering_id
 	WHERE %[1]s.broker_id=$1;`, serviceOfferingTable, servicePlanTable)
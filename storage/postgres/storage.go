@@ -83,6 +83,31 @@ func (ts *transactionalWarehouse) Credentials() storage.Credentials {
 	return &credentialStorage{db: ts.tx}
 }
 
+func (ts *transactionalWarehouse) BrokerCatalog() storage.BrokerCatalog {
+	ts.checkOpen()
+	return &brokerCatalogStorage{db: ts.tx}
+}
+
+func (ts *transactionalWarehouse) Operation() storage.Operation {
+	ts.checkOpen()
+	return &operationStorage{db: ts.tx}
+}
+
+func (ts *transactionalWarehouse) CatalogOverride() storage.CatalogOverride {
+	ts.checkOpen()
+	return &catalogOverrideStorage{db: ts.tx}
+}
+
+func (ts *transactionalWarehouse) AggregatedInstance() storage.AggregatedInstance {
+	ts.checkOpen()
+	return &aggregatedInstanceStorage{db: ts.tx}
+}
+
+func (ts *transactionalWarehouse) AdvisoryLock() storage.AdvisoryLock {
+	ts.checkOpen()
+	return &advisoryLockStorage{db: ts.tx}
+}
+
 func (ts *transactionalWarehouse) checkOpen() {
 	if ts.tx == nil {
 		log.D().Panicln("Storage transaction is not present for transactional warehouse")
@@ -155,6 +180,31 @@ func (ps *postgresStorage) Security() storage.Security {
 	return &securityStorage{ps.db, ps.encryptionKey, false, &sync.Mutex{}}
 }
 
+func (ps *postgresStorage) BrokerCatalog() storage.BrokerCatalog {
+	ps.checkOpen()
+	return &brokerCatalogStorage{ps.db}
+}
+
+func (ps *postgresStorage) Operation() storage.Operation {
+	ps.checkOpen()
+	return &operationStorage{ps.db}
+}
+
+func (ps *postgresStorage) CatalogOverride() storage.CatalogOverride {
+	ps.checkOpen()
+	return &catalogOverrideStorage{ps.db}
+}
+
+func (ps *postgresStorage) AggregatedInstance() storage.AggregatedInstance {
+	ps.checkOpen()
+	return &aggregatedInstanceStorage{ps.db}
+}
+
+func (ps *postgresStorage) AdvisoryLock() storage.AdvisoryLock {
+	ps.checkOpen()
+	return &advisoryLockStorage{db: ps.db, sessionDB: ps.db}
+}
+
 func (ps *postgresStorage) Open(options *storage.Settings) error {
 	var err error
 	if err = options.Validate(); err != nil {
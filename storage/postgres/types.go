@@ -28,15 +28,22 @@ import (
 
 	"github.com/Peripli/service-manager/pkg/types"
 	sqlxtypes "github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
 )
 
 const (
 	// platformTable db table name for platforms
 	platformTable = "platforms"
 
+	// platformLabelsTable db table for platform labels
+	platformLabelsTable = "platform_labels"
+
 	// brokerTable db table name for brokers
 	brokerTable = "brokers"
 
+	// brokerCatalogTable db table for broker catalog snapshots
+	brokerCatalogTable = "broker_catalogs"
+
 	// brokerLabelsTable db table for broker labels
 	brokerLabelsTable = "broker_labels"
 
@@ -51,6 +58,15 @@ const (
 
 	// visibilityLabelsTable db table for visibilities table
 	visibilityLabelsTable = "visibility_labels"
+
+	// operationTable db table for operations
+	operationTable = "operations"
+
+	// catalogOverrideTable db table for catalog overrides
+	catalogOverrideTable = "catalog_overrides"
+
+	// aggregatedInstanceTable db table for the service instances of the aggregated broker
+	aggregatedInstanceTable = "aggregated_instances"
 )
 
 // Safe represents a secret entity
@@ -70,6 +86,10 @@ type Platform struct {
 	UpdatedAt   time.Time      `db:"updated_at"`
 	Username    string         `db:"username"`
 	Password    string         `db:"password"`
+
+	PreviousUsername            sql.NullString `db:"previous_username"`
+	PreviousPassword            sql.NullString `db:"previous_password"`
+	PreviousCredentialsExpireAt pq.NullTime    `db:"previous_credentials_expire_at"`
 }
 
 // Broker entity
@@ -82,6 +102,60 @@ type Broker struct {
 	BrokerURL   string         `db:"broker_url"`
 	Username    string         `db:"username"`
 	Password    string         `db:"password"`
+
+	CatalogSyncedAt     *time.Time     `db:"catalog_synced_at"`
+	CatalogSyncFailedAt pq.NullTime    `db:"catalog_sync_failed_at"`
+	CatalogSyncError    sql.NullString `db:"catalog_sync_error"`
+	CatalogETag         sql.NullString `db:"catalog_etag"`
+
+	PinnedCatalogVersion sql.NullInt64 `db:"pinned_catalog_version"`
+
+	State string `db:"state"`
+
+	NamingPolicy sql.NullString `db:"naming_policy"`
+
+	StaticCatalog sql.NullString `db:"static_catalog"`
+}
+
+type BrokerCatalog struct {
+	ID        string             `db:"id"`
+	BrokerID  string             `db:"broker_id"`
+	Version   int64              `db:"version"`
+	Catalog   sqlxtypes.JSONText `db:"catalog"`
+	CreatedAt time.Time          `db:"created_at"`
+}
+
+type Operation struct {
+	ID           string         `db:"id"`
+	Type         string         `db:"type"`
+	State        string         `db:"state"`
+	ResourceID   sql.NullString `db:"resource_id"`
+	ResourceType string         `db:"resource_type"`
+	Errors       sql.NullString `db:"errors"`
+	CreatedAt    time.Time      `db:"created_at"`
+	UpdatedAt    time.Time      `db:"updated_at"`
+}
+
+type CatalogOverride struct {
+	ID               string         `db:"id"`
+	BrokerID         string         `db:"broker_id"`
+	ServiceCatalogID string         `db:"service_catalog_id"`
+	PlanCatalogID    string         `db:"plan_catalog_id"`
+	DisplayName      sql.NullString `db:"display_name"`
+	Description      sql.NullString `db:"description"`
+	Hidden           bool           `db:"hidden"`
+	Metadata         sql.NullString `db:"metadata"`
+	CreatedAt        time.Time      `db:"created_at"`
+	UpdatedAt        time.Time      `db:"updated_at"`
+}
+
+type AggregatedInstance struct {
+	ID            string    `db:"id"`
+	BrokerID      string    `db:"broker_id"`
+	PlatformID    string    `db:"platform_id"`
+	ServicePlanID string    `db:"service_plan_id"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
 }
 
 type ServiceOffering struct {
@@ -122,14 +196,25 @@ type ServicePlan struct {
 	Schemas  sqlxtypes.JSONText `db:"schemas"`
 
 	ServiceOfferingID string `db:"service_offering_id"`
+
+	Active        bool        `db:"active"`
+	DeactivatedAt pq.NullTime `db:"deactivated_at"`
 }
 
 type Visibility struct {
 	ID            string         `db:"id"`
 	PlatformID    sql.NullString `db:"platform_id"`
-	ServicePlanID string         `db:"service_plan_id"`
+	ServicePlanID sql.NullString `db:"service_plan_id"`
 	CreatedAt     time.Time      `db:"created_at"`
 	UpdatedAt     time.Time      `db:"updated_at"`
+
+	PlatformSelector  sql.NullString `db:"platform_selector"`
+	ServiceOfferingID sql.NullString `db:"service_offering_id"`
+
+	ValidFrom pq.NullTime `db:"valid_from"`
+	ExpiresAt pq.NullTime `db:"expires_at"`
+
+	Effect string `db:"effect"`
 }
 
 // Labelable is an interface that entities that support can be labelled should implement
@@ -137,6 +222,72 @@ type Labelable interface {
 	Label() (labelTableName string, referenceColumnName string, primaryColumnName string)
 }
 
+type platformLabels []*PlatformLabel
+
+func (pls platformLabels) Validate() error {
+	pairs := make(map[string][]string)
+	for _, pl := range pls {
+		newKey := pl.Key.String
+		newValue := pl.Val.String
+		val, exists := pairs[newKey]
+		if exists && slice.StringsAnyEquals(val, newValue) {
+			return fmt.Errorf("duplicate label with key %s and value %s", newKey, newValue)
+		}
+		pairs[newKey] = append(pairs[newKey], newValue)
+	}
+	return nil
+}
+
+func (pls *platformLabels) FromDTO(platformID string, labels types.Labels) error {
+	now := time.Now()
+	for key, values := range labels {
+		for _, labelValue := range values {
+			UUID, err := uuid.NewV4()
+			if err != nil {
+				return fmt.Errorf("could not generate GUID for platform label: %s", err)
+			}
+			id := UUID.String()
+			pLabel := &PlatformLabel{
+				ID:         toNullString(id),
+				Key:        toNullString(key),
+				Val:        toNullString(labelValue),
+				CreatedAt:  &now,
+				UpdatedAt:  &now,
+				PlatformID: toNullString(platformID),
+			}
+			*pls = append(*pls, pLabel)
+		}
+	}
+	return nil
+}
+
+func (pls *platformLabels) ToDTO() types.Labels {
+	labelValues := make(map[string][]string)
+	for _, label := range *pls {
+		values, exists := labelValues[label.Key.String]
+		if exists {
+			labelValues[label.Key.String] = append(values, label.Val.String)
+		} else {
+			labelValues[label.Key.String] = []string{label.Val.String}
+		}
+	}
+	return labelValues
+}
+
+type PlatformLabel struct {
+	ID         sql.NullString `db:"id"`
+	Key        sql.NullString `db:"key"`
+	Val        sql.NullString `db:"val"`
+	CreatedAt  *time.Time     `db:"created_at"`
+	UpdatedAt  *time.Time     `db:"updated_at"`
+	PlatformID sql.NullString `db:"platform_id"`
+}
+
+func (pl *PlatformLabel) Label() (labelTableName string, referenceColumnName string, primaryColumnName string) {
+	labelTableName, referenceColumnName, primaryColumnName = platformLabelsTable, "platform_id", "id"
+	return
+}
+
 type brokerLabels []*BrokerLabel
 
 func (bls brokerLabels) Validate() error {
@@ -283,7 +434,26 @@ func (b *Broker) ToDTO() *types.Broker {
 				Password: b.Password,
 			},
 		},
-		Labels: make(map[string][]string),
+		CatalogSyncError:     b.CatalogSyncError.String,
+		CatalogETag:          b.CatalogETag.String,
+		PinnedCatalogVersion: b.PinnedCatalogVersion.Int64,
+		State:                types.BrokerState(b.State),
+		Labels:               make(map[string][]string),
+	}
+	if b.CatalogSyncedAt != nil {
+		broker.CatalogSyncedAt = *b.CatalogSyncedAt
+	}
+	if b.CatalogSyncFailedAt.Valid {
+		broker.CatalogSyncFailedAt = b.CatalogSyncFailedAt.Time
+	}
+	if b.NamingPolicy.Valid {
+		namingPolicy := &types.NamingPolicy{}
+		if err := json.Unmarshal([]byte(b.NamingPolicy.String), namingPolicy); err == nil {
+			broker.NamingPolicy = namingPolicy
+		}
+	}
+	if b.StaticCatalog.Valid {
+		broker.StaticCatalog = json.RawMessage(b.StaticCatalog.String)
 	}
 	return broker
 }
@@ -296,19 +466,143 @@ func (b *Broker) FromDTO(broker *types.Broker) {
 		BrokerURL:   broker.BrokerURL,
 		CreatedAt:   broker.CreatedAt,
 		UpdatedAt:   broker.UpdatedAt,
+
+		CatalogSyncFailedAt: pq.NullTime{Time: broker.CatalogSyncFailedAt, Valid: !broker.CatalogSyncFailedAt.IsZero()},
+		CatalogSyncError:    toNullString(broker.CatalogSyncError),
+		CatalogETag:         toNullString(broker.CatalogETag),
+
+		PinnedCatalogVersion: sql.NullInt64{Int64: broker.PinnedCatalogVersion, Valid: broker.PinnedCatalogVersion != 0},
+
+		State: string(broker.State),
 	}
 
+	if !broker.CatalogSyncedAt.IsZero() {
+		catalogSyncedAt := broker.CatalogSyncedAt
+		b.CatalogSyncedAt = &catalogSyncedAt
+	}
 	if broker.Description != "" {
 		b.Description.Valid = true
 	}
+	if broker.NamingPolicy != nil {
+		if namingPolicy, err := json.Marshal(broker.NamingPolicy); err == nil {
+			b.NamingPolicy = toNullString(string(namingPolicy))
+		}
+	}
+	if broker.HasStaticCatalog() {
+		b.StaticCatalog = toNullString(string(broker.StaticCatalog))
+	}
 	if broker.Credentials != nil && broker.Credentials.Basic != nil {
 		b.Username = broker.Credentials.Basic.Username
 		b.Password = broker.Credentials.Basic.Password
 	}
 }
 
+func (bc *BrokerCatalog) ToDTO() *types.BrokerCatalog {
+	return &types.BrokerCatalog{
+		ID:        bc.ID,
+		BrokerID:  bc.BrokerID,
+		Version:   bc.Version,
+		Catalog:   json.RawMessage(bc.Catalog),
+		CreatedAt: bc.CreatedAt,
+	}
+}
+
+func (bc *BrokerCatalog) FromDTO(brokerCatalog *types.BrokerCatalog) {
+	*bc = BrokerCatalog{
+		ID:        brokerCatalog.ID,
+		BrokerID:  brokerCatalog.BrokerID,
+		Version:   brokerCatalog.Version,
+		Catalog:   sqlxtypes.JSONText(brokerCatalog.Catalog),
+		CreatedAt: brokerCatalog.CreatedAt,
+	}
+}
+
+func (o *Operation) ToDTO() *types.Operation {
+	operation := &types.Operation{
+		ID:           o.ID,
+		Type:         types.OperationType(o.Type),
+		State:        types.OperationState(o.State),
+		ResourceID:   o.ResourceID.String,
+		ResourceType: o.ResourceType,
+		CreatedAt:    o.CreatedAt,
+		UpdatedAt:    o.UpdatedAt,
+	}
+	if o.Errors.Valid {
+		operation.Errors = json.RawMessage(o.Errors.String)
+	}
+	return operation
+}
+
+func (o *Operation) FromDTO(operation *types.Operation) {
+	*o = Operation{
+		ID:           operation.ID,
+		Type:         string(operation.Type),
+		State:        string(operation.State),
+		ResourceID:   toNullString(operation.ResourceID),
+		ResourceType: operation.ResourceType,
+		Errors:       toNullString(string(operation.Errors)),
+		CreatedAt:    operation.CreatedAt,
+		UpdatedAt:    operation.UpdatedAt,
+	}
+}
+
+func (co *CatalogOverride) ToDTO() *types.CatalogOverride {
+	override := &types.CatalogOverride{
+		ID:               co.ID,
+		BrokerID:         co.BrokerID,
+		ServiceCatalogID: co.ServiceCatalogID,
+		PlanCatalogID:    co.PlanCatalogID,
+		DisplayName:      co.DisplayName.String,
+		Description:      co.Description.String,
+		Hidden:           co.Hidden,
+		CreatedAt:        co.CreatedAt,
+		UpdatedAt:        co.UpdatedAt,
+	}
+	if co.Metadata.Valid {
+		override.Metadata = json.RawMessage(co.Metadata.String)
+	}
+	return override
+}
+
+func (co *CatalogOverride) FromDTO(override *types.CatalogOverride) {
+	*co = CatalogOverride{
+		ID:               override.ID,
+		BrokerID:         override.BrokerID,
+		ServiceCatalogID: override.ServiceCatalogID,
+		PlanCatalogID:    override.PlanCatalogID,
+		DisplayName:      toNullString(override.DisplayName),
+		Description:      toNullString(override.Description),
+		Hidden:           override.Hidden,
+		Metadata:         toNullString(string(override.Metadata)),
+		CreatedAt:        override.CreatedAt,
+		UpdatedAt:        override.UpdatedAt,
+	}
+}
+
+func (ai *AggregatedInstance) ToDTO() *types.AggregatedInstance {
+	return &types.AggregatedInstance{
+		ID:            ai.ID,
+		BrokerID:      ai.BrokerID,
+		PlatformID:    ai.PlatformID,
+		ServicePlanID: ai.ServicePlanID,
+		CreatedAt:     ai.CreatedAt,
+		UpdatedAt:     ai.UpdatedAt,
+	}
+}
+
+func (ai *AggregatedInstance) FromDTO(instance *types.AggregatedInstance) {
+	*ai = AggregatedInstance{
+		ID:            instance.ID,
+		BrokerID:      instance.BrokerID,
+		PlatformID:    instance.PlatformID,
+		ServicePlanID: instance.ServicePlanID,
+		CreatedAt:     instance.CreatedAt,
+		UpdatedAt:     instance.UpdatedAt,
+	}
+}
+
 func (p *Platform) ToDTO() *types.Platform {
-	return &types.Platform{
+	platform := &types.Platform{
 		ID:          p.ID,
 		Type:        p.Type,
 		Name:        p.Name,
@@ -321,7 +615,18 @@ func (p *Platform) ToDTO() *types.Platform {
 				Password: p.Password,
 			},
 		},
+		Labels: make(map[string][]string),
 	}
+	if p.PreviousUsername.Valid {
+		platform.PreviousCredentials = &types.Credentials{
+			Basic: &types.Basic{
+				Username: p.PreviousUsername.String,
+				Password: p.PreviousPassword.String,
+			},
+		}
+		platform.PreviousCredentialsExpireAt = p.PreviousCredentialsExpireAt.Time
+	}
+	return platform
 }
 
 func (p *Platform) FromDTO(platform *types.Platform) {
@@ -341,6 +646,14 @@ func (p *Platform) FromDTO(platform *types.Platform) {
 		p.Username = platform.Credentials.Basic.Username
 		p.Password = platform.Credentials.Basic.Password
 	}
+	if platform.PreviousCredentials != nil && platform.PreviousCredentials.Basic != nil {
+		p.PreviousUsername = toNullString(platform.PreviousCredentials.Basic.Username)
+		p.PreviousPassword = toNullString(platform.PreviousCredentials.Basic.Password)
+		p.PreviousCredentialsExpireAt = pq.NullTime{
+			Time:  platform.PreviousCredentialsExpireAt,
+			Valid: !platform.PreviousCredentialsExpireAt.IsZero(),
+		}
+	}
 }
 
 func (so *ServiceOffering) ToDTO() *types.ServiceOffering {
@@ -398,6 +711,8 @@ func (sp *ServicePlan) ToDTO() *types.ServicePlan {
 		Metadata:          getJSONRawMessage(sp.Metadata),
 		Schemas:           getJSONRawMessage(sp.Schemas),
 		ServiceOfferingID: sp.ServiceOfferingID,
+		Active:            sp.Active,
+		DeactivatedAt:     sp.DeactivatedAt.Time,
 	}
 }
 
@@ -416,6 +731,8 @@ func (sp *ServicePlan) FromDTO(plan *types.ServicePlan) {
 		Metadata:          getJSONText(plan.Metadata),
 		Schemas:           getJSONText(plan.Schemas),
 		ServiceOfferingID: plan.ServiceOfferingID,
+		Active:            plan.Active,
+		DeactivatedAt:     pq.NullTime{Time: plan.DeactivatedAt, Valid: !plan.DeactivatedAt.IsZero()},
 	}
 }
 
@@ -423,10 +740,18 @@ func (v *Visibility) ToDTO() *types.Visibility {
 	return &types.Visibility{
 		ID:            v.ID,
 		PlatformID:    v.PlatformID.String,
-		ServicePlanID: v.ServicePlanID,
+		ServicePlanID: v.ServicePlanID.String,
 		CreatedAt:     v.CreatedAt,
 		UpdatedAt:     v.UpdatedAt,
 		Labels:        make(map[string][]string),
+
+		PlatformSelector:  v.PlatformSelector.String,
+		ServiceOfferingID: v.ServiceOfferingID.String,
+
+		ValidFrom: v.ValidFrom.Time,
+		ExpiresAt: v.ExpiresAt.Time,
+
+		Effect: types.VisibilityEffect(v.Effect),
 	}
 }
 
@@ -435,9 +760,20 @@ func (v *Visibility) FromDTO(visibility *types.Visibility) {
 		ID: visibility.ID,
 		// API cannot send nulls right now and storage cannot store empty string for this column as it is FK
 		PlatformID:    toNullString(visibility.PlatformID),
-		ServicePlanID: visibility.ServicePlanID,
+		ServicePlanID: toNullString(visibility.ServicePlanID),
 		CreatedAt:     visibility.CreatedAt,
 		UpdatedAt:     visibility.UpdatedAt,
+
+		PlatformSelector:  toNullString(visibility.PlatformSelector),
+		ServiceOfferingID: toNullString(visibility.ServiceOfferingID),
+
+		ValidFrom: pq.NullTime{Time: visibility.ValidFrom, Valid: !visibility.ValidFrom.IsZero()},
+		ExpiresAt: pq.NullTime{Time: visibility.ExpiresAt, Valid: !visibility.ExpiresAt.IsZero()},
+
+		Effect: string(visibility.Effect),
+	}
+	if v.Effect == "" {
+		v.Effect = string(types.VisibilityAllow)
 	}
 }
 
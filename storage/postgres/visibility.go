@@ -18,6 +18,7 @@ package postgres
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/Peripli/service-manager/pkg/util"
@@ -119,6 +120,20 @@ func (vs *visibilityStorage) Delete(ctx context.Context, criteria ...query.Crite
 	return deleteAllByFieldCriteria(ctx, vs.db, visibilityTable, Visibility{}, criteria)
 }
 
+func (vs *visibilityStorage) DeleteExpired(ctx context.Context, at time.Time) ([]*types.Visibility, error) {
+	sqlQuery := fmt.Sprintf(`DELETE FROM %s WHERE expires_at <= $1 RETURNING *`, visibilityTable)
+	log.C(ctx).Debugf("Executing query %s", sqlQuery)
+	expired := make([]*Visibility, 0)
+	if err := vs.db.SelectContext(ctx, &expired, sqlQuery, at.UTC()); err != nil {
+		return nil, err
+	}
+	result := make([]*types.Visibility, 0, len(expired))
+	for _, visibility := range expired {
+		result = append(result, visibility.ToDTO())
+	}
+	return result, nil
+}
+
 func (vs *visibilityStorage) Update(ctx context.Context, visibility *types.Visibility, labelChanges ...*query.LabelChange) error {
 	v := &Visibility{}
 	v.FromDTO(visibility)
@@ -24,6 +24,19 @@ type FakeCredentials struct {
 		result1 *types.Credentials
 		result2 error
 	}
+	UpdatePasswordStub        func(ctx context.Context, username string, password string) error
+	updatePasswordMutex       sync.RWMutex
+	updatePasswordArgsForCall []struct {
+		ctx      context.Context
+		username string
+		password string
+	}
+	updatePasswordReturns struct {
+		result1 error
+	}
+	updatePasswordReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -80,11 +93,63 @@ func (fake *FakeCredentials) GetReturnsOnCall(i int, result1 *types.Credentials,
 	}{result1, result2}
 }
 
+func (fake *FakeCredentials) UpdatePassword(ctx context.Context, username string, password string) error {
+	fake.updatePasswordMutex.Lock()
+	ret, specificReturn := fake.updatePasswordReturnsOnCall[len(fake.updatePasswordArgsForCall)]
+	fake.updatePasswordArgsForCall = append(fake.updatePasswordArgsForCall, struct {
+		ctx      context.Context
+		username string
+		password string
+	}{ctx, username, password})
+	fake.recordInvocation("UpdatePassword", []interface{}{ctx, username, password})
+	fake.updatePasswordMutex.Unlock()
+	if fake.UpdatePasswordStub != nil {
+		return fake.UpdatePasswordStub(ctx, username, password)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.updatePasswordReturns.result1
+}
+
+func (fake *FakeCredentials) UpdatePasswordCallCount() int {
+	fake.updatePasswordMutex.RLock()
+	defer fake.updatePasswordMutex.RUnlock()
+	return len(fake.updatePasswordArgsForCall)
+}
+
+func (fake *FakeCredentials) UpdatePasswordArgsForCall(i int) (context.Context, string, string) {
+	fake.updatePasswordMutex.RLock()
+	defer fake.updatePasswordMutex.RUnlock()
+	return fake.updatePasswordArgsForCall[i].ctx, fake.updatePasswordArgsForCall[i].username, fake.updatePasswordArgsForCall[i].password
+}
+
+func (fake *FakeCredentials) UpdatePasswordReturns(result1 error) {
+	fake.UpdatePasswordStub = nil
+	fake.updatePasswordReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeCredentials) UpdatePasswordReturnsOnCall(i int, result1 error) {
+	fake.UpdatePasswordStub = nil
+	if fake.updatePasswordReturnsOnCall == nil {
+		fake.updatePasswordReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.updatePasswordReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeCredentials) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
 	fake.getMutex.RLock()
 	defer fake.getMutex.RUnlock()
+	fake.updatePasswordMutex.RLock()
+	defer fake.updatePasswordMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -101,6 +101,51 @@ type FakeStorage struct {
 	securityReturnsOnCall map[int]struct {
 		result1 storage.Security
 	}
+	AdvisoryLockStub        func() storage.AdvisoryLock
+	advisoryLockMutex       sync.RWMutex
+	advisoryLockArgsForCall []struct{}
+	advisoryLockReturns     struct {
+		result1 storage.AdvisoryLock
+	}
+	advisoryLockReturnsOnCall map[int]struct {
+		result1 storage.AdvisoryLock
+	}
+	BrokerCatalogStub        func() storage.BrokerCatalog
+	brokerCatalogMutex       sync.RWMutex
+	brokerCatalogArgsForCall []struct{}
+	brokerCatalogReturns     struct {
+		result1 storage.BrokerCatalog
+	}
+	brokerCatalogReturnsOnCall map[int]struct {
+		result1 storage.BrokerCatalog
+	}
+	OperationStub        func() storage.Operation
+	operationMutex       sync.RWMutex
+	operationArgsForCall []struct{}
+	operationReturns     struct {
+		result1 storage.Operation
+	}
+	operationReturnsOnCall map[int]struct {
+		result1 storage.Operation
+	}
+	CatalogOverrideStub        func() storage.CatalogOverride
+	catalogOverrideMutex       sync.RWMutex
+	catalogOverrideArgsForCall []struct{}
+	catalogOverrideReturns     struct {
+		result1 storage.CatalogOverride
+	}
+	catalogOverrideReturnsOnCall map[int]struct {
+		result1 storage.CatalogOverride
+	}
+	AggregatedInstanceStub        func() storage.AggregatedInstance
+	aggregatedInstanceMutex       sync.RWMutex
+	aggregatedInstanceArgsForCall []struct{}
+	aggregatedInstanceReturns     struct {
+		result1 storage.AggregatedInstance
+	}
+	aggregatedInstanceReturnsOnCall map[int]struct {
+		result1 storage.AggregatedInstance
+	}
 	InTransactionStub        func(ctx context.Context, f func(ctx context.Context, storage storage.Warehouse) error) error
 	inTransactionMutex       sync.RWMutex
 	inTransactionArgsForCall []struct {
@@ -525,6 +570,206 @@ func (fake *FakeStorage) SecurityReturnsOnCall(i int, result1 storage.Security)
 	}{result1}
 }
 
+func (fake *FakeStorage) AdvisoryLock() storage.AdvisoryLock {
+	fake.advisoryLockMutex.Lock()
+	ret, specificReturn := fake.advisoryLockReturnsOnCall[len(fake.advisoryLockArgsForCall)]
+	fake.advisoryLockArgsForCall = append(fake.advisoryLockArgsForCall, struct{}{})
+	fake.recordInvocation("AdvisoryLock", []interface{}{})
+	fake.advisoryLockMutex.Unlock()
+	if fake.AdvisoryLockStub != nil {
+		return fake.AdvisoryLockStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.advisoryLockReturns.result1
+}
+
+func (fake *FakeStorage) AdvisoryLockCallCount() int {
+	fake.advisoryLockMutex.RLock()
+	defer fake.advisoryLockMutex.RUnlock()
+	return len(fake.advisoryLockArgsForCall)
+}
+
+func (fake *FakeStorage) AdvisoryLockReturns(result1 storage.AdvisoryLock) {
+	fake.AdvisoryLockStub = nil
+	fake.advisoryLockReturns = struct {
+		result1 storage.AdvisoryLock
+	}{result1}
+}
+
+func (fake *FakeStorage) AdvisoryLockReturnsOnCall(i int, result1 storage.AdvisoryLock) {
+	fake.AdvisoryLockStub = nil
+	if fake.advisoryLockReturnsOnCall == nil {
+		fake.advisoryLockReturnsOnCall = make(map[int]struct {
+			result1 storage.AdvisoryLock
+		})
+	}
+	fake.advisoryLockReturnsOnCall[i] = struct {
+		result1 storage.AdvisoryLock
+	}{result1}
+}
+
+func (fake *FakeStorage) BrokerCatalog() storage.BrokerCatalog {
+	fake.brokerCatalogMutex.Lock()
+	ret, specificReturn := fake.brokerCatalogReturnsOnCall[len(fake.brokerCatalogArgsForCall)]
+	fake.brokerCatalogArgsForCall = append(fake.brokerCatalogArgsForCall, struct{}{})
+	fake.recordInvocation("BrokerCatalog", []interface{}{})
+	fake.brokerCatalogMutex.Unlock()
+	if fake.BrokerCatalogStub != nil {
+		return fake.BrokerCatalogStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.brokerCatalogReturns.result1
+}
+
+func (fake *FakeStorage) BrokerCatalogCallCount() int {
+	fake.brokerCatalogMutex.RLock()
+	defer fake.brokerCatalogMutex.RUnlock()
+	return len(fake.brokerCatalogArgsForCall)
+}
+
+func (fake *FakeStorage) BrokerCatalogReturns(result1 storage.BrokerCatalog) {
+	fake.BrokerCatalogStub = nil
+	fake.brokerCatalogReturns = struct {
+		result1 storage.BrokerCatalog
+	}{result1}
+}
+
+func (fake *FakeStorage) BrokerCatalogReturnsOnCall(i int, result1 storage.BrokerCatalog) {
+	fake.BrokerCatalogStub = nil
+	if fake.brokerCatalogReturnsOnCall == nil {
+		fake.brokerCatalogReturnsOnCall = make(map[int]struct {
+			result1 storage.BrokerCatalog
+		})
+	}
+	fake.brokerCatalogReturnsOnCall[i] = struct {
+		result1 storage.BrokerCatalog
+	}{result1}
+}
+
+func (fake *FakeStorage) Operation() storage.Operation {
+	fake.operationMutex.Lock()
+	ret, specificReturn := fake.operationReturnsOnCall[len(fake.operationArgsForCall)]
+	fake.operationArgsForCall = append(fake.operationArgsForCall, struct{}{})
+	fake.recordInvocation("Operation", []interface{}{})
+	fake.operationMutex.Unlock()
+	if fake.OperationStub != nil {
+		return fake.OperationStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.operationReturns.result1
+}
+
+func (fake *FakeStorage) OperationCallCount() int {
+	fake.operationMutex.RLock()
+	defer fake.operationMutex.RUnlock()
+	return len(fake.operationArgsForCall)
+}
+
+func (fake *FakeStorage) OperationReturns(result1 storage.Operation) {
+	fake.OperationStub = nil
+	fake.operationReturns = struct {
+		result1 storage.Operation
+	}{result1}
+}
+
+func (fake *FakeStorage) OperationReturnsOnCall(i int, result1 storage.Operation) {
+	fake.OperationStub = nil
+	if fake.operationReturnsOnCall == nil {
+		fake.operationReturnsOnCall = make(map[int]struct {
+			result1 storage.Operation
+		})
+	}
+	fake.operationReturnsOnCall[i] = struct {
+		result1 storage.Operation
+	}{result1}
+}
+
+func (fake *FakeStorage) CatalogOverride() storage.CatalogOverride {
+	fake.catalogOverrideMutex.Lock()
+	ret, specificReturn := fake.catalogOverrideReturnsOnCall[len(fake.catalogOverrideArgsForCall)]
+	fake.catalogOverrideArgsForCall = append(fake.catalogOverrideArgsForCall, struct{}{})
+	fake.recordInvocation("CatalogOverride", []interface{}{})
+	fake.catalogOverrideMutex.Unlock()
+	if fake.CatalogOverrideStub != nil {
+		return fake.CatalogOverrideStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.catalogOverrideReturns.result1
+}
+
+func (fake *FakeStorage) CatalogOverrideCallCount() int {
+	fake.catalogOverrideMutex.RLock()
+	defer fake.catalogOverrideMutex.RUnlock()
+	return len(fake.catalogOverrideArgsForCall)
+}
+
+func (fake *FakeStorage) CatalogOverrideReturns(result1 storage.CatalogOverride) {
+	fake.CatalogOverrideStub = nil
+	fake.catalogOverrideReturns = struct {
+		result1 storage.CatalogOverride
+	}{result1}
+}
+
+func (fake *FakeStorage) CatalogOverrideReturnsOnCall(i int, result1 storage.CatalogOverride) {
+	fake.CatalogOverrideStub = nil
+	if fake.catalogOverrideReturnsOnCall == nil {
+		fake.catalogOverrideReturnsOnCall = make(map[int]struct {
+			result1 storage.CatalogOverride
+		})
+	}
+	fake.catalogOverrideReturnsOnCall[i] = struct {
+		result1 storage.CatalogOverride
+	}{result1}
+}
+
+func (fake *FakeStorage) AggregatedInstance() storage.AggregatedInstance {
+	fake.aggregatedInstanceMutex.Lock()
+	ret, specificReturn := fake.aggregatedInstanceReturnsOnCall[len(fake.aggregatedInstanceArgsForCall)]
+	fake.aggregatedInstanceArgsForCall = append(fake.aggregatedInstanceArgsForCall, struct{}{})
+	fake.recordInvocation("AggregatedInstance", []interface{}{})
+	fake.aggregatedInstanceMutex.Unlock()
+	if fake.AggregatedInstanceStub != nil {
+		return fake.AggregatedInstanceStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.aggregatedInstanceReturns.result1
+}
+
+func (fake *FakeStorage) AggregatedInstanceCallCount() int {
+	fake.aggregatedInstanceMutex.RLock()
+	defer fake.aggregatedInstanceMutex.RUnlock()
+	return len(fake.aggregatedInstanceArgsForCall)
+}
+
+func (fake *FakeStorage) AggregatedInstanceReturns(result1 storage.AggregatedInstance) {
+	fake.AggregatedInstanceStub = nil
+	fake.aggregatedInstanceReturns = struct {
+		result1 storage.AggregatedInstance
+	}{result1}
+}
+
+func (fake *FakeStorage) AggregatedInstanceReturnsOnCall(i int, result1 storage.AggregatedInstance) {
+	fake.AggregatedInstanceStub = nil
+	if fake.aggregatedInstanceReturnsOnCall == nil {
+		fake.aggregatedInstanceReturnsOnCall = make(map[int]struct {
+			result1 storage.AggregatedInstance
+		})
+	}
+	fake.aggregatedInstanceReturnsOnCall[i] = struct {
+		result1 storage.AggregatedInstance
+	}{result1}
+}
+
 func (fake *FakeStorage) InTransaction(ctx context.Context, f func(ctx context.Context, storage storage.Warehouse) error) error {
 	fake.inTransactionMutex.Lock()
 	ret, specificReturn := fake.inTransactionReturnsOnCall[len(fake.inTransactionArgsForCall)]
@@ -597,6 +842,16 @@ func (fake *FakeStorage) Invocations() map[string][][]interface{} {
 	defer fake.credentialsMutex.RUnlock()
 	fake.securityMutex.RLock()
 	defer fake.securityMutex.RUnlock()
+	fake.advisoryLockMutex.RLock()
+	defer fake.advisoryLockMutex.RUnlock()
+	fake.brokerCatalogMutex.RLock()
+	defer fake.brokerCatalogMutex.RUnlock()
+	fake.operationMutex.RLock()
+	defer fake.operationMutex.RUnlock()
+	fake.catalogOverrideMutex.RLock()
+	defer fake.catalogOverrideMutex.RUnlock()
+	fake.aggregatedInstanceMutex.RLock()
+	defer fake.aggregatedInstanceMutex.RUnlock()
 	fake.inTransactionMutex.RLock()
 	defer fake.inTransactionMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/Peripli/service-manager/test/common"
+	"github.com/gavv/httpexpect"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -69,6 +70,57 @@ var _ = Describe("Service Manager Authentication", func() {
 		})
 	})
 
+	Context("Authorization", func() {
+		withScopes := func(scopes ...string) *httpexpect.Expect {
+			token := ctx.Servers[common.OauthServer].(*common.OAuthServer).CreateToken(map[string]interface{}{
+				"scope": scopes,
+			})
+			return ctx.SM.Builder(func(req *httpexpect.Request) {
+				req.WithHeader("Authorization", "Bearer "+token)
+			})
+		}
+
+		It("Forbids requests with a token without scopes", func() {
+			withScopes().DELETE("/v1/service_brokers").
+				WithQuery("fieldQuery", "name = unknown").
+				Expect().
+				Status(http.StatusForbidden).
+				JSON().Object().Value("description").String().Contains("brokers:write")
+		})
+
+		It("Allows reading but forbids writing with the read scope", func() {
+			SMWithRead := withScopes("service_manager.read")
+			SMWithRead.GET("/v1/service_brokers").
+				Expect().
+				Status(http.StatusOK)
+
+			SMWithRead.POST("/v1/platforms").
+				WithJSON(common.GenerateRandomPlatform()).
+				Expect().
+				Status(http.StatusForbidden).
+				JSON().Object().Keys().Contains("error", "description")
+		})
+	})
+
+	Context("Brute-force protection", func() {
+		It("Locks out a username after repeated failed basic authentication attempts", func() {
+			username := "unknown-user"
+			for i := 0; i < 5; i++ {
+				ctx.SM.GET("/v1/visibilities").
+					WithBasicAuth(username, "wrong-password").
+					Expect().
+					Status(http.StatusUnauthorized)
+			}
+
+			resp := ctx.SM.GET("/v1/visibilities").
+				WithBasicAuth(username, "wrong-password").
+				Expect().
+				Status(http.StatusTooManyRequests)
+			resp.Header("Retry-After").NotEmpty()
+			resp.JSON().Object().Keys().Contains("error", "description")
+		})
+	})
+
 	Context("Failing security scenarios", func() {
 		authRequests := []struct{ name, method, path, authHeader string }{
 			// PLATFORMS
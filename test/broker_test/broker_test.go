@@ -20,7 +20,9 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/Peripli/service-manager/pkg/env"
 	"github.com/Peripli/service-manager/pkg/types"
 
 	"github.com/Peripli/service-manager/pkg/query"
@@ -375,6 +377,92 @@ var _ = test.DescribeTestsFor(test.TestCase{
 					})
 				})
 
+				Context("when dry run is requested", func() {
+					It("returns the catalog as added without registering the broker", func() {
+						serviceCount := len(gjson.Get(string(brokerServer.Catalog), "services").Array())
+
+						ctx.SMWithOAuth.POST("/v1/service_brokers").
+							WithQuery("dry_run", "true").
+							WithJSON(postBrokerRequestWithNoLabels).
+							Expect().
+							Status(http.StatusOK).
+							JSON().Path("$.added_service_offerings").Array().Length().Equal(serviceCount)
+
+						ctx.SMWithOAuth.GET("/v1/service_brokers").
+							Expect().
+							Status(http.StatusOK).
+							JSON().Path("$.service_brokers").Array().Empty()
+
+						assertInvocationCount(brokerServer.CatalogEndpointRequests, 1)
+					})
+				})
+
+				Context("when async is requested", func() {
+					getOperation := func(location string) map[string]interface{} {
+						return ctx.SMWithOAuth.GET(location).
+							Expect().
+							Status(http.StatusOK).
+							JSON().Object().Raw()
+					}
+
+					It("returns 202 and registers the broker in the background", func() {
+						resp := ctx.SMWithOAuth.POST("/v1/service_brokers").
+							WithQuery("async", "true").
+							WithJSON(postBrokerRequestWithNoLabels).
+							Expect().
+							Status(http.StatusAccepted)
+						resp.JSON().Object().
+							ValueEqual("type", "create").
+							ValueEqual("resource_type", "/v1/service_brokers")
+						location := resp.Header("Location").Raw()
+						Expect(location).To(HavePrefix("/v1/operations/"))
+
+						Eventually(func() interface{} {
+							return getOperation(location)["state"]
+						}, 5*time.Second, 200*time.Millisecond).Should(Equal("succeeded"))
+
+						brokerID := getOperation(location)["resource_id"].(string)
+						ctx.SMWithOAuth.GET("/v1/service_brokers/" + brokerID).
+							Expect().
+							Status(http.StatusOK).
+							JSON().Object().ContainsMap(expectedBrokerResponse)
+
+						assertInvocationCount(brokerServer.CatalogEndpointRequests, 1)
+					})
+
+					It("records the error when the registration fails", func() {
+						brokerServer.CatalogHandler = func(w http.ResponseWriter, req *http.Request) {
+							common.SetResponse(w, http.StatusInternalServerError, common.Object{})
+						}
+
+						location := ctx.SMWithOAuth.POST("/v1/service_brokers").
+							WithQuery("async", "true").
+							WithJSON(postBrokerRequestWithNoLabels).
+							Expect().
+							Status(http.StatusAccepted).
+							Header("Location").Raw()
+
+						Eventually(func() interface{} {
+							return getOperation(location)["state"]
+						}, 5*time.Second, 200*time.Millisecond).Should(Equal("failed"))
+
+						operation := getOperation(location)
+						Expect(operation).To(HaveKey("errors"))
+						Expect(operation).ToNot(HaveKey("resource_id"))
+
+						ctx.SMWithOAuth.GET("/v1/service_brokers").
+							Expect().
+							Status(http.StatusOK).
+							JSON().Path("$.service_brokers").Array().Empty()
+					})
+
+					It("returns 404 for a missing operation", func() {
+						ctx.SMWithOAuth.GET("/v1/operations/missing-operation-id").
+							Expect().
+							Status(http.StatusNotFound)
+					})
+				})
+
 				Context("when broker with name already exists", func() {
 					It("returns 409", func() {
 						ctx.SMWithOAuth.POST("/v1/service_brokers").WithJSON(postBrokerRequestWithNoLabels).
@@ -1005,6 +1093,46 @@ var _ = test.DescribeTestsFor(test.TestCase{
 
 							assertInvocationCount(brokerServer.CatalogEndpointRequests, 1)
 						})
+
+						Context("when dry run is requested", func() {
+							It("returns the service offering, its plans and their visibilities as removed without removing them", func() {
+								planID := ctx.SMWithOAuth.GET("/v1/service_plans").
+									WithQuery("fieldQuery", "service_offering_id = "+serviceOfferingID).
+									Expect().
+									Status(http.StatusOK).
+									JSON().Path("$.service_plans[0].id").String().Raw()
+								visibilityID := ctx.SMWithOAuth.POST("/v1/visibilities").
+									WithJSON(common.Object{
+										"service_plan_id": planID,
+										"platform_id":     ctx.TestPlatform.ID,
+									}).
+									Expect().
+									Status(http.StatusCreated).
+									JSON().Object().Value("id").String().Raw()
+
+								diff := ctx.SMWithOAuth.PATCH("/v1/service_brokers/"+brokerID).
+									WithQuery("dry_run", "true").
+									WithJSON(common.Object{}).
+									Expect().
+									Status(http.StatusOK).
+									JSON()
+								diff.Path("$.removed_service_offerings[*].id").Array().Contains(serviceOfferingID)
+								diff.Path("$.removed_service_plans[*].id").Array().Contains(planID)
+								diff.Path("$.removed_visibilities[*].id").Array().Contains(visibilityID)
+								diff.Path("$.added_service_offerings").Array().Empty()
+
+								ctx.SMWithOAuth.GET("/v1/service_offerings").
+									Expect().
+									Status(http.StatusOK).
+									JSON().Path("$.service_offerings[*].id").Array().Contains(serviceOfferingID)
+
+								ctx.SMWithOAuth.GET("/v1/visibilities/" + visibilityID).
+									Expect().
+									Status(http.StatusOK)
+
+								assertInvocationCount(brokerServer.CatalogEndpointRequests, 1)
+							})
+						})
 					})
 
 					Context("when an existing service offering is modified", func() {
@@ -1430,6 +1558,1161 @@ var _ = test.DescribeTestsFor(test.TestCase{
 					})
 				})
 			})
+
+			Describe("plan protection", func() {
+				var (
+					protectionCtx         *common.TestContext
+					protectedBrokerID     string
+					protectedBrokerServer *common.BrokerServer
+					removedPlanID         string
+					removedPlanCatalogID  string
+				)
+
+				BeforeEach(func() {
+					protectionCtx = common.NewTestContextBuilder().WithEnvPostExtensions(func(e env.Environment, servers map[string]common.FakeServer) {
+						e.Set("api.protect_plans_with_visibilities", true)
+					}).Build()
+					protectedBrokerID, _, protectedBrokerServer = protectionCtx.RegisterBroker()
+
+					removedPlanCatalogID = gjson.Get(string(protectedBrokerServer.Catalog), "services.0.plans.0.id").Str
+					Expect(removedPlanCatalogID).ToNot(BeEmpty())
+					removedPlanID = protectionCtx.SMWithOAuth.GET("/v1/service_plans").
+						WithQuery("fieldQuery", "catalog_id = "+removedPlanCatalogID).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.service_plans[0].id").String().Raw()
+					protectedBrokerServer.Catalog.RemovePlan(0, 0)
+				})
+
+				AfterEach(func() {
+					protectionCtx.Cleanup()
+				})
+
+				Context("when the removed plan has visibilities", func() {
+					BeforeEach(func() {
+						protectionCtx.SMWithOAuth.POST("/v1/visibilities").
+							WithJSON(common.Object{
+								"service_plan_id": removedPlanID,
+								"platform_id":     protectionCtx.TestPlatform.ID,
+							}).
+							Expect().
+							Status(http.StatusCreated)
+
+						protectionCtx.SMWithOAuth.PATCH("/v1/service_brokers/" + protectedBrokerID).
+							WithJSON(common.Object{}).
+							Expect().
+							Status(http.StatusOK)
+					})
+
+					It("deactivates the plan instead of removing it", func() {
+						protectionCtx.SMWithOAuth.GET("/v1/service_plans/"+removedPlanID).
+							Expect().
+							Status(http.StatusOK).
+							JSON().Object().ValueEqual("active", false).ContainsKey("deactivated_at")
+					})
+
+					It("is no longer returned in the OSB catalog", func() {
+						protectionCtx.SMWithBasic.GET("/v1/osb/"+protectedBrokerID+"/v2/catalog").
+							WithHeader("X-Broker-API-Version", "2.13").
+							Expect().
+							Status(http.StatusOK).
+							JSON().Path("$.services[*].plans[*].id").Array().NotContains(removedPlanCatalogID)
+					})
+
+					It("is removed when the inactive plans are purged", func() {
+						protectionCtx.SMWithOAuth.DELETE("/v1/service_brokers/" + protectedBrokerID + "/inactive_plans").
+							Expect().
+							Status(http.StatusOK)
+
+						protectionCtx.SMWithOAuth.GET("/v1/service_plans/" + removedPlanID).
+							Expect().
+							Status(http.StatusNotFound)
+					})
+				})
+
+				Context("when the service offering of the removed plan has visibilities", func() {
+					BeforeEach(func() {
+						serviceOfferingID := protectionCtx.SMWithOAuth.GET("/v1/service_plans/" + removedPlanID).
+							Expect().
+							Status(http.StatusOK).
+							JSON().Object().Value("service_offering_id").String().Raw()
+
+						protectionCtx.SMWithOAuth.POST("/v1/visibilities").
+							WithJSON(common.Object{
+								"service_offering_id": serviceOfferingID,
+								"platform_id":         protectionCtx.TestPlatform.ID,
+							}).
+							Expect().
+							Status(http.StatusCreated)
+
+						protectionCtx.SMWithOAuth.PATCH("/v1/service_brokers/" + protectedBrokerID).
+							WithJSON(common.Object{}).
+							Expect().
+							Status(http.StatusOK)
+					})
+
+					It("deactivates the plan instead of removing it", func() {
+						protectionCtx.SMWithOAuth.GET("/v1/service_plans/"+removedPlanID).
+							Expect().
+							Status(http.StatusOK).
+							JSON().Object().ValueEqual("active", false)
+					})
+				})
+
+				Context("when the removed plan has no visibilities", func() {
+					It("removes the plan", func() {
+						protectionCtx.SMWithOAuth.PATCH("/v1/service_brokers/" + protectedBrokerID).
+							WithJSON(common.Object{}).
+							Expect().
+							Status(http.StatusOK)
+
+						protectionCtx.SMWithOAuth.GET("/v1/service_plans/" + removedPlanID).
+							Expect().
+							Status(http.StatusNotFound)
+					})
+				})
+			})
+
+			Describe("suspend and resume", func() {
+				var (
+					suspendedBrokerID     string
+					suspendedBrokerServer *common.BrokerServer
+					visibilityID          string
+				)
+
+				provision := func() *httpexpect.Response {
+					return ctx.SMWithBasic.PUT("/v1/osb/"+suspendedBrokerID+"/v2/service_instances/instance-id").
+						WithHeader("X-Broker-API-Version", "2.13").
+						WithJSON(common.Object{}).
+						Expect()
+				}
+
+				catalogServices := func() *httpexpect.Array {
+					return ctx.SMWithBasic.GET("/v1/osb/"+suspendedBrokerID+"/v2/catalog").
+						WithHeader("X-Broker-API-Version", "2.13").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.services").Array()
+				}
+
+				BeforeEach(func() {
+					suspendedBrokerID, _, suspendedBrokerServer = ctx.RegisterBroker()
+					planID := ctx.SMWithOAuth.GET("/v1/service_plans").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.service_plans[0].id").String().Raw()
+					visibilityID = ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(common.Object{
+							"service_plan_id": planID,
+							"platform_id":     ctx.TestPlatform.ID,
+						}).
+						Expect().
+						Status(http.StatusCreated).
+						JSON().Object().Value("id").String().Raw()
+
+					ctx.SMWithOAuth.POST("/v1/service_brokers/"+suspendedBrokerID+"/suspend").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Object().ValueEqual("state", "suspended")
+				})
+
+				It("omits the services of a suspended broker from the catalog", func() {
+					catalogServices().Empty()
+				})
+
+				It("rejects provisioning with a suspended broker", func() {
+					provision().
+						Status(http.StatusUnprocessableEntity).
+						JSON().Object().ValueEqual("error", "BrokerSuspended")
+
+					assertInvocationCount(suspendedBrokerServer.ServiceInstanceEndpointRequests, 0)
+				})
+
+				It("keeps the visibilities of a suspended broker", func() {
+					ctx.SMWithOAuth.GET("/v1/visibilities/" + visibilityID).
+						Expect().
+						Status(http.StatusOK)
+				})
+
+				It("keeps the broker suspended when it is updated", func() {
+					ctx.SMWithOAuth.PATCH("/v1/service_brokers/"+suspendedBrokerID).
+						WithJSON(common.Object{"state": "active"}).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Object().ValueEqual("state", "suspended")
+				})
+
+				Context("when the broker is resumed", func() {
+					BeforeEach(func() {
+						ctx.SMWithOAuth.POST("/v1/service_brokers/"+suspendedBrokerID+"/resume").
+							Expect().
+							Status(http.StatusOK).
+							JSON().Object().ValueEqual("state", "active")
+					})
+
+					It("offers its services and accepts provisioning again", func() {
+						catalogServices().NotEmpty()
+
+						provision().Status(http.StatusCreated)
+						assertInvocationCount(suspendedBrokerServer.ServiceInstanceEndpointRequests, 1)
+					})
+				})
+
+				It("returns 404 for a missing broker", func() {
+					ctx.SMWithOAuth.POST("/v1/service_brokers/missing-broker-id/suspend").
+						Expect().
+						Status(http.StatusNotFound)
+				})
+			})
+
+			Describe("catalog overrides", func() {
+				var (
+					overriddenBrokerID string
+					serviceOfferingID  string
+					servicePlanID      string
+					planCatalogID      string
+				)
+
+				osbCatalogPlans := func() map[string]map[string]interface{} {
+					services := ctx.SMWithBasic.GET("/v1/osb/"+overriddenBrokerID+"/v2/catalog").
+						WithHeader("X-Broker-API-Version", "2.13").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.services").Array()
+					plans := make(map[string]map[string]interface{})
+					for _, service := range services.Iter() {
+						for _, plan := range service.Object().Value("plans").Array().Iter() {
+							plans[plan.Object().Value("id").String().Raw()] = plan.Object().Raw()
+						}
+					}
+					return plans
+				}
+
+				BeforeEach(func() {
+					overriddenBrokerID, _, _ = ctx.RegisterBroker()
+					serviceOffering := ctx.SMWithOAuth.GET("/v1/service_offerings").
+						WithQuery("fieldQuery", "broker_id = "+overriddenBrokerID).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.service_offerings[0]").Object()
+					serviceOfferingID = serviceOffering.Value("id").String().Raw()
+					servicePlan := ctx.SMWithOAuth.GET("/v1/service_plans").
+						WithQuery("fieldQuery", "service_offering_id = "+serviceOfferingID).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.service_plans[0]").Object()
+					servicePlanID = servicePlan.Value("id").String().Raw()
+					planCatalogID = servicePlan.Value("catalog_id").String().Raw()
+				})
+
+				It("applies a plan override to the service plans API and the OSB catalog", func() {
+					ctx.SMWithOAuth.PUT("/v1/service_plans/"+servicePlanID+"/override").
+						WithJSON(common.Object{
+							"display_name": "overridden-name",
+							"description":  "overridden description",
+							"metadata":     common.Object{"costs": "none"},
+						}).
+						Expect().
+						Status(http.StatusCreated).
+						JSON().Object().
+						ValueEqual("broker_id", overriddenBrokerID).
+						ValueEqual("plan_catalog_id", planCatalogID)
+
+					servicePlan := ctx.SMWithOAuth.GET("/v1/service_plans/" + servicePlanID).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Object()
+					servicePlan.ValueEqual("description", "overridden description")
+					servicePlan.Path("$.metadata.displayName").Equal("overridden-name")
+					servicePlan.Path("$.metadata.costs").Equal("none")
+
+					plan := osbCatalogPlans()[planCatalogID]
+					Expect(plan).ToNot(BeNil())
+					Expect(plan["description"]).To(Equal("overridden description"))
+					Expect(plan["metadata"]).To(HaveKeyWithValue("displayName", "overridden-name"))
+				})
+
+				It("applies a service offering override to the service offerings API", func() {
+					ctx.SMWithOAuth.PUT("/v1/service_offerings/"+serviceOfferingID+"/override").
+						WithJSON(common.Object{
+							"display_name": "overridden-name",
+							"description":  "overridden description",
+						}).
+						Expect().
+						Status(http.StatusCreated).
+						JSON().Object().
+						ValueEqual("broker_id", overriddenBrokerID).
+						NotContainsKey("plan_catalog_id")
+
+					serviceOffering := ctx.SMWithOAuth.GET("/v1/service_offerings/" + serviceOfferingID).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Object()
+					serviceOffering.ValueEqual("description", "overridden description")
+					serviceOffering.Path("$.metadata.displayName").Equal("overridden-name")
+
+					ctx.SMWithOAuth.GET("/v1/service_offerings").
+						WithQuery("fieldQuery", "broker_id = "+overriddenBrokerID).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.service_offerings[0].description").Equal("overridden description")
+				})
+
+				It("replaces an existing override", func() {
+					ctx.SMWithOAuth.PUT("/v1/service_plans/" + servicePlanID + "/override").
+						WithJSON(common.Object{"description": "first description"}).
+						Expect().
+						Status(http.StatusCreated)
+					ctx.SMWithOAuth.PUT("/v1/service_plans/" + servicePlanID + "/override").
+						WithJSON(common.Object{"display_name": "second-name"}).
+						Expect().
+						Status(http.StatusOK)
+
+					ctx.SMWithOAuth.GET("/v1/service_plans/"+servicePlanID+"/override").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Object().
+						ValueEqual("display_name", "second-name").
+						NotContainsKey("description")
+				})
+
+				It("hides a hidden plan from the list and the OSB catalog", func() {
+					ctx.SMWithOAuth.PUT("/v1/service_plans/" + servicePlanID + "/override").
+						WithJSON(common.Object{"hidden": true}).
+						Expect().
+						Status(http.StatusCreated)
+
+					ctx.SMWithOAuth.GET("/v1/service_plans").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.service_plans[*].id").Array().NotContains(servicePlanID)
+					ctx.SMWithOAuth.GET("/v1/service_plans/" + servicePlanID).
+						Expect().
+						Status(http.StatusOK)
+					Expect(osbCatalogPlans()).ToNot(HaveKey(planCatalogID))
+				})
+
+				It("hides the plans of a hidden service offering", func() {
+					ctx.SMWithOAuth.PUT("/v1/service_offerings/" + serviceOfferingID + "/override").
+						WithJSON(common.Object{"hidden": true}).
+						Expect().
+						Status(http.StatusCreated)
+
+					ctx.SMWithOAuth.GET("/v1/service_plans").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.service_plans[*].id").Array().NotContains(servicePlanID)
+					ctx.SMWithOAuth.GET("/v1/service_offerings").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.service_offerings[*].id").Array().NotContains(serviceOfferingID)
+					ctx.SMWithOAuth.GET("/v1/service_offerings/" + serviceOfferingID).
+						Expect().
+						Status(http.StatusOK)
+					Expect(osbCatalogPlans()).ToNot(HaveKey(planCatalogID))
+				})
+
+				It("preserves the overrides across catalog resyncs", func() {
+					ctx.SMWithOAuth.PUT("/v1/service_plans/" + servicePlanID + "/override").
+						WithJSON(common.Object{"description": "overridden description"}).
+						Expect().
+						Status(http.StatusCreated)
+
+					ctx.SMWithOAuth.POST("/v1/service_brokers/" + overriddenBrokerID + "/refresh").
+						Expect().
+						Status(http.StatusOK)
+
+					ctx.SMWithOAuth.GET("/v1/service_plans/"+servicePlanID).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Object().ValueEqual("description", "overridden description")
+				})
+
+				It("removes a deleted override", func() {
+					ctx.SMWithOAuth.PUT("/v1/service_offerings/" + serviceOfferingID + "/override").
+						WithJSON(common.Object{"description": "overridden description"}).
+						Expect().
+						Status(http.StatusCreated)
+
+					ctx.SMWithOAuth.DELETE("/v1/service_offerings/" + serviceOfferingID + "/override").
+						Expect().
+						Status(http.StatusOK)
+
+					ctx.SMWithOAuth.GET("/v1/service_offerings/" + serviceOfferingID + "/override").
+						Expect().
+						Status(http.StatusNotFound)
+				})
+
+				It("rejects override metadata which is not an object", func() {
+					ctx.SMWithOAuth.PUT("/v1/service_plans/" + servicePlanID + "/override").
+						WithJSON(common.Object{"metadata": common.Array{"costs"}}).
+						Expect().
+						Status(http.StatusBadRequest)
+				})
+			})
+
+			Describe("naming policy", func() {
+				var (
+					namedBrokerID   string
+					namedBrokerName string
+					catalog         common.SBCatalog
+				)
+
+				catalogNames := func(brokerID string) (string, string) {
+					service := ctx.SMWithBasic.GET("/v1/osb/"+brokerID+"/v2/catalog").
+						WithHeader("X-Broker-API-Version", "2.13").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.services[0]").Object()
+					return service.Value("name").String().Raw(), service.Path("$.plans[0].name").String().Raw()
+				}
+
+				conflictValues := func(field string) []interface{} {
+					conflicts := ctx.SMWithOAuth.GET("/v1/service_brokers/naming_conflicts").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Object().Value("conflicts").Array()
+					values := make([]interface{}, 0)
+					for _, conflict := range conflicts.Iter() {
+						if conflict.Object().Value("field").String().Raw() == field {
+							values = append(values, conflict.Object().Value("value").Raw())
+						}
+					}
+					return values
+				}
+
+				BeforeEach(func() {
+					catalog = common.NewRandomSBCatalog()
+					namedBrokerID, _, _ = ctx.RegisterBrokerWithCatalogAndLabels(catalog, common.Object{
+						"env": common.Array{"dev"},
+					})
+					namedBrokerName = ctx.SMWithOAuth.GET("/v1/service_brokers/" + namedBrokerID).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Object().Value("name").String().Raw()
+				})
+
+				It("namespaces the service and plan names in the OSB catalog", func() {
+					serviceName, planName := catalogNames(namedBrokerID)
+
+					ctx.SMWithOAuth.PATCH("/v1/service_brokers/" + namedBrokerID).
+						WithJSON(common.Object{
+							"naming_policy": common.Object{
+								"prefix":         "{{.Name}}-",
+								"suffix":         "-{{.Labels.env}}",
+								"apply_to_plans": true,
+							},
+						}).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.naming_policy.prefix").Equal("{{.Name}}-")
+
+					namespacedServiceName, namespacedPlanName := catalogNames(namedBrokerID)
+					Expect(namespacedServiceName).To(Equal(namedBrokerName + "-" + serviceName + "-dev"))
+					Expect(namespacedPlanName).To(Equal(namedBrokerName + "-" + planName + "-dev"))
+				})
+
+				It("keeps the plan names unless requested", func() {
+					_, planName := catalogNames(namedBrokerID)
+
+					ctx.SMWithOAuth.PATCH("/v1/service_brokers/" + namedBrokerID).
+						WithJSON(common.Object{
+							"naming_policy": common.Object{"prefix": "dev-"},
+						}).
+						Expect().
+						Status(http.StatusOK)
+
+					_, namespacedPlanName := catalogNames(namedBrokerID)
+					Expect(namespacedPlanName).To(Equal(planName))
+				})
+
+				It("rejects an invalid naming policy", func() {
+					ctx.SMWithOAuth.PATCH("/v1/service_brokers/" + namedBrokerID).
+						WithJSON(common.Object{
+							"naming_policy": common.Object{"prefix": "{{.Name"},
+						}).
+						Expect().
+						Status(http.StatusBadRequest)
+				})
+
+				Context("when another broker offers the same catalog", func() {
+					var serviceName string
+
+					BeforeEach(func() {
+						serviceName, _ = catalogNames(namedBrokerID)
+						ctx.RegisterBrokerWithCatalog(catalog)
+					})
+
+					It("reports the duplicate service names and catalog ids", func() {
+						Expect(conflictValues("service_name")).To(ContainElement(serviceName))
+						Expect(conflictValues("service_catalog_id")).To(ContainElement(gjson.Get(string(catalog), "services.0.id").Str))
+						Expect(conflictValues("plan_catalog_id")).To(ContainElement(gjson.Get(string(catalog), "services.0.plans.0.id").Str))
+					})
+
+					It("does not report service names resolved by a naming policy", func() {
+						ctx.SMWithOAuth.PATCH("/v1/service_brokers/" + namedBrokerID).
+							WithJSON(common.Object{
+								"naming_policy": common.Object{"prefix": "{{.Name}}-"},
+							}).
+							Expect().
+							Status(http.StatusOK)
+
+						Expect(conflictValues("service_name")).ToNot(ContainElement(serviceName))
+						Expect(conflictValues("service_catalog_id")).To(ContainElement(gjson.Get(string(catalog), "services.0.id").Str))
+					})
+				})
+			})
+
+			Describe("static catalog", func() {
+				var staticCatalog common.SBCatalog
+
+				serviceOfferingsCount := func(brokerID string) int {
+					return len(ctx.SMWithOAuth.GET("/v1/service_offerings").
+						WithQuery("fieldQuery", "broker_id = "+brokerID).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Object().Value("service_offerings").Array().Iter())
+				}
+
+				BeforeEach(func() {
+					staticCatalog = common.NewRandomSBCatalog()
+					brokerServer.CatalogHandler = func(rw http.ResponseWriter, req *http.Request) {
+						common.SetResponse(rw, http.StatusInternalServerError, common.Object{})
+					}
+					postBrokerRequestWithNoLabels["static_catalog"] = common.JSONToMap(string(staticCatalog))
+				})
+
+				It("registers the broker without fetching its catalog", func() {
+					brokerID := ctx.SMWithOAuth.POST("/v1/service_brokers").
+						WithJSON(postBrokerRequestWithNoLabels).
+						Expect().
+						Status(http.StatusCreated).
+						JSON().Object().Value("id").String().Raw()
+
+					assertInvocationCount(brokerServer.CatalogEndpointRequests, 0)
+					Expect(serviceOfferingsCount(brokerID)).To(Equal(1))
+				})
+
+				It("rejects a static catalog which violates the OSB specification", func() {
+					staticCatalog.RemovePlan(0, 1)
+					staticCatalog.RemovePlan(0, 0)
+					postBrokerRequestWithNoLabels["static_catalog"] = common.JSONToMap(string(staticCatalog))
+
+					ctx.SMWithOAuth.POST("/v1/service_brokers").
+						WithJSON(postBrokerRequestWithNoLabels).
+						Expect().
+						Status(http.StatusBadRequest).
+						JSON().Object().Value("description").String().Contains("has no plans")
+				})
+
+				It("proxies the OSB calls to the broker", func() {
+					brokerID := ctx.SMWithOAuth.POST("/v1/service_brokers").
+						WithJSON(postBrokerRequestWithNoLabels).
+						Expect().
+						Status(http.StatusCreated).
+						JSON().Object().Value("id").String().Raw()
+
+					ctx.SMWithBasic.PUT("/v1/osb/"+brokerID+"/v2/service_instances/instance-id").
+						WithHeader("X-Broker-API-Version", "2.13").
+						WithJSON(common.Object{}).
+						Expect().
+						Status(http.StatusCreated)
+					assertInvocationCount(brokerServer.ServiceInstanceEndpointRequests, 1)
+				})
+
+				It("updates the catalog with a new static catalog document", func() {
+					brokerID := ctx.SMWithOAuth.POST("/v1/service_brokers").
+						WithJSON(postBrokerRequestWithNoLabels).
+						Expect().
+						Status(http.StatusCreated).
+						JSON().Object().Value("id").String().Raw()
+
+					staticCatalog.AddService(common.GenerateTestServiceWithPlans(common.GenerateTestPlan()))
+					ctx.SMWithOAuth.PUT("/v1/service_brokers/" + brokerID + "/static_catalog").
+						WithJSON(common.JSONToMap(string(staticCatalog))).
+						Expect().
+						Status(http.StatusOK)
+
+					assertInvocationCount(brokerServer.CatalogEndpointRequests, 0)
+					Expect(serviceOfferingsCount(brokerID)).To(Equal(2))
+				})
+
+				It("rejects a static catalog for a broker that serves its own catalog", func() {
+					brokerServer.ResetHandlers()
+					delete(postBrokerRequestWithNoLabels, "static_catalog")
+					brokerID := ctx.SMWithOAuth.POST("/v1/service_brokers").
+						WithJSON(postBrokerRequestWithNoLabels).
+						Expect().
+						Status(http.StatusCreated).
+						JSON().Object().Value("id").String().Raw()
+
+					ctx.SMWithOAuth.PUT("/v1/service_brokers/" + brokerID + "/static_catalog").
+						WithJSON(common.JSONToMap(string(staticCatalog))).
+						Expect().
+						Status(http.StatusConflict)
+				})
+			})
+
+			Describe("broker check", func() {
+				checkStatuses := func(report *httpexpect.Object) map[string]interface{} {
+					statuses := make(map[string]interface{})
+					for _, check := range report.Value("checks").Array().Iter() {
+						statuses[check.Object().Value("name").String().Raw()] = check.Object().Value("status").Raw()
+					}
+					return statuses
+				}
+
+				Context("for a registered broker", func() {
+					var (
+						checkedBrokerID     string
+						checkedBrokerServer *common.BrokerServer
+					)
+
+					BeforeEach(func() {
+						checkedBrokerID, _, checkedBrokerServer = ctx.RegisterBroker()
+					})
+
+					It("reports a working broker as passed", func() {
+						report := ctx.SMWithOAuth.POST("/v1/service_brokers/" + checkedBrokerID + "/check").
+							Expect().
+							Status(http.StatusOK).
+							JSON().Object()
+						report.ValueEqual("passed", true)
+						Expect(checkStatuses(report)).To(Equal(map[string]interface{}{
+							"reachability":    "passed",
+							"credentials":     "passed",
+							"osb_version":     "passed",
+							"catalog":         "passed",
+							"tls_certificate": "skipped",
+						}))
+					})
+
+					It("reports rejected credentials", func() {
+						checkedBrokerServer.CatalogHandler = func(w http.ResponseWriter, req *http.Request) {
+							common.SetResponse(w, http.StatusUnauthorized, common.Object{})
+						}
+
+						report := ctx.SMWithOAuth.POST("/v1/service_brokers/" + checkedBrokerID + "/check").
+							Expect().
+							Status(http.StatusOK).
+							JSON().Object()
+						report.ValueEqual("passed", false)
+						Expect(checkStatuses(report)).To(HaveKeyWithValue("credentials", "failed"))
+						Expect(checkStatuses(report)).To(HaveKeyWithValue("catalog", "skipped"))
+					})
+
+					It("reports an invalid catalog", func() {
+						duplicatePlan := gjson.Get(string(checkedBrokerServer.Catalog), "services.0.plans.0").Raw
+						checkedBrokerServer.Catalog.AddPlanToService(duplicatePlan, 0)
+
+						report := ctx.SMWithOAuth.POST("/v1/service_brokers/" + checkedBrokerID + "/check").
+							Expect().
+							Status(http.StatusOK).
+							JSON().Object()
+						report.ValueEqual("passed", false)
+						Expect(checkStatuses(report)).To(HaveKeyWithValue("catalog", "failed"))
+					})
+
+					It("returns 404 for a missing broker", func() {
+						ctx.SMWithOAuth.POST("/v1/service_brokers/missing-broker-id/check").
+							Expect().
+							Status(http.StatusNotFound)
+					})
+				})
+
+				Context("before registration", func() {
+					It("reports a working broker as passed", func() {
+						ctx.SMWithOAuth.POST("/v1/service_brokers/check").
+							WithJSON(postBrokerRequestWithNoLabels).
+							Expect().
+							Status(http.StatusOK).
+							JSON().Object().ValueEqual("passed", true)
+
+						assertInvocationCount(brokerServer.CatalogEndpointRequests, 1)
+					})
+
+					It("reports an unreachable broker and skips the remaining checks", func() {
+						postBrokerRequestWithNoLabels["broker_url"] = "http://localhost:1"
+
+						report := ctx.SMWithOAuth.POST("/v1/service_brokers/check").
+							WithJSON(postBrokerRequestWithNoLabels).
+							Expect().
+							Status(http.StatusOK).
+							JSON().Object()
+						report.ValueEqual("passed", false)
+						Expect(checkStatuses(report)).To(Equal(map[string]interface{}{
+							"reachability":    "failed",
+							"credentials":     "skipped",
+							"osb_version":     "skipped",
+							"catalog":         "skipped",
+							"tls_certificate": "skipped",
+						}))
+					})
+
+					It("returns 400 when the broker url is missing", func() {
+						delete(postBrokerRequestWithNoLabels, "broker_url")
+
+						ctx.SMWithOAuth.POST("/v1/service_brokers/check").
+							WithJSON(postBrokerRequestWithNoLabels).
+							Expect().
+							Status(http.StatusBadRequest)
+					})
+				})
+			})
+
+			Describe("catalog refresh", func() {
+				var (
+					refreshBrokerID     string
+					refreshBrokerServer *common.BrokerServer
+					anotherServiceID    string
+				)
+
+				BeforeEach(func() {
+					refreshBrokerID, _, refreshBrokerServer = ctx.RegisterBrokerWithCatalogAndLabels(common.NewRandomSBCatalog(), common.Object{
+						"env": common.Array{"refresh"},
+					})
+
+					anotherService := common.JSONToMap(common.GenerateTestServiceWithPlans())
+					anotherServiceID = anotherService["id"].(string)
+					currServices, err := sjson.Set(string(refreshBrokerServer.Catalog), "services.-1", anotherService)
+					Expect(err).ShouldNot(HaveOccurred())
+					refreshBrokerServer.Catalog = common.SBCatalog(currServices)
+					refreshBrokerServer.ResetCallHistory()
+				})
+
+				It("resyncs the catalog of the broker and returns the changes", func() {
+					refreshBroker := ctx.SMWithOAuth.GET("/v1/service_brokers/" + refreshBrokerID).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Object().Raw()
+
+					ctx.SMWithOAuth.POST("/v1/service_brokers/" + refreshBrokerID + "/refresh").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.added_service_offerings[*].catalog_id").Array().Contains(anotherServiceID)
+
+					assertInvocationCount(refreshBrokerServer.CatalogEndpointRequests, 1)
+
+					ctx.SMWithOAuth.GET("/v1/service_offerings").
+						WithQuery("fieldQuery", "broker_id = "+refreshBrokerID).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.service_offerings[*].catalog_id").Array().Contains(anotherServiceID)
+
+					ctx.SMWithOAuth.GET("/v1/service_brokers/"+refreshBrokerID).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Object().
+						ValueEqual("updated_at", refreshBroker["updated_at"]).
+						ValueEqual("broker_url", refreshBroker["broker_url"])
+				})
+
+				It("returns 404 for a missing broker", func() {
+					ctx.SMWithOAuth.POST("/v1/service_brokers/missing-broker-id/refresh").
+						Expect().
+						Status(http.StatusNotFound)
+				})
+
+				It("refreshes the brokers matching the label query", func() {
+					refreshes := ctx.SMWithOAuth.POST("/v1/service_brokers/refresh").
+						WithQuery("labelQuery", "env = refresh").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.refreshes").Array()
+					refreshes.Length().Equal(1)
+					refreshes.Element(0).Object().
+						ValueEqual("broker_id", refreshBrokerID).
+						NotContainsKey("error").
+						Path("$.changes.added_service_offerings[*].catalog_id").Array().Contains(anotherServiceID)
+				})
+
+				It("reports the brokers whose catalog cannot be fetched", func() {
+					refreshBrokerServer.CatalogHandler = func(w http.ResponseWriter, req *http.Request) {
+						common.SetResponse(w, http.StatusInternalServerError, common.Object{})
+					}
+
+					ctx.SMWithOAuth.POST("/v1/service_brokers/refresh").
+						WithQuery("labelQuery", "env = refresh").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.refreshes[0]").Object().
+						ValueEqual("broker_id", refreshBrokerID).
+						ContainsKey("error").
+						NotContainsKey("changes")
+				})
+
+				It("requires a query selecting the brokers to refresh", func() {
+					ctx.SMWithOAuth.POST("/v1/service_brokers/refresh").
+						Expect().
+						Status(http.StatusBadRequest)
+
+					assertInvocationCount(refreshBrokerServer.CatalogEndpointRequests, 0)
+				})
+
+				It("skips suspended brokers", func() {
+					ctx.SMWithOAuth.POST("/v1/service_brokers/" + refreshBrokerID + "/suspend").
+						Expect().
+						Status(http.StatusOK)
+
+					ctx.SMWithOAuth.POST("/v1/service_brokers/refresh").
+						WithQuery("labelQuery", "env = refresh").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.refreshes").Array().Empty()
+
+					assertInvocationCount(refreshBrokerServer.CatalogEndpointRequests, 0)
+				})
+
+				Context("when the catalog is unchanged", func() {
+					serviceOfferingUpdatedAt := func() interface{} {
+						return ctx.SMWithOAuth.GET("/v1/service_offerings").
+							WithQuery("fieldQuery", "catalog_id = "+anotherServiceID).
+							Expect().
+							Status(http.StatusOK).
+							JSON().Path("$.service_offerings[0].updated_at").Raw()
+					}
+
+					It("skips the resync of a catalog with the same content", func() {
+						ctx.SMWithOAuth.POST("/v1/service_brokers/" + refreshBrokerID + "/refresh").
+							Expect().
+							Status(http.StatusOK)
+						updatedAt := serviceOfferingUpdatedAt()
+
+						ctx.SMWithOAuth.POST("/v1/service_brokers/" + refreshBrokerID + "/refresh").
+							Expect().
+							Status(http.StatusOK).
+							JSON().Object().Value("updated_service_offerings").Array().Empty()
+
+						assertInvocationCount(refreshBrokerServer.CatalogEndpointRequests, 2)
+						Expect(refreshBrokerServer.CatalogEndpointRequests[1].Header.Get("If-None-Match")).ToNot(BeEmpty())
+						Expect(serviceOfferingUpdatedAt()).To(Equal(updatedAt))
+					})
+
+					It("sends the ETag of the catalog and skips the resync when the catalog is not modified", func() {
+						const catalogETag = `"catalog-v1"`
+						refreshBrokerServer.CatalogHandler = func(w http.ResponseWriter, req *http.Request) {
+							if req.Header.Get("If-None-Match") == catalogETag {
+								w.WriteHeader(http.StatusNotModified)
+								return
+							}
+							w.Header().Set("ETag", catalogETag)
+							common.SetResponse(w, http.StatusOK, common.JSONToMap(string(refreshBrokerServer.Catalog)))
+						}
+
+						ctx.SMWithOAuth.POST("/v1/service_brokers/" + refreshBrokerID + "/refresh").
+							Expect().
+							Status(http.StatusOK).
+							JSON().Path("$.added_service_offerings[*].catalog_id").Array().Contains(anotherServiceID)
+						updatedAt := serviceOfferingUpdatedAt()
+
+						ctx.SMWithOAuth.POST("/v1/service_brokers/" + refreshBrokerID + "/refresh").
+							Expect().
+							Status(http.StatusOK).
+							JSON().Object().Value("added_service_offerings").Array().Empty()
+
+						assertInvocationCount(refreshBrokerServer.CatalogEndpointRequests, 2)
+						Expect(refreshBrokerServer.CatalogEndpointRequests[1].Header.Get("If-None-Match")).To(Equal(catalogETag))
+						Expect(serviceOfferingUpdatedAt()).To(Equal(updatedAt))
+					})
+
+					It("skips the resync of the catalog fetched when the broker was registered", func() {
+						registeredBrokerID, _, registeredBrokerServer := ctx.RegisterBroker()
+						registeredBrokerServer.ResetCallHistory()
+
+						refresh := ctx.SMWithOAuth.POST("/v1/service_brokers/" + registeredBrokerID + "/refresh").
+							Expect().
+							Status(http.StatusOK).
+							JSON().Object()
+						refresh.Value("added_service_offerings").Array().Empty()
+						refresh.Value("updated_service_offerings").Array().Empty()
+
+						assertInvocationCount(registeredBrokerServer.CatalogEndpointRequests, 1)
+						Expect(registeredBrokerServer.CatalogEndpointRequests[0].Header.Get("If-None-Match")).ToNot(BeEmpty())
+					})
+
+					It("skips the resync of the catalog fetched when the broker was updated", func() {
+						ctx.SMWithOAuth.PATCH("/v1/service_brokers/" + refreshBrokerID).
+							WithJSON(common.Object{"description": "updated"}).
+							Expect().
+							Status(http.StatusOK)
+						updatedAt := serviceOfferingUpdatedAt()
+
+						ctx.SMWithOAuth.POST("/v1/service_brokers/" + refreshBrokerID + "/refresh").
+							Expect().
+							Status(http.StatusOK).
+							JSON().Object().Value("updated_service_offerings").Array().Empty()
+
+						Expect(serviceOfferingUpdatedAt()).To(Equal(updatedAt))
+					})
+				})
+			})
+
+			Describe("catalog history", func() {
+				var (
+					historyCtx          *common.TestContext
+					historyBrokerID     string
+					historyBrokerServer *common.BrokerServer
+					removedServiceID    string
+				)
+
+				BeforeEach(func() {
+					historyCtx = common.NewTestContextBuilder().Build()
+					historyBrokerID, _, historyBrokerServer = historyCtx.RegisterBroker()
+
+					removedServiceID = gjson.Get(string(historyBrokerServer.Catalog), "services.0.id").Str
+					Expect(removedServiceID).ToNot(BeEmpty())
+					historyBrokerServer.Catalog.RemoveService(0)
+					historyCtx.SMWithOAuth.PATCH("/v1/service_brokers/" + historyBrokerID).
+						WithJSON(common.Object{}).
+						Expect().
+						Status(http.StatusOK)
+				})
+
+				AfterEach(func() {
+					historyCtx.Cleanup()
+				})
+
+				listServiceOfferingCatalogIDs := func() []interface{} {
+					return historyCtx.SMWithOAuth.GET("/v1/service_offerings").
+						WithQuery("fieldQuery", "broker_id = "+historyBrokerID).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.service_offerings[*].catalog_id").Array().Raw()
+				}
+
+				It("stores a new version when the catalog changes", func() {
+					catalogs := historyCtx.SMWithOAuth.GET("/v1/service_brokers/" + historyBrokerID + "/catalogs").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.catalogs").Array()
+					catalogs.Length().Equal(2)
+					catalogs.Element(1).Object().ValueEqual("version", 2).NotContainsKey("catalog")
+
+					historyCtx.SMWithOAuth.GET("/v1/service_brokers/" + historyBrokerID + "/catalogs/1").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.catalog.services[*].id").Array().Contains(removedServiceID)
+				})
+
+				Context("when the catalog history limit is exceeded", func() {
+					var limitedCtx *common.TestContext
+
+					BeforeEach(func() {
+						limitedCtx = common.NewTestContextBuilder().WithEnvPostExtensions(func(e env.Environment, servers map[string]common.FakeServer) {
+							e.Set("api.catalog_history_limit", 2)
+						}).Build()
+					})
+
+					AfterEach(func() {
+						limitedCtx.Cleanup()
+					})
+
+					changeCatalog := func(brokerID string, brokerServer *common.BrokerServer) {
+						brokerServer.Catalog.RemoveService(0)
+						limitedCtx.SMWithOAuth.PATCH("/v1/service_brokers/" + brokerID).
+							WithJSON(common.Object{}).
+							Expect().
+							Status(http.StatusOK)
+					}
+
+					listVersions := func(brokerID string) []interface{} {
+						return limitedCtx.SMWithOAuth.GET("/v1/service_brokers/" + brokerID + "/catalogs").
+							Expect().
+							Status(http.StatusOK).
+							JSON().Path("$.catalogs[*].version").Array().Raw()
+					}
+
+					It("removes the oldest versions", func() {
+						brokerID, _, brokerServer := limitedCtx.RegisterBrokerWithCatalog(common.NewRandomSBCatalog())
+						changeCatalog(brokerID, brokerServer)
+						changeCatalog(brokerID, brokerServer)
+
+						Expect(listVersions(brokerID)).To(Equal([]interface{}{2.0, 3.0}))
+					})
+
+					It("keeps the pinned version", func() {
+						brokerID, _, brokerServer := limitedCtx.RegisterBrokerWithCatalog(common.NewRandomSBCatalog())
+						limitedCtx.SMWithOAuth.PUT("/v1/service_brokers/" + brokerID + "/pinned_catalog").
+							WithJSON(common.Object{"version": 1}).
+							Expect().
+							Status(http.StatusOK)
+						changeCatalog(brokerID, brokerServer)
+						changeCatalog(brokerID, brokerServer)
+
+						Expect(listVersions(brokerID)).To(Equal([]interface{}{1.0, 2.0, 3.0}))
+					})
+				})
+
+				It("does not store a new version when the catalog is unchanged", func() {
+					historyCtx.SMWithOAuth.PATCH("/v1/service_brokers/" + historyBrokerID).
+						WithJSON(common.Object{}).
+						Expect().
+						Status(http.StatusOK)
+
+					historyCtx.SMWithOAuth.GET("/v1/service_brokers/" + historyBrokerID + "/catalogs").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.catalogs").Array().Length().Equal(2)
+				})
+
+				It("returns the diff between two versions", func() {
+					historyCtx.SMWithOAuth.GET("/v1/service_brokers/"+historyBrokerID+"/catalogs/1/diff").
+						WithQuery("to", 2).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.removed_service_offerings[*].catalog_id").Array().Contains(removedServiceID)
+				})
+
+				It("returns 404 for a missing version", func() {
+					historyCtx.SMWithOAuth.GET("/v1/service_brokers/" + historyBrokerID + "/catalogs/3").
+						Expect().
+						Status(http.StatusNotFound)
+				})
+
+				It("returns 400 for an invalid version", func() {
+					historyCtx.SMWithOAuth.GET("/v1/service_brokers/" + historyBrokerID + "/catalogs/latest").
+						Expect().
+						Status(http.StatusBadRequest)
+				})
+
+				Context("when the catalog is pinned to a previous version", func() {
+					BeforeEach(func() {
+						historyCtx.SMWithOAuth.PUT("/v1/service_brokers/"+historyBrokerID+"/pinned_catalog").
+							WithJSON(common.Object{"version": 1}).
+							Expect().
+							Status(http.StatusOK).
+							JSON().Object().ValueEqual("pinned_catalog_version", 1)
+					})
+
+					It("serves the pinned catalog", func() {
+						Expect(listServiceOfferingCatalogIDs()).To(ContainElement(removedServiceID))
+					})
+
+					It("keeps serving the pinned catalog when the broker is updated", func() {
+						historyCtx.SMWithOAuth.PATCH("/v1/service_brokers/"+historyBrokerID).
+							WithJSON(common.Object{}).
+							Expect().
+							Status(http.StatusOK).
+							JSON().Object().ValueEqual("pinned_catalog_version", 1)
+
+						Expect(listServiceOfferingCatalogIDs()).To(ContainElement(removedServiceID))
+					})
+
+					It("serves the latest catalog when it is unpinned", func() {
+						historyCtx.SMWithOAuth.DELETE("/v1/service_brokers/" + historyBrokerID + "/pinned_catalog").
+							Expect().
+							Status(http.StatusOK).
+							JSON().Object().NotContainsKey("pinned_catalog_version")
+
+						Expect(listServiceOfferingCatalogIDs()).ToNot(ContainElement(removedServiceID))
+					})
+				})
+
+				Context("when pinning a missing version", func() {
+					It("returns 404", func() {
+						historyCtx.SMWithOAuth.PUT("/v1/service_brokers/" + historyBrokerID + "/pinned_catalog").
+							WithJSON(common.Object{"version": 3}).
+							Expect().
+							Status(http.StatusNotFound)
+					})
+				})
+			})
+
+			Describe("periodic catalog resync", func() {
+				var (
+					resyncCtx          *common.TestContext
+					resyncBrokerID     string
+					resyncBrokerServer *common.BrokerServer
+				)
+
+				BeforeEach(func() {
+					resyncCtx = common.NewTestContextBuilder().WithEnvPostExtensions(func(e env.Environment, servers map[string]common.FakeServer) {
+						e.Set("api.catalog_resync_interval", "1s")
+					}).Build()
+				})
+
+				AfterEach(func() {
+					resyncCtx.Cleanup()
+				})
+
+				Context("when a new service offering is added to the broker catalog", func() {
+					var anotherServiceID string
+
+					BeforeEach(func() {
+						resyncBrokerID, _, resyncBrokerServer = resyncCtx.RegisterBroker()
+
+						anotherService := common.JSONToMap(common.GenerateTestServiceWithPlans())
+						anotherServiceID = anotherService["id"].(string)
+						currServices, err := sjson.Set(string(resyncBrokerServer.Catalog), "services.-1", anotherService)
+						Expect(err).ShouldNot(HaveOccurred())
+						resyncBrokerServer.Catalog = common.SBCatalog(currServices)
+					})
+
+					It("is eventually returned from the Services API", func() {
+						Eventually(func() []interface{} {
+							return resyncCtx.SMWithOAuth.GET("/v1/service_offerings").
+								WithQuery("fieldQuery", "broker_id = "+resyncBrokerID).
+								Expect().
+								Status(http.StatusOK).
+								JSON().Path("$.service_offerings[*].catalog_id").Array().Raw()
+						}, 5*time.Second, 200*time.Millisecond).Should(ContainElement(anotherServiceID))
+
+						resyncCtx.SMWithOAuth.GET("/v1/service_brokers/" + resyncBrokerID).
+							Expect().
+							Status(http.StatusOK).
+							JSON().Object().NotContainsKey("catalog_sync_error")
+					})
+				})
+
+				Context("when fetching the broker catalog fails", func() {
+					BeforeEach(func() {
+						resyncBrokerID, _, resyncBrokerServer = resyncCtx.RegisterBroker()
+						resyncBrokerServer.CatalogHandler = func(w http.ResponseWriter, req *http.Request) {
+							common.SetResponse(w, http.StatusInternalServerError, common.Object{})
+						}
+					})
+
+					It("records the error on the broker", func() {
+						Eventually(func() map[string]interface{} {
+							return resyncCtx.SMWithOAuth.GET("/v1/service_brokers/" + resyncBrokerID).
+								Expect().
+								Status(http.StatusOK).
+								JSON().Object().Raw()
+						}, 5*time.Second, 200*time.Millisecond).Should(HaveKey("catalog_sync_error"))
+					})
+
+					It("records the time of the failure without advancing the time of the latest sync", func() {
+						syncedAt := resyncCtx.SMWithOAuth.GET("/v1/service_brokers/" + resyncBrokerID).
+							Expect().
+							Status(http.StatusOK).
+							JSON().Object().Value("catalog_synced_at").String().Raw()
+
+						Eventually(func() map[string]interface{} {
+							return resyncCtx.SMWithOAuth.GET("/v1/service_brokers/" + resyncBrokerID).
+								Expect().
+								Status(http.StatusOK).
+								JSON().Object().Raw()
+						}, 5*time.Second, 200*time.Millisecond).Should(SatisfyAll(
+							HaveKey("catalog_sync_failed_at"),
+							HaveKeyWithValue("catalog_synced_at", syncedAt),
+						))
+					})
+				})
+
+				Context("when the broker disables the resync using a label", func() {
+					BeforeEach(func() {
+						resyncBrokerID, _, resyncBrokerServer = resyncCtx.RegisterBrokerWithCatalogAndLabels(common.NewRandomSBCatalog(), common.Object{
+							"catalog_resync_interval": common.Array{"0"},
+						})
+					})
+
+					It("does not fetch the broker catalog", func() {
+						Consistently(func() int {
+							return len(resyncBrokerServer.CatalogEndpointRequests)
+						}, 3*time.Second, 200*time.Millisecond).Should(Equal(0))
+					})
+				})
+			})
 		})
 	},
 })
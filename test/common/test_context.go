@@ -71,12 +71,19 @@ type TestContext struct {
 
 type testSMServer struct {
 	*httptest.Server
+
+	cancel context.CancelFunc
 }
 
 func (ts *testSMServer) URL() string {
 	return ts.Server.URL
 }
 
+func (ts *testSMServer) Close() {
+	ts.cancel()
+	ts.Server.Close()
+}
+
 // DefaultTestContext sets up a test context with default values
 func DefaultTestContext() *TestContext {
 	return NewTestContextBuilder().Build()
@@ -103,8 +110,10 @@ func NewTestContextBuilder() *TestContextBuilder {
 				})
 			},
 		},
-		smExtensions:       []func(ctx context.Context, smb *sm.ServiceManagerBuilder, env env.Environment) error{},
-		defaultTokenClaims: make(map[string]interface{}, 0),
+		smExtensions: []func(ctx context.Context, smb *sm.ServiceManagerBuilder, env env.Environment) error{},
+		defaultTokenClaims: map[string]interface{}{
+			"scope": []string{"service_manager.admin"},
+		},
 		Servers: map[string]FakeServer{
 			"oauth-server": NewOAuthServer(),
 		},
@@ -248,6 +257,7 @@ func newSMServer(smEnv env.Environment, fs []func(ctx context.Context, smb *sm.S
 	serviceManager := smb.Build()
 	return &testSMServer{
 		Server: httptest.NewServer(serviceManager.Server.Router),
+		cancel: cancel,
 	}
 }
 
@@ -0,0 +1,172 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package filter_test
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/env"
+	"github.com/Peripli/service-manager/test/common"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Service Manager Public Plans Policy", func() {
+	var ctx *common.TestContext
+	var publicPlanID string
+	var privatePlanID string
+
+	planIDByCatalogID := func(catalogID string) string {
+		return ctx.SMWithOAuth.GET("/v1/service_plans").WithQuery("fieldQuery", "catalog_id = "+catalogID).
+			Expect().
+			Status(http.StatusOK).JSON().Path("$.service_plans[0].id").String().Raw()
+	}
+
+	publicVisibilities := func(servicePlanID string) []interface{} {
+		return ctx.SMWithOAuth.GET("/v1/visibilities").WithQuery("fieldQuery", "service_plan_id = "+servicePlanID).
+			Expect().
+			Status(http.StatusOK).JSON().Path("$.visibilities[*].platform_id").Array().Raw()
+	}
+
+	BeforeEach(func() {
+		ctx = common.NewTestContextBuilder().WithEnvPostExtensions(func(e env.Environment, servers map[string]common.FakeServer) {
+			e.Set("api.public_plans_policy", "plan.metadata.public == true && broker.labels.env == 'prod'")
+			e.Set("api.public_plans_reconciliation_interval", "1s")
+		}).Build()
+
+		publicPlan, err := sjson.Set(common.GeneratePaidTestPlan(), "metadata.public", true)
+		Expect(err).ToNot(HaveOccurred())
+		privatePlan := common.GenerateFreeTestPlan()
+		catalog := common.NewEmptySBCatalog()
+		catalog.AddService(common.GenerateTestServiceWithPlans(publicPlan, privatePlan))
+		ctx.RegisterBrokerWithCatalogAndLabels(catalog, common.Object{
+			"env": common.Array{"prod"},
+		})
+
+		publicPlanID = planIDByCatalogID(gjson.Get(publicPlan, "id").Str)
+		privatePlanID = planIDByCatalogID(gjson.Get(privatePlan, "id").Str)
+	})
+
+	AfterEach(func() {
+		ctx.Cleanup()
+	})
+
+	It("creates public visibilities for the plans matching the policy on broker registration", func() {
+		Expect(publicVisibilities(publicPlanID)).To(ConsistOf(""))
+		Expect(publicVisibilities(privatePlanID)).To(BeEmpty())
+	})
+
+	It("periodically reconciles the public visibilities of the existing plans", func() {
+		visibilityID := ctx.SMWithOAuth.GET("/v1/visibilities").WithQuery("fieldQuery", "service_plan_id = "+publicPlanID).
+			Expect().
+			Status(http.StatusOK).JSON().Path("$.visibilities[0].id").String().Raw()
+		ctx.SMWithOAuth.DELETE("/v1/visibilities/" + visibilityID).
+			Expect().
+			Status(http.StatusOK)
+		ctx.SMWithOAuth.POST("/v1/visibilities").
+			WithJSON(common.Object{
+				"service_plan_id": privatePlanID,
+			}).
+			Expect().
+			Status(http.StatusCreated)
+
+		Eventually(func() []interface{} {
+			return publicVisibilities(publicPlanID)
+		}, 5*time.Second, 200*time.Millisecond).Should(ConsistOf(""))
+		Eventually(func() []interface{} {
+			return publicVisibilities(privatePlanID)
+		}, 5*time.Second, 200*time.Millisecond).Should(BeEmpty())
+	})
+})
+
+var _ = Describe("Service Manager Public Plans Policy without periodic reconciliation", func() {
+	var ctx *common.TestContext
+
+	BeforeEach(func() {
+		ctx = common.NewTestContextBuilder().WithEnvPostExtensions(func(e env.Environment, servers map[string]common.FakeServer) {
+			e.Set("api.public_plans_policy", "plan.metadata.public == true")
+		}).Build()
+	})
+
+	AfterEach(func() {
+		ctx.Cleanup()
+	})
+
+	It("creates public visibilities for the plans matching the policy when the catalog is refreshed", func() {
+		catalog := common.NewEmptySBCatalog()
+		catalog.AddService(common.GenerateTestServiceWithPlans(common.GenerateFreeTestPlan()))
+		brokerID, _, brokerServer := ctx.RegisterBrokerWithCatalog(catalog)
+
+		publicPlan, err := sjson.Set(common.GeneratePaidTestPlan(), "metadata.public", true)
+		Expect(err).ToNot(HaveOccurred())
+		catalog.AddService(common.GenerateTestServiceWithPlans(publicPlan))
+		brokerServer.Catalog = catalog
+		ctx.SMWithOAuth.POST("/v1/service_brokers/" + brokerID + "/refresh").
+			Expect().
+			Status(http.StatusOK)
+
+		publicPlanID := ctx.SMWithOAuth.GET("/v1/service_plans").WithQuery("fieldQuery", "catalog_id = "+gjson.Get(publicPlan, "id").Str).
+			Expect().
+			Status(http.StatusOK).JSON().Path("$.service_plans[0].id").String().Raw()
+		ctx.SMWithOAuth.GET("/v1/visibilities").WithQuery("fieldQuery", "service_plan_id = "+publicPlanID).
+			Expect().
+			Status(http.StatusOK).JSON().Path("$.visibilities[*].platform_id").Array().Equal(common.Array{""})
+	})
+
+	It("creates public visibilities for the plans matching the policy when the broker is registered asynchronously", func() {
+		publicPlan, err := sjson.Set(common.GeneratePaidTestPlan(), "metadata.public", true)
+		Expect(err).ToNot(HaveOccurred())
+		catalog := common.NewEmptySBCatalog()
+		catalog.AddService(common.GenerateTestServiceWithPlans(publicPlan))
+		brokerServer := common.NewBrokerServerWithCatalog(catalog)
+		ctx.Servers[common.BrokerServerPrefix+"async"] = brokerServer
+
+		location := ctx.SMWithOAuth.POST("/v1/service_brokers").
+			WithQuery("async", "true").
+			WithJSON(common.Object{
+				"name":       "async-broker",
+				"broker_url": brokerServer.URL(),
+				"credentials": common.Object{
+					"basic": common.Object{
+						"username": brokerServer.Username,
+						"password": brokerServer.Password,
+					},
+				},
+			}).
+			Expect().
+			Status(http.StatusAccepted).
+			Header("Location").Raw()
+
+		Eventually(func() interface{} {
+			return ctx.SMWithOAuth.GET(location).
+				Expect().
+				Status(http.StatusOK).
+				JSON().Object().Raw()["state"]
+		}, 5*time.Second, 200*time.Millisecond).Should(Equal("succeeded"))
+
+		publicPlanID := ctx.SMWithOAuth.GET("/v1/service_plans").WithQuery("fieldQuery", "catalog_id = "+gjson.Get(publicPlan, "id").Str).
+			Expect().
+			Status(http.StatusOK).JSON().Path("$.service_plans[0].id").String().Raw()
+		ctx.SMWithOAuth.GET("/v1/visibilities").WithQuery("fieldQuery", "service_plan_id = "+publicPlanID).
+			Expect().
+			Status(http.StatusOK).JSON().Path("$.visibilities[*].platform_id").Array().Equal(common.Array{""})
+	})
+})
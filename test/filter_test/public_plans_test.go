@@ -19,6 +19,7 @@ package filter_test
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/Peripli/service-manager/pkg/env"
 
@@ -87,16 +88,18 @@ var _ = Describe("Service Manager Public Plans Filter", func() {
 		return planID
 	}
 
+	registerPublicPlansFilter := func(ctx context.Context, smb *sm.ServiceManagerBuilder, e env.Environment) error {
+		smb.RegisterFilters(&filters.PublicServicePlansFilter{
+			Repository: smb.Storage,
+			IsCatalogPlanPublicFunc: func(broker *types.Broker, catalogService *types.ServiceOffering, catalogPlan *types.ServicePlan) (b bool, e error) {
+				return catalogPlan.Free, nil
+			},
+		})
+		return nil
+	}
+
 	BeforeSuite(func() {
-		ctx = common.NewTestContextBuilder().WithSMExtensions(func(ctx context.Context, smb *sm.ServiceManagerBuilder, e env.Environment) error {
-			smb.RegisterFilters(&filters.PublicServicePlansFilter{
-				Repository: smb.Storage,
-				IsCatalogPlanPublicFunc: func(broker *types.Broker, catalogService *types.ServiceOffering, catalogPlan *types.ServicePlan) (b bool, e error) {
-					return catalogPlan.Free, nil
-				},
-			})
-			return nil
-		}).Build()
+		ctx = common.NewTestContextBuilder().WithSMExtensions(registerPublicPlansFilter).Build()
 	})
 
 	AfterSuite(func() {
@@ -348,4 +351,136 @@ var _ = Describe("Service Manager Public Plans Filter", func() {
 			Expect(visibilities["platform_id"]).To(Equal(""))
 		})
 	})
+
+	Context("when pinning a catalog version that contains a removed public plan", func() {
+		BeforeEach(func() {
+			catalog, err := sjson.Delete(testCatalog, "services.0.plans.0")
+			Expect(err).ShouldNot(HaveOccurred())
+			existingBrokerServer.Catalog = common.SBCatalog(catalog)
+
+			ctx.SMWithOAuth.PATCH("/v1/service_brokers/" + existingBrokerID).
+				WithJSON(common.Object{}).
+				Expect().
+				Status(http.StatusOK)
+			ctx.SMWithOAuth.GET("/v1/service_plans").WithQuery("fieldQuery", "catalog_name = "+oldPublicPlanCatalogName).
+				Expect().
+				Status(http.StatusOK).JSON().Object().Value("service_plans").Array().Empty()
+		})
+
+		It("creates a public visibility for the plan brought back by the pinned version", func() {
+			ctx.SMWithOAuth.PUT("/v1/service_brokers/" + existingBrokerID + "/pinned_catalog").
+				WithJSON(common.Object{"version": 1}).
+				Expect().
+				Status(http.StatusOK)
+
+			planID := findDatabaseIDForServicePlanByCatalogName(oldPublicPlanCatalogName)
+			visibility := findOneVisibilityForServicePlanID(planID)
+			Expect(visibility["platform_id"]).To(Equal(""))
+		})
+	})
+
+	Context("when a new public plan is added and the catalog is refreshed", func() {
+		BeforeEach(func() {
+			s, err := sjson.Set(testCatalog, "services.0.plans.-1", common.JSONToMap(newPublicPlan))
+			Expect(err).ShouldNot(HaveOccurred())
+			existingBrokerServer.Catalog = common.SBCatalog(s)
+		})
+
+		It("creates a public visibility for the plan", func() {
+			ctx.SMWithOAuth.POST("/v1/service_brokers/" + existingBrokerID + "/refresh").
+				Expect().
+				Status(http.StatusOK)
+
+			planID := findDatabaseIDForServicePlanByCatalogName(newPublicPlanCatalogName)
+			visibility := findOneVisibilityForServicePlanID(planID)
+			Expect(visibility["platform_id"]).To(Equal(""))
+		})
+	})
+
+	Context("when a new public plan is added to a static catalog", func() {
+		var staticBrokerID string
+		var staticCatalog common.SBCatalog
+
+		BeforeEach(func() {
+			staticCatalog = common.NewEmptySBCatalog()
+			staticCatalog.AddService(common.GenerateTestServiceWithPlans(common.GeneratePaidTestPlan()))
+			staticBrokerID = ctx.SMWithOAuth.POST("/v1/service_brokers").
+				WithJSON(common.Object{
+					"name":       "public-plans-static-broker",
+					"broker_url": existingBrokerServer.URL(),
+					"credentials": common.Object{
+						"basic": common.Object{
+							"username": existingBrokerServer.Username,
+							"password": existingBrokerServer.Password,
+						},
+					},
+					"static_catalog": common.JSONToMap(string(staticCatalog)),
+				}).
+				Expect().
+				Status(http.StatusCreated).JSON().Object().Value("id").String().Raw()
+		})
+
+		AfterEach(func() {
+			ctx.SMWithOAuth.DELETE("/v1/service_brokers/" + staticBrokerID).
+				Expect().
+				Status(http.StatusOK)
+		})
+
+		It("creates a public visibility for the plan", func() {
+			publicPlan := common.GenerateFreeTestPlan()
+			s, err := sjson.Set(string(staticCatalog), "services.0.plans.-1", common.JSONToMap(publicPlan))
+			Expect(err).ShouldNot(HaveOccurred())
+
+			ctx.SMWithOAuth.PUT("/v1/service_brokers/" + staticBrokerID + "/static_catalog").
+				WithJSON(common.JSONToMap(s)).
+				Expect().
+				Status(http.StatusOK)
+
+			planID := findDatabaseIDForServicePlanByCatalogName(gjson.Get(publicPlan, "name").Str)
+			visibility := findOneVisibilityForServicePlanID(planID)
+			Expect(visibility["platform_id"]).To(Equal(""))
+		})
+	})
+
+	Context("when a new public plan is added and the catalog is resynced periodically", func() {
+		var resyncCtx *common.TestContext
+
+		BeforeEach(func() {
+			resyncCtx = common.NewTestContextBuilder().WithEnvPostExtensions(func(e env.Environment, servers map[string]common.FakeServer) {
+				e.Set("api.catalog_resync_interval", "1s")
+			}).WithSMExtensions(registerPublicPlansFilter).Build()
+		})
+
+		AfterEach(func() {
+			resyncCtx.Cleanup()
+		})
+
+		It("eventually creates a public visibility for the plan", func() {
+			catalog := common.NewEmptySBCatalog()
+			service := common.GenerateTestServiceWithPlans(common.GeneratePaidTestPlan())
+			catalog.AddService(service)
+			_, _, brokerServer := resyncCtx.RegisterBrokerWithCatalog(catalog)
+
+			publicPlan := common.GenerateFreeTestPlan()
+			publicPlanCatalogID := gjson.Get(publicPlan, "id").Str
+			resyncedCatalog, err := sjson.Set(string(catalog), "services.0.plans.-1", common.JSONToMap(publicPlan))
+			Expect(err).ShouldNot(HaveOccurred())
+			brokerServer.Catalog = common.SBCatalog(resyncedCatalog)
+
+			var planID string
+			Eventually(func() []interface{} {
+				plans := resyncCtx.SMWithOAuth.GET("/v1/service_plans").WithQuery("fieldQuery", "catalog_id = "+publicPlanCatalogID).
+					Expect().
+					Status(http.StatusOK).JSON().Path("$.service_plans[*].id").Array().Raw()
+				if len(plans) == 1 {
+					planID = plans[0].(string)
+				}
+				return plans
+			}, 5*time.Second, 200*time.Millisecond).Should(HaveLen(1))
+
+			resyncCtx.SMWithOAuth.GET("/v1/visibilities").WithQuery("fieldQuery", "service_plan_id = "+planID).
+				Expect().
+				Status(http.StatusOK).JSON().Path("$.visibilities[*].platform_id").Array().Equal([]interface{}{""})
+		})
+	})
 })
@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/Peripli/service-manager/api/healthcheck"
+	"github.com/Peripli/service-manager/pkg/env"
 	"github.com/Peripli/service-manager/test/common"
 	. "github.com/onsi/ginkgo"
 )
@@ -50,4 +51,46 @@ var _ = Describe("Healthcheck API", func() {
 			})
 		})
 	})
+
+	Describe("broker health indicators", func() {
+		var (
+			brokersCtx   *common.TestContext
+			brokerID     string
+			brokerServer *common.BrokerServer
+		)
+
+		BeforeEach(func() {
+			brokersCtx = common.NewTestContextBuilder().WithEnvPostExtensions(func(e env.Environment, servers map[string]common.FakeServer) {
+				e.Set("api.broker_health_indicators", true)
+			}).Build()
+			brokerID, _, brokerServer = brokersCtx.RegisterBroker()
+		})
+
+		AfterEach(func() {
+			brokersCtx.Cleanup()
+		})
+
+		It("reports the registered brokers", func() {
+			brokersCtx.SM.GET(healthcheck.URL).
+				Expect().
+				Status(http.StatusOK).
+				JSON().Path("$.details.brokers.status").Equal("UP")
+		})
+
+		It("reports DOWN when the share of failing brokers reaches the threshold", func() {
+			brokerServer.CatalogHandler = func(w http.ResponseWriter, req *http.Request) {
+				common.SetResponse(w, http.StatusInternalServerError, common.Object{})
+			}
+			brokersCtx.SMWithOAuth.POST("/v1/service_brokers/" + brokerID + "/refresh").
+				Expect().
+				Status(http.StatusBadRequest)
+
+			health := brokersCtx.SM.GET(healthcheck.URL).
+				Expect().
+				Status(http.StatusServiceUnavailable).
+				JSON()
+			health.Path("$.status").Equal("DOWN")
+			health.Path("$.details.brokers.status").Equal("DOWN")
+		})
+	})
 })
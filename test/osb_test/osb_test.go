@@ -24,6 +24,7 @@ import (
 
 	"github.com/Peripli/service-manager/test/common"
 	"github.com/gavv/httpexpect"
+	"github.com/tidwall/gjson"
 
 	"testing"
 
@@ -596,6 +597,167 @@ var _ = Describe("Service Manager OSB API", func() {
 		})
 	})
 
+	Describe("Aggregated broker", func() {
+		const aggregatedURL = "/v1/osb/aggregated"
+
+		var (
+			aggregatedBrokerID     string
+			aggregatedBrokerServer *common.BrokerServer
+			serviceID              string
+			visiblePlanID          string
+			visiblePlanCatalogID   string
+			hiddenPlanID           string
+			instanceID             string
+		)
+
+		provision := func(planID string) *httpexpect.Response {
+			return ctx.SMWithBasic.PUT(aggregatedURL+"/v2/service_instances/"+instanceID).
+				WithHeader("X-Broker-API-Version", "2.13").
+				WithJSON(common.Object{
+					"service_id":        serviceID,
+					"plan_id":           planID,
+					"organization_guid": "orgguid",
+					"space_guid":        "spaceguid",
+				}).
+				Expect()
+		}
+
+		BeforeEach(func() {
+			aggregatedBrokerID, _, aggregatedBrokerServer = ctx.RegisterBroker()
+			serviceID = ctx.SMWithOAuth.GET("/v1/service_offerings").
+				WithQuery("fieldQuery", "broker_id = "+aggregatedBrokerID).
+				Expect().
+				Status(http.StatusOK).
+				JSON().Path("$.service_offerings[0].id").String().Raw()
+			plans := ctx.SMWithOAuth.GET("/v1/service_plans").
+				WithQuery("fieldQuery", "service_offering_id = "+serviceID).
+				Expect().
+				Status(http.StatusOK).
+				JSON().Object().Value("service_plans").Array()
+			visiblePlanID = plans.Element(0).Object().Value("id").String().Raw()
+			visiblePlanCatalogID = plans.Element(0).Object().Value("catalog_id").String().Raw()
+			hiddenPlanID = plans.Element(1).Object().Value("id").String().Raw()
+
+			ctx.SMWithOAuth.POST("/v1/visibilities").
+				WithJSON(common.Object{
+					"service_plan_id": visiblePlanID,
+					"platform_id":     ctx.TestPlatform.ID,
+				}).
+				Expect().
+				Status(http.StatusCreated)
+
+			UUID, err := uuid.NewV4()
+			Expect(err).ToNot(HaveOccurred())
+			instanceID = UUID.String()
+		})
+
+		AfterEach(func() {
+			ctx.CleanupBroker(aggregatedBrokerID)
+		})
+
+		It("serves the plans visible to the platform with SM generated ids", func() {
+			services := ctx.SMWithBasic.GET(aggregatedURL+"/v2/catalog").
+				WithHeader("X-Broker-API-Version", "2.13").
+				Expect().
+				Status(http.StatusOK).
+				JSON().Object().Value("services").Array()
+
+			var planIDs []interface{}
+			for _, service := range services.Iter() {
+				if service.Object().Value("id").String().Raw() != serviceID {
+					continue
+				}
+				for _, plan := range service.Object().Value("plans").Array().Iter() {
+					planIDs = append(planIDs, plan.Object().Value("id").Raw())
+				}
+			}
+			Expect(planIDs).To(ConsistOf(visiblePlanID))
+			Expect(len(aggregatedBrokerServer.CatalogEndpointRequests)).To(Equal(0))
+		})
+
+		It("routes provisioning to the broker of the plan using its catalog ids", func() {
+			provision(visiblePlanID).Status(http.StatusCreated)
+
+			Expect(len(aggregatedBrokerServer.ServiceInstanceEndpointRequests)).To(Equal(1))
+			Expect(gjson.GetBytes(aggregatedBrokerServer.LastRequestBody, "plan_id").String()).To(Equal(visiblePlanCatalogID))
+		})
+
+		It("rejects provisioning of a plan that is not visible to the platform", func() {
+			provision(hiddenPlanID).Status(http.StatusBadRequest)
+
+			Expect(len(aggregatedBrokerServer.ServiceInstanceEndpointRequests)).To(Equal(0))
+		})
+
+		It("rejects provisioning of a public plan that is denied to the platform", func() {
+			ctx.SMWithOAuth.POST("/v1/visibilities").
+				WithJSON(common.Object{
+					"service_plan_id": hiddenPlanID,
+				}).
+				Expect().
+				Status(http.StatusCreated)
+			ctx.SMWithOAuth.POST("/v1/visibilities").
+				WithJSON(common.Object{
+					"service_plan_id": hiddenPlanID,
+					"platform_id":     ctx.TestPlatform.ID,
+					"effect":          "deny",
+				}).
+				Expect().
+				Status(http.StatusCreated)
+
+			provision(hiddenPlanID).Status(http.StatusBadRequest)
+
+			Expect(len(aggregatedBrokerServer.ServiceInstanceEndpointRequests)).To(Equal(0))
+		})
+
+		It("routes the calls for a provisioned instance to its broker", func() {
+			provision(visiblePlanID).Status(http.StatusCreated)
+
+			ctx.SMWithBasic.GET(aggregatedURL+"/v2/service_instances/"+instanceID+"/last_operation").
+				WithHeader("X-Broker-API-Version", "2.13").
+				WithQuery("plan_id", visiblePlanID).
+				Expect().
+				Status(http.StatusOK)
+			Expect(len(aggregatedBrokerServer.ServiceInstanceLastOpEndpointRequests)).To(Equal(1))
+			Expect(aggregatedBrokerServer.ServiceInstanceLastOpEndpointRequests[0].URL.Query().Get("plan_id")).To(Equal(visiblePlanCatalogID))
+
+			ctx.SMWithBasic.PUT(aggregatedURL+"/v2/service_instances/"+instanceID+"/service_bindings/binding-id").
+				WithHeader("X-Broker-API-Version", "2.13").
+				WithJSON(common.Object{
+					"service_id": serviceID,
+					"plan_id":    visiblePlanID,
+				}).
+				Expect().
+				Status(http.StatusCreated).
+				JSON().Object().ContainsKey("credentials")
+			Expect(len(aggregatedBrokerServer.BindingEndpointRequests)).To(Equal(1))
+		})
+
+		It("forgets a deprovisioned instance", func() {
+			provision(visiblePlanID).Status(http.StatusCreated)
+
+			ctx.SMWithBasic.DELETE(aggregatedURL+"/v2/service_instances/"+instanceID).
+				WithHeader("X-Broker-API-Version", "2.13").
+				WithQuery("service_id", serviceID).
+				WithQuery("plan_id", visiblePlanID).
+				Expect().
+				Status(http.StatusOK)
+
+			ctx.SMWithBasic.GET(aggregatedURL+"/v2/service_instances/"+instanceID+"/last_operation").
+				WithHeader("X-Broker-API-Version", "2.13").
+				Expect().
+				Status(http.StatusNotFound)
+		})
+
+		It("returns gone when deprovisioning an unknown instance", func() {
+			ctx.SMWithBasic.DELETE(aggregatedURL+"/v2/service_instances/"+instanceID).
+				WithHeader("X-Broker-API-Version", "2.13").
+				Expect().
+				Status(http.StatusGone)
+
+			Expect(len(aggregatedBrokerServer.ServiceInstanceEndpointRequests)).To(Equal(0))
+		})
+	})
+
 })
 
 type prefixedBrokerHandler struct {
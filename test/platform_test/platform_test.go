@@ -18,6 +18,7 @@ package platform_test
 
 import (
 	"net/http"
+	"sync"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -25,6 +26,7 @@ import (
 	"github.com/Peripli/service-manager/test"
 
 	"github.com/Peripli/service-manager/test/common"
+	"github.com/gavv/httpexpect"
 	. "github.com/onsi/ginkgo"
 )
 
@@ -36,7 +38,7 @@ func TestPlatforms(t *testing.T) {
 
 var _ = test.DescribeTestsFor(test.TestCase{
 	API:            "/v1/platforms",
-	SupportsLabels: false,
+	SupportsLabels: true,
 	SupportedOps: []test.Op{
 		test.Get, test.List, test.Delete, test.DeleteList,
 	},
@@ -282,6 +284,94 @@ var _ = test.DescribeTestsFor(test.TestCase{
 					})
 				})
 			})
+
+			Describe("credentials rotation", func() {
+				var (
+					platformID  string
+					credentials *httpexpect.Object
+				)
+
+				canAuthenticate := func(credentials *httpexpect.Object) int {
+					return ctx.SM.GET("/v1/visibilities").
+						WithBasicAuth(
+							credentials.Path("$.basic.username").String().Raw(),
+							credentials.Path("$.basic.password").String().Raw()).
+						Expect().
+						Raw().StatusCode
+				}
+
+				BeforeEach(func() {
+					platform := ctx.SMWithOAuth.POST("/v1/platforms").
+						WithJSON(common.GenerateRandomPlatform()).
+						Expect().
+						Status(http.StatusCreated).
+						JSON().Object()
+					platformID = platform.Value("id").String().Raw()
+					credentials = platform.Value("credentials").Object()
+				})
+
+				It("returns new credentials and invalidates the current ones", func() {
+					newCredentials := ctx.SMWithOAuth.POST("/v1/platforms/" + platformID + "/credentials/rotate").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Object().Value("credentials").Object()
+					newCredentials.Path("$.basic.username").NotEqual(credentials.Path("$.basic.username").Raw())
+
+					Expect(canAuthenticate(newCredentials)).To(Equal(http.StatusOK))
+					Expect(canAuthenticate(credentials)).To(Equal(http.StatusUnauthorized))
+
+					ctx.SMWithOAuth.GET("/v1/platforms/" + platformID).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Object().NotContainsKey("credentials")
+				})
+
+				It("keeps the current credentials valid during the overlap", func() {
+					newCredentials := ctx.SMWithOAuth.POST("/v1/platforms/" + platformID + "/credentials/rotate").
+						WithJSON(common.Object{"overlap": "1h"}).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Object().Value("credentials").Object()
+
+					Expect(canAuthenticate(newCredentials)).To(Equal(http.StatusOK))
+					Expect(canAuthenticate(credentials)).To(Equal(http.StatusOK))
+				})
+
+				It("keeps the credentials of concurrent rotations valid during the overlap", func() {
+					rotatedCredentials := make([]*httpexpect.Object, 2)
+					wg := sync.WaitGroup{}
+					for i := range rotatedCredentials {
+						wg.Add(1)
+						go func(i int) {
+							defer GinkgoRecover()
+							defer wg.Done()
+							rotatedCredentials[i] = ctx.SMWithOAuth.POST("/v1/platforms/" + platformID + "/credentials/rotate").
+								WithJSON(common.Object{"overlap": "1h"}).
+								Expect().
+								Status(http.StatusOK).
+								JSON().Object().Value("credentials").Object()
+						}(i)
+					}
+					wg.Wait()
+
+					for _, credentials := range rotatedCredentials {
+						Expect(canAuthenticate(credentials)).To(Equal(http.StatusOK))
+					}
+				})
+
+				It("returns 400 for an invalid overlap", func() {
+					ctx.SMWithOAuth.POST("/v1/platforms/" + platformID + "/credentials/rotate").
+						WithJSON(common.Object{"overlap": "one hour"}).
+						Expect().
+						Status(http.StatusBadRequest)
+				})
+
+				It("returns 404 for a missing platform", func() {
+					ctx.SMWithOAuth.POST("/v1/platforms/missing/credentials/rotate").
+						Expect().
+						Status(http.StatusNotFound)
+				})
+			})
 		})
 	},
 })
@@ -0,0 +1,168 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package report_test
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Peripli/service-manager/api/report"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/test/common"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestReports(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Reports Tests Suite")
+}
+
+var _ = Describe("Visibility matrix report", func() {
+	var (
+		ctx *common.TestContext
+
+		brokerID         string
+		publicPlanID     string
+		restrictedPlanID string
+		platform         *types.Platform
+		otherPlatform    *types.Platform
+	)
+
+	BeforeSuite(func() {
+		ctx = common.DefaultTestContext()
+	})
+
+	AfterSuite(func() {
+		ctx.Cleanup()
+	})
+
+	BeforeEach(func() {
+		brokerID, _, _ = ctx.RegisterBroker()
+		planIDs := ctx.SMWithOAuth.GET("/v1/service_plans").
+			Expect().
+			Status(http.StatusOK).
+			JSON().Path("$.service_plans[*].id").Array()
+		publicPlanID = planIDs.Element(0).String().Raw()
+		restrictedPlanID = planIDs.Element(1).String().Raw()
+
+		platformJSON := common.GenerateRandomPlatform()
+		platformJSON["labels"] = common.Object{"report": common.Array{"matrix"}}
+		platform = common.RegisterPlatformInSM(platformJSON, ctx.SMWithOAuth)
+		otherPlatform = ctx.RegisterPlatform()
+
+		common.RemoveAllVisibilities(ctx.SMWithOAuth)
+		ctx.SMWithOAuth.POST("/v1/visibilities").
+			WithJSON(common.Object{"service_plan_id": publicPlanID}).
+			Expect().
+			Status(http.StatusCreated)
+		ctx.SMWithOAuth.POST("/v1/visibilities").
+			WithJSON(common.Object{"service_plan_id": restrictedPlanID, "platform_id": platform.ID}).
+			Expect().
+			Status(http.StatusCreated)
+	})
+
+	AfterEach(func() {
+		ctx.CleanupBroker(brokerID)
+		ctx.CleanupAdditionalResources()
+	})
+
+	It("requires authentication", func() {
+		ctx.SM.GET(report.VisibilityMatrixURL).
+			Expect().
+			Status(http.StatusUnauthorized)
+	})
+
+	It("returns the plans visible to the selected platforms", func() {
+		entries := ctx.SMWithOAuth.GET(report.VisibilityMatrixURL).
+			WithQuery("platforms", "report = matrix").
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("entries").Array()
+
+		planIDs := make([]string, 0)
+		for _, entry := range entries.Iter() {
+			object := entry.Object().
+				ValueEqual("platform_id", platform.ID).
+				ValueEqual("platform_name", platform.Name).
+				ValueEqual("broker_id", brokerID)
+			planID := object.Value("service_plan_id").String().Raw()
+			object.ValueEqual("public", planID == publicPlanID)
+			planIDs = append(planIDs, planID)
+		}
+		Expect(planIDs).To(ConsistOf(publicPlanID, restrictedPlanID))
+	})
+
+	It("returns the platforms that can see the selected plans", func() {
+		ctx.SMWithOAuth.GET(report.VisibilityMatrixURL).
+			WithQuery("service_plans", "id = "+restrictedPlanID).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Path("$.entries[*].platform_id").Array().ContainsOnly(platform.ID)
+
+		ctx.SMWithOAuth.GET(report.VisibilityMatrixURL).
+			WithQuery("service_plans", "id = "+publicPlanID).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Path("$.entries[*].platform_id").Array().Contains(platform.ID, otherPlatform.ID, ctx.TestPlatform.ID)
+	})
+
+	It("leaves out the plans hidden by catalog overrides", func() {
+		ctx.SMWithOAuth.PUT("/v1/service_plans/" + restrictedPlanID + "/override").
+			WithJSON(common.Object{"hidden": true}).
+			Expect().
+			Status(http.StatusCreated)
+
+		ctx.SMWithOAuth.GET(report.VisibilityMatrixURL).
+			WithQuery("platforms", "report = matrix").
+			Expect().
+			Status(http.StatusOK).
+			JSON().Path("$.entries[*].service_plan_id").Array().ContainsOnly(publicPlanID)
+	})
+
+	It("returns the report as CSV", func() {
+		body := ctx.SMWithOAuth.GET(report.VisibilityMatrixURL).
+			WithQuery("platforms", "report = matrix").
+			WithQuery("format", "csv").
+			Expect().
+			Status(http.StatusOK).
+			ContentType("text/csv").
+			Body().Raw()
+
+		records, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records).To(HaveLen(3))
+		Expect(records[0][0]).To(Equal("platform_id"))
+		Expect(records[1][0]).To(Equal(platform.ID))
+	})
+
+	It("returns 400 for an unsupported format", func() {
+		ctx.SMWithOAuth.GET(report.VisibilityMatrixURL).
+			WithQuery("format", "xml").
+			Expect().
+			Status(http.StatusBadRequest)
+	})
+
+	It("returns 400 for an invalid selector", func() {
+		ctx.SMWithOAuth.GET(report.VisibilityMatrixURL).
+			WithQuery("platforms", "report matrix").
+			Expect().
+			Status(http.StatusBadRequest)
+	})
+})
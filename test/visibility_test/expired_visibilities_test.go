@@ -0,0 +1,72 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package visibility_test
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/env"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/test/common"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Expired visibilities cleanup", func() {
+	var ctx *common.TestContext
+
+	BeforeEach(func() {
+		ctx = common.NewTestContextBuilder().WithEnvPostExtensions(func(e env.Environment, servers map[string]common.FakeServer) {
+			e.Set("api.expired_visibilities_cleanup_interval", "1s")
+		}).Build()
+	})
+
+	AfterEach(func() {
+		ctx.Cleanup()
+	})
+
+	It("deletes the expired visibilities and keeps the others", func() {
+		ctx.RegisterBroker()
+		servicePlanID := ctx.SMWithOAuth.GET("/v1/service_plans").
+			Expect().
+			Status(http.StatusOK).JSON().Path("$.service_plans[0].id").String().Raw()
+
+		expiredID := ctx.SMWithOAuth.POST("/v1/visibilities").
+			WithJSON(common.Object{
+				"service_plan_id": servicePlanID,
+				"expires_at":      util.ToRFCFormat(time.Now().Add(-time.Minute)),
+			}).
+			Expect().
+			Status(http.StatusCreated).JSON().Object().Value("id").String().Raw()
+		validID := ctx.SMWithOAuth.POST("/v1/visibilities").
+			WithJSON(common.Object{
+				"service_plan_id": servicePlanID,
+				"expires_at":      util.ToRFCFormat(time.Now().Add(time.Hour)),
+			}).
+			Expect().
+			Status(http.StatusCreated).JSON().Object().Value("id").String().Raw()
+
+		Eventually(func() int {
+			return ctx.SMWithOAuth.GET("/v1/visibilities/" + expiredID).Expect().Raw().StatusCode
+		}, 5*time.Second, 200*time.Millisecond).Should(Equal(http.StatusNotFound))
+		ctx.SMWithOAuth.GET("/v1/visibilities/" + validID).
+			Expect().
+			Status(http.StatusOK)
+	})
+})
@@ -20,13 +20,16 @@ import (
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/Peripli/service-manager/pkg/query"
 	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
 
 	"github.com/Peripli/service-manager/test"
 
 	"github.com/Peripli/service-manager/test/common"
+	"github.com/gavv/httpexpect"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -295,6 +298,471 @@ var _ = test.DescribeTestsFor(test.TestCase{
 				})
 			})
 
+			Describe("platform selector", func() {
+				var selectorVisibility common.Object
+
+				registerPlatformWithLabels := func(labels common.Object) (*types.Platform, *httpexpect.Expect) {
+					platformJSON := common.GenerateRandomPlatform()
+					platformJSON["labels"] = labels
+					platform := common.RegisterPlatformInSM(platformJSON, ctx.SMWithOAuth)
+					platformClient := ctx.SM.Builder(func(req *httpexpect.Request) {
+						req.WithBasicAuth(platform.Credentials.Basic.Username, platform.Credentials.Basic.Password)
+					})
+					return platform, platformClient
+				}
+
+				BeforeEach(func() {
+					selectorVisibility = common.Object{
+						"service_plan_id":   existingPlanIDs[0],
+						"platform_selector": "region in [eu10||eu20]",
+					}
+				})
+
+				It("returns 400 when the selector is invalid", func() {
+					selectorVisibility["platform_selector"] = "region in eu10"
+					ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(selectorVisibility).
+						Expect().
+						Status(http.StatusBadRequest)
+				})
+
+				It("returns 400 when a platform id is also provided", func() {
+					selectorVisibility["platform_id"] = existingPlatformID
+					ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(selectorVisibility).
+						Expect().
+						Status(http.StatusBadRequest)
+				})
+
+				It("makes the plan visible only to the platforms with matching labels", func() {
+					ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(selectorVisibility).
+						Expect().
+						Status(http.StatusCreated).
+						JSON().Object().ContainsMap(selectorVisibility)
+
+					euPlatform, euPlatformClient := registerPlatformWithLabels(common.Object{
+						"region": common.Array{"eu10"},
+					})
+					_, usPlatformClient := registerPlatformWithLabels(common.Object{
+						"region": common.Array{"us10"},
+					})
+
+					visibilities := euPlatformClient.GET("/v1/visibilities").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.visibilities").Array()
+					visibilities.Length().Equal(1)
+					visibilities.First().Object().
+						ValueEqual("service_plan_id", existingPlanIDs[0]).
+						ValueEqual("platform_id", euPlatform.ID)
+
+					usPlatformClient.GET("/v1/visibilities").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.visibilities").Array().Empty()
+				})
+
+				It("applies to platforms whose labels start matching the selector", func() {
+					ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(selectorVisibility).
+						Expect().
+						Status(http.StatusCreated)
+
+					platform, platformClient := registerPlatformWithLabels(common.Object{
+						"region": common.Array{"us10"},
+					})
+					platformClient.GET("/v1/visibilities").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.visibilities").Array().Empty()
+
+					ctx.SMWithOAuth.PATCH("/v1/platforms/" + platform.ID).
+						WithJSON(common.Object{
+							"labels": common.Array{
+								common.Object{
+									"op":     "add",
+									"key":    "region",
+									"values": common.Array{"eu20"},
+								},
+							},
+						}).
+						Expect().
+						Status(http.StatusOK)
+
+					platformClient.GET("/v1/visibilities").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.visibilities[*].service_plan_id").Array().ContainsOnly(existingPlanIDs[0])
+				})
+			})
+
+			Describe("service offering", func() {
+				var (
+					serviceOfferingID string
+					offeringPlanIDs   []interface{}
+				)
+
+				BeforeEach(func() {
+					serviceOfferingID = ctx.SMWithOAuth.GET("/v1/service_plans/" + existingPlanIDs[0].(string)).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Object().Value("service_offering_id").String().Raw()
+					offeringPlanIDs = ctx.SMWithOAuth.GET("/v1/service_plans").
+						WithQuery("fieldQuery", "service_offering_id = "+serviceOfferingID).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.service_plans[*].id").Array().Raw()
+				})
+
+				It("returns 201 for a visibility of the service offering", func() {
+					offeringVisibility := common.Object{
+						"platform_id":         existingPlatformID,
+						"service_offering_id": serviceOfferingID,
+					}
+					ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(offeringVisibility).
+						Expect().
+						Status(http.StatusCreated).
+						JSON().Object().ContainsMap(offeringVisibility).NotContainsKey("service_plan_id")
+				})
+
+				It("returns 400 when a service plan id is also provided", func() {
+					ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(common.Object{
+							"platform_id":         existingPlatformID,
+							"service_plan_id":     existingPlanIDs[0],
+							"service_offering_id": serviceOfferingID,
+						}).
+						Expect().
+						Status(http.StatusBadRequest)
+				})
+
+				It("returns 400 when a public visibility of the service offering exists", func() {
+					ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(common.Object{"service_offering_id": serviceOfferingID}).
+						Expect().
+						Status(http.StatusCreated)
+
+					ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(postVisibilityRequestNoLabels).
+						Expect().
+						Status(http.StatusBadRequest)
+				})
+
+				It("returns 400 for a public visibility when visibilities for plans of the service offering exist", func() {
+					ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(postVisibilityRequestNoLabels).
+						Expect().
+						Status(http.StatusCreated)
+
+					ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(common.Object{"service_offering_id": serviceOfferingID}).
+						Expect().
+						Status(http.StatusBadRequest)
+				})
+
+				It("reports a visibility for each plan of the service offering to the platform", func() {
+					ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(common.Object{
+							"platform_id":         existingPlatformID,
+							"service_offering_id": serviceOfferingID,
+						}).
+						Expect().
+						Status(http.StatusCreated)
+
+					ctx.SMWithBasic.GET("/v1/visibilities").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.visibilities[*].service_plan_id").Array().ContainsOnly(offeringPlanIDs...)
+				})
+			})
+
+			Describe("validity period", func() {
+				It("returns 400 when expires_at is not after valid_from", func() {
+					validFrom := time.Now().Add(time.Hour)
+					postVisibilityRequestNoLabels["valid_from"] = util.ToRFCFormat(validFrom)
+					postVisibilityRequestNoLabels["expires_at"] = util.ToRFCFormat(validFrom.Add(-time.Minute))
+					ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(postVisibilityRequestNoLabels).
+						Expect().
+						Status(http.StatusBadRequest)
+				})
+
+				It("does not report expired visibilities to the platform", func() {
+					postVisibilityRequestNoLabels["expires_at"] = util.ToRFCFormat(time.Now().Add(-time.Minute))
+					ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(postVisibilityRequestNoLabels).
+						Expect().
+						Status(http.StatusCreated).
+						JSON().Object().ContainsKey("expires_at")
+
+					ctx.SMWithBasic.GET("/v1/visibilities").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.visibilities").Array().Empty()
+				})
+
+				It("does not report visibilities that are not valid yet to the platform", func() {
+					postVisibilityRequestNoLabels["valid_from"] = util.ToRFCFormat(time.Now().Add(time.Hour))
+					ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(postVisibilityRequestNoLabels).
+						Expect().
+						Status(http.StatusCreated)
+
+					ctx.SMWithBasic.GET("/v1/visibilities").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.visibilities").Array().Empty()
+				})
+
+				It("reports visibilities within their validity period to the platform", func() {
+					postVisibilityRequestNoLabels["valid_from"] = util.ToRFCFormat(time.Now().Add(-time.Hour))
+					postVisibilityRequestNoLabels["expires_at"] = util.ToRFCFormat(time.Now().Add(time.Hour))
+					ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(postVisibilityRequestNoLabels).
+						Expect().
+						Status(http.StatusCreated)
+
+					ctx.SMWithBasic.GET("/v1/visibilities").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.visibilities[*].service_plan_id").Array().ContainsOnly(existingPlanIDs[0])
+				})
+			})
+
+			Describe("deny", func() {
+				var denyVisibility common.Object
+
+				BeforeEach(func() {
+					denyVisibility = common.Object{
+						"platform_id":     existingPlatformID,
+						"service_plan_id": existingPlanIDs[0],
+						"effect":          "deny",
+					}
+				})
+
+				It("returns 400 when the effect is unknown", func() {
+					denyVisibility["effect"] = "hide"
+					ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(denyVisibility).
+						Expect().
+						Status(http.StatusBadRequest)
+				})
+
+				It("returns 400 when the effect of an existing visibility is reset", func() {
+					id := ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(denyVisibility).
+						Expect().
+						Status(http.StatusCreated).
+						JSON().Object().Value("id").String().Raw()
+
+					ctx.SMWithOAuth.PATCH("/v1/visibilities/" + id).
+						WithJSON(common.Object{"effect": ""}).
+						Expect().
+						Status(http.StatusBadRequest)
+					ctx.SMWithOAuth.PATCH("/v1/visibilities/" + id).
+						WithJSON(common.Object{"effect": "hide"}).
+						Expect().
+						Status(http.StatusBadRequest)
+					ctx.SMWithOAuth.GET("/v1/visibilities/"+id).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Object().ValueEqual("effect", "deny")
+				})
+
+				It("returns 400 when no platform is specified", func() {
+					delete(denyVisibility, "platform_id")
+					ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(denyVisibility).
+						Expect().
+						Status(http.StatusBadRequest)
+				})
+
+				It("excludes the platform from a public plan", func() {
+					ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(common.Object{"service_plan_id": existingPlanIDs[0]}).
+						Expect().
+						Status(http.StatusCreated)
+					ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(denyVisibility).
+						Expect().
+						Status(http.StatusCreated).
+						JSON().Object().ContainsMap(denyVisibility)
+
+					ctx.SMWithBasic.GET("/v1/visibilities").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.visibilities").Array().Empty()
+
+					platform := ctx.RegisterPlatform()
+					ctx.SM.GET("/v1/visibilities").
+						WithBasicAuth(platform.Credentials.Basic.Username, platform.Credentials.Basic.Password).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.visibilities[*].service_plan_id").Array().ContainsOnly(existingPlanIDs[0])
+				})
+
+				It("excludes the platform from a plan of a visible service offering", func() {
+					serviceOfferingID := ctx.SMWithOAuth.GET("/v1/service_plans/" + existingPlanIDs[0].(string)).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Object().Value("service_offering_id").String().Raw()
+					ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(common.Object{
+							"platform_id":         existingPlatformID,
+							"service_offering_id": serviceOfferingID,
+						}).
+						Expect().
+						Status(http.StatusCreated)
+					ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(denyVisibility).
+						Expect().
+						Status(http.StatusCreated)
+
+					ctx.SMWithBasic.GET("/v1/visibilities").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.visibilities[*].service_plan_id").Array().NotContains(existingPlanIDs[0])
+				})
+			})
+
+			Describe("bulk", func() {
+				listVisibilityIDs := func() []interface{} {
+					return ctx.SMWithOAuth.GET("/v1/visibilities").
+						Expect().
+						Status(http.StatusOK).
+						JSON().Path("$.visibilities[*].id").Array().Raw()
+				}
+
+				It("returns 400 when there are no operations", func() {
+					ctx.SMWithOAuth.POST("/v1/visibilities/bulk").
+						WithJSON(common.Object{}).
+						Expect().
+						Status(http.StatusBadRequest)
+				})
+
+				It("returns 400 without creating anything when operations are invalid", func() {
+					results := ctx.SMWithOAuth.POST("/v1/visibilities/bulk").
+						WithJSON(common.Object{
+							"create": common.Array{
+								postVisibilityRequestNoLabels,
+								common.Object{"platform_id": existingPlatformID},
+							},
+							"delete": common.Array{""},
+						}).
+						Expect().
+						Status(http.StatusBadRequest).
+						JSON().Object().Value("results").Array()
+					results.Length().Equal(2)
+					results.Element(0).Object().
+						ValueEqual("item", "create[1]").
+						ValueEqual("error", "BadRequest")
+					results.Element(1).Object().
+						ValueEqual("item", "delete[0]").
+						ValueEqual("error", "BadRequest")
+
+					Expect(listVisibilityIDs()).To(BeEmpty())
+				})
+
+				It("returns 404 for each missing visibility to delete without executing any operation", func() {
+					results := ctx.SMWithOAuth.POST("/v1/visibilities/bulk").
+						WithJSON(common.Object{
+							"create": common.Array{postVisibilityRequestNoLabels},
+							"delete": common.Array{"missing-visibility-id", "another-missing-visibility-id"},
+						}).
+						Expect().
+						Status(http.StatusNotFound).
+						JSON().Object().Value("results").Array()
+					results.Length().Equal(2)
+					results.Element(0).Object().ValueEqual("item", "delete[0]")
+					results.Element(1).Object().ValueEqual("item", "delete[1]")
+
+					Expect(listVisibilityIDs()).To(BeEmpty())
+				})
+
+				It("creates and deletes visibilities in one transaction", func() {
+					existingVisibilityID := ctx.SMWithOAuth.POST("/v1/visibilities").
+						WithJSON(postVisibilityRequestNoLabels).
+						Expect().
+						Status(http.StatusCreated).
+						JSON().Object().Value("id").String().Raw()
+
+					results := ctx.SMWithOAuth.POST("/v1/visibilities/bulk").
+						WithJSON(common.Object{
+							"create": common.Array{
+								common.Object{"platform_id": existingPlatformID, "service_plan_id": existingPlanIDs[1]},
+							},
+							"delete": common.Array{existingVisibilityID},
+						}).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Object().Value("results").Array()
+					results.Length().Equal(2)
+					results.Element(0).Object().
+						ValueEqual("item", "create[0]").
+						ValueEqual("operation", "create")
+					results.Element(1).Object().
+						ValueEqual("item", "delete[0]").
+						ValueEqual("operation", "delete").
+						ValueEqual("id", existingVisibilityID)
+
+					createdVisibilityID := results.Element(0).Object().Value("id").String().Raw()
+					Expect(listVisibilityIDs()).To(ConsistOf(createdVisibilityID))
+				})
+
+				It("rolls back all operations when one of them fails", func() {
+					ctx.SMWithOAuth.POST("/v1/visibilities/bulk").
+						WithJSON(common.Object{
+							"create": common.Array{
+								postVisibilityRequestNoLabels,
+								postVisibilityRequestNoLabels,
+							},
+						}).
+						Expect().
+						Status(http.StatusConflict).
+						JSON().Object().Value("results").Array().
+						Element(0).Object().
+						ValueEqual("item", "create[1]").
+						ValueEqual("error", "Conflict")
+
+					Expect(listVisibilityIDs()).To(BeEmpty())
+				})
+
+				It("creates a visibility for each selected plan and platform", func() {
+					platformIDs := make([]string, 0)
+					for i := 0; i < 2; i++ {
+						platformJSON := common.GenerateRandomPlatform()
+						platformJSON["labels"] = common.Object{"onboarding": common.Array{"bulk"}}
+						platformIDs = append(platformIDs, common.RegisterPlatformInSM(platformJSON, ctx.SMWithOAuth).ID)
+					}
+
+					results := ctx.SMWithOAuth.POST("/v1/visibilities/bulk").
+						WithJSON(common.Object{
+							"create": common.Array{postVisibilityRequestNoLabels},
+							"selectors": common.Object{
+								"service_plans": fmt.Sprintf("id in [%s||%s]", existingPlanIDs[0], existingPlanIDs[1]),
+								"platforms":     "onboarding = bulk",
+							},
+						}).
+						Expect().
+						Status(http.StatusOK).
+						JSON().Object().Value("results").Array()
+					results.Length().Equal(5)
+					results.Element(0).Object().ValueEqual("item", "create[0]")
+					results.Element(1).Object().ValueEqual("item", "selectors[0]")
+					results.Element(4).Object().ValueEqual("item", "selectors[3]")
+
+					for _, platformID := range platformIDs {
+						ctx.SMWithOAuth.GET("/v1/visibilities").
+							WithQuery("fieldQuery", "platform_id = "+platformID).
+							Expect().
+							Status(http.StatusOK).
+							JSON().Path("$.visibilities[*].service_plan_id").Array().ContainsOnly(existingPlanIDs[0], existingPlanIDs[1])
+					}
+				})
+			})
+
 			Describe("PATCH", func() {
 				var existingVisibilityID string
 				var existingVisibilityReqBody common.Object